@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pirmd/gostore/util"
+)
+
+// Aliases lists the known aliases of the given field (like the variants of
+// authors' names recorded by the authornormalizer module) and their canonical
+// value. If aliases are provided, only the corresponding aliases are listed.
+func (gs *Gostore) Aliases(field string, aliases []string) error {
+	known, err := gs.store.Aliases(field)
+	if err != nil {
+		return fmt.Errorf("listing aliases of '%s' failed: %s", field, err)
+	}
+
+	names := make([]string, 0, len(known))
+	for alias := range known {
+		if len(aliases) > 0 && !containsFold(aliases, alias) {
+			continue
+		}
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	for _, alias := range names {
+		gs.ui.Printf("%s -> %s\n", alias, known[alias])
+	}
+
+	return nil
+}
+
+// DeleteAliases removes aliases of the given field so that they are no more
+// resolved to their canonical value, for example when two different authors
+// have been wrongly recognized as variants of the same name.
+func (gs *Gostore) DeleteAliases(field string, aliases []string) error {
+	known, err := gs.store.Aliases(field)
+	if err != nil {
+		return fmt.Errorf("deleting aliases of '%s' failed: %s", field, err)
+	}
+
+	var delErr util.MultiErrors
+	for _, alias := range aliases {
+		if _, exists := known[alias]; !exists {
+			delErr.Add(fmt.Errorf("deleting alias '%s' of '%s' failed: unknown alias", alias, field))
+			continue
+		}
+
+		gs.log.Printf("Deleting alias '%s' of '%s'", alias, field)
+		if !gs.pretend {
+			if err := gs.store.DeleteAlias(field, alias); err != nil {
+				delErr.Add(fmt.Errorf("deleting alias '%s' of '%s' failed: %s", alias, field, err))
+				continue
+			}
+		}
+	}
+
+	return delErr.Err()
+}
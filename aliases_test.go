@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+func TestAliases(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	for alias, canonical := range map[string]string{"V. Hugo": "Victor Hugo", "J. Verne": "Jules Verne"} {
+		if err := gs.store.SetAlias("Authors", alias, canonical); err != nil {
+			t.Fatalf("Fail to set alias '%s': %v", alias, err)
+		}
+	}
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	if err := gs.Aliases("Authors", nil); err != nil {
+		t.Fatalf("Fail to list aliases: %v", err)
+	}
+	if got, want := stdout.String(), "J. Verne -> Jules Verne\nV. Hugo -> Victor Hugo\n"; got != want {
+		t.Errorf("Fail to list aliases.\nWant: %q\nGot : %q", want, got)
+	}
+
+	if err := gs.DeleteAliases("Authors", []string{"V. Hugo", "H. Balzac"}); err == nil {
+		t.Errorf("Deleting an unknown alias should fail")
+	}
+
+	if alias, err := gs.store.Alias("Authors", "V. Hugo"); err != nil || alias != "" {
+		t.Errorf("Fail to delete alias 'V. Hugo', got '%s' (err: %v)", alias, err)
+	}
+	if alias, err := gs.store.Alias("Authors", "J. Verne"); err != nil || alias != "Jules Verne" {
+		t.Errorf("Deleting an alias should not modify other aliases, got '%s' (err: %v)", alias, err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/ui"
+)
+
+// Browse opens a full-screen browser of the collection. Records are searched
+// as the user types a query, following bleve's search syntax
+// (https://blevesearch.com/docs/Query-String-Query/), and can be modified in
+// place.
+// Browse is only available if the fullscreen User Interface is selected.
+func (gs *Gostore) Browse() error {
+	browser, ok := gs.ui.(ui.Browser)
+	if !ok {
+		return fmt.Errorf("browsing the collection failed: browsing needs the fullscreen user interface")
+	}
+
+	var updated store.Records
+	err := browser.Browse(gs.browseSearch, func(orig, edited map[string]interface{}) error {
+		r, err := gs.browseUpdate(orig, edited)
+		if err != nil {
+			return err
+		}
+		updated = append(updated, r)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("browsing the collection failed: %s", err)
+	}
+
+	if len(updated) != 0 {
+		gs.ui.PrettyPrint(updated.Flatted()...)
+		if err := gs.runHooks("post-update", updated...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// browseSearch retrieves the records matching query, all records being
+// retrieved if query is empty.
+func (gs *Gostore) browseSearch(query string) ([]map[string]interface{}, error) {
+	var records store.Records
+	var err error
+
+	if query == "" {
+		records, err = gs.store.ReadAll()
+	} else {
+		records, err = gs.store.ReadQuery(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var mdata []map[string]interface{}
+	for _, r := range records {
+		m := r.Data()
+		m[store.KeyField] = r.Key()
+		mdata = append(mdata, m)
+	}
+
+	return mdata, nil
+}
+
+// browseUpdate updates the browsed record orig with its edited version.
+func (gs *Gostore) browseUpdate(orig, edited map[string]interface{}) (*store.Record, error) {
+	key, _ := orig[store.KeyField].(string)
+	r, err := gs.store.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	mdata := make(map[string]interface{}, len(edited))
+	for k, v := range edited {
+		if k != store.KeyField {
+			mdata[k] = v
+		}
+	}
+
+	if err := gs.store.Validate(mdata); err != nil {
+		return nil, err
+	}
+
+	if err := gs.update(r, mdata); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
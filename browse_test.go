@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func TestBrowse(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	testData := map[string]map[string]interface{}{
+		"a.epub": {"Type": "book/epub", "Title": "Les misérables", "Authors": []string{"Victor Hugo"}},
+		"b.epub": {"Type": "book/epub", "Title": "Les fleurs du mal", "Authors": []string{"Charles Baudelaire"}},
+	}
+	for key, data := range testData {
+		if _, err := gs.store.Create(key, data, verify.MockROFile("0123456789")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	t.Run("NeedsFullscreen", func(t *testing.T) {
+		if err := gs.Browse(); err == nil {
+			t.Errorf("Browse should fail without the fullscreen user interface")
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		all, err := gs.browseSearch("")
+		if err != nil {
+			t.Fatalf("Fail to search all records: %v", err)
+		}
+		if len(all) != len(testData) {
+			t.Errorf("Fail to search all records: got %d records, want %d", len(all), len(testData))
+		}
+
+		found, err := gs.browseSearch("Authors:Hugo")
+		if err != nil {
+			t.Fatalf("Fail to search records: %v", err)
+		}
+		if len(found) != 1 || found[0][store.KeyField] != "a.epub" {
+			t.Errorf("Fail to search records: got %v", found)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		orig, err := gs.browseSearch("Authors:Hugo")
+		if err != nil || len(orig) != 1 {
+			t.Fatalf("Fail to search records: %v", err)
+		}
+
+		edited := make(map[string]interface{})
+		for k, v := range orig[0] {
+			edited[k] = v
+		}
+		edited["Title"] = "Notre-Dame de Paris"
+
+		if _, err := gs.browseUpdate(orig[0], edited); err != nil {
+			t.Fatalf("Fail to update record: %v", err)
+		}
+
+		r, err := gs.store.Read("a.epub")
+		if err != nil {
+			t.Fatalf("Fail to read updated record: %v", err)
+		}
+		if got := r.Get("Title"); got != "Notre-Dame de Paris" {
+			t.Errorf("Fail to update record: got Title %v", got)
+		}
+		if r.Get(store.KeyField) != nil {
+			t.Errorf("Record's key should not be stored as a field")
+		}
+	})
+}
@@ -3,11 +3,15 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/pirmd/clapp"
+
+	"github.com/pirmd/gostore/util"
 )
 
 func newApp(cfg *Config) *clapp.Command {
@@ -45,6 +49,12 @@ func newApp(cfg *Config) *clapp.Command {
 			Var:   &cfg.Store.Path,
 		},
 
+		{
+			Name:  "collection",
+			Usage: "Name of the collection to work with, as defined in the configuration file. Default to the collection defined at the configuration's top-level.",
+			Var:   &cfg.Collection,
+		},
+
 		{
 			Name:  "pretend",
 			Usage: "Operations that modify the collection are simulated.",
@@ -57,6 +67,12 @@ func newApp(cfg *Config) *clapp.Command {
 			Var:   &cfg.UI.Auto,
 		},
 
+		{
+			Name:  "fullscreen",
+			Usage: "Use a full-screen terminal user interface to edit or merge records.",
+			Var:   &cfg.UI.Fullscreen,
+		},
+
 		{
 			Name:  "style",
 			Usage: "Style for printing records' details. Available styles are defined in the configuration file.",
@@ -89,6 +105,42 @@ func newApp(cfg *Config) *clapp.Command {
 		},
 	})
 
+	var calibreRoot string
+	var calibreFormats []string
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "import-calibre",
+		Usage: "Import the books of a Calibre library into the collection. Metadata known by Calibre are merged into the new records before import modules are run.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "format",
+				Usage: "Preferred format of the books to import if several are available (for example EPUB). Can be repeated, first listed formats are preferred. Default to the first available format in alphabetical order.",
+				Var:   &calibreFormats,
+			},
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "library",
+				Usage: "Path to the Calibre library (folder containing Calibre's 'metadata.db').",
+				Var:   &calibreRoot,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.ImportCalibre(calibreRoot, calibreFormats); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
 	var recordIDs []string
 	var recordIDsArg = &clapp.Arg{
 		Name:     "name",
@@ -117,7 +169,7 @@ func newApp(cfg *Config) *clapp.Command {
 		},
 
 		Execute: func() error {
-			gs, err := openGostore(cfg)
+			gs, err := openGostoreReadOnly(cfg)
 			if err != nil {
 				return err
 			}
@@ -138,6 +190,7 @@ func newApp(cfg *Config) *clapp.Command {
 	})
 
 	var query string
+	var allCollections bool
 	cmd.SubCommands.Add(&clapp.Command{
 		Name:  "search",
 		Usage: "Search the collection's records matching the given query.",
@@ -152,15 +205,27 @@ func newApp(cfg *Config) *clapp.Command {
 
 		Flags: clapp.Flags{
 			sortByFlag,
+			{
+				Name:  "all-collections",
+				Usage: "Search all the collections defined in the configuration file. Records' collection is available in the 'Collection' field.",
+				Var:   &allCollections,
+			},
 		},
 
 		Execute: func() error {
-			gs, err := openGostore(cfg)
+			gs, err := openGostoreReadOnly(cfg)
 			if err != nil {
 				return err
 			}
 			defer gs.Close()
 
+			if allCollections {
+				if err := gs.ListQueryAll(query, sortBy); err != nil {
+					return err
+				}
+				return nil
+			}
+
 			if err := gs.ListQuery(query, sortBy); err != nil {
 				return err
 			}
@@ -212,10 +277,42 @@ func newApp(cfg *Config) *clapp.Command {
 	})
 
 	cmd.SubCommands.Add(&clapp.Command{
-		Name:  "delete",
-		Usage: "Delete an existing record from the collection.",
+		Name:  "browse",
+		Usage: "Browse the collection in full-screen, searching records as a query is typed and editing them in place. Needs the fullscreen user interface, selected by flag '--fullscreen' or in the configuration file.",
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Browse(); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var pipelineName, processQuery string
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "process",
+		Usage: "Apply a user defined pipeline of modules to existing records of the collection. Changes are displayed and confirmation is asked before updating each record, unless flag '--auto' is used. If no record's name nor query is provided, all records are processed.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "query",
+				Usage: "Select records to process using a query instead of their name. Query pattern follows blevesearch query language (https://blevesearch.com/docs/Query-String-Query/).",
+				Var:   &processQuery,
+			},
+		},
 
 		Args: clapp.Args{
+			{
+				Name:  "pipeline",
+				Usage: "Name of the pipeline to apply, as defined in gostore's configuration.",
+				Var:   &pipelineName,
+			},
 			recordIDsArg,
 		},
 
@@ -226,25 +323,38 @@ func newApp(cfg *Config) *clapp.Command {
 			}
 			defer gs.Close()
 
-			if err := gs.Delete(recordIDs); err != nil {
+			if err := gs.Process(pipelineName, recordIDs, processQuery); err != nil {
 				return err
 			}
 			return nil
 		},
 	})
 
-	var dstFolder string
+	var setNames []string
+	var setQuery string
+	var assignments []string
 	cmd.SubCommands.Add(&clapp.Command{
-		Name:  "export",
-		Usage: "Copy a record's media file from the collection to the given destination.",
+		Name:  "set",
+		Usage: "Modify fields of the records selected by their name or by a query. Assignments are either 'Field:value' (or 'Field=value') to set a field, 'Field+=value' or 'Field-=value' to add or remove a value from a list, or 'Field-' to remove a field. Changes are processed by the update modules and displayed, confirmation being asked before updating each record unless flag '--auto' is used.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "name",
+				Usage: "Select records to modify by their name. Name can be specified using a glob pattern. Flag can be repeated.",
+				Var:   &setNames,
+			},
+			{
+				Name:  "query",
+				Usage: "Select records to modify using a query. Query pattern follows blevesearch query language (https://blevesearch.com/docs/Query-String-Query/).",
+				Var:   &setQuery,
+			},
+		},
 
 		Args: clapp.Args{
-			recordIDsArg,
 			{
-				Name:     "dst",
-				Usage:    "Destination folder where the record needs to be exported to. Default to current working directory.",
-				Var:      &dstFolder,
-				Optional: true,
+				Name:  "assignment",
+				Usage: "Modification to apply to the selected records' fields.",
+				Var:   &assignments,
 			},
 		},
 
@@ -255,32 +365,83 @@ func newApp(cfg *Config) *clapp.Command {
 			}
 			defer gs.Close()
 
-			if err := gs.Export(dstFolder, recordIDs); err != nil {
+			if err := gs.Set(assignments, setNames, setQuery); err != nil {
 				return err
 			}
 			return nil
 		},
 	})
 
+	var userNames []string
+	var userQuery string
+	userSelectFlags := clapp.Flags{
+		{
+			Name:  "name",
+			Usage: "Select records by their name. Name can be specified using a glob pattern. Flag can be repeated.",
+			Var:   &userNames,
+		},
+		{
+			Name:  "query",
+			Usage: "Select records using a query. Query pattern follows blevesearch query language (https://blevesearch.com/docs/Query-String-Query/).",
+			Var:   &userQuery,
+		},
+	}
+
+	var tags []string
+	var untag bool
 	cmd.SubCommands.Add(&clapp.Command{
-		Name:  "check",
-		Usage: "Verify collection's consistency and repairs or reports found inconsistencies.",
+		Name:  "tag",
+		Usage: "Add tags to the records selected by their name or by a query. Tags are kept apart from the records' metadata so that reading again the media's metadata never modifies them, and can be searched for using a query like 'Tags:classic'.",
 
-		Flags: clapp.Flags{
+		Flags: append(clapp.Flags{
 			{
-				Name:  "delete-ghosts",
-				Usage: "Delete any database entries that does not correspond to an existing file in the store's filesystem (so called ghost record)",
-				Var:   &cfg.DeleteGhosts,
+				Name:  "remove",
+				Usage: "Remove the tags instead of adding them.",
+				Var:   &untag,
+			},
+		}, userSelectFlags...),
+
+		Args: clapp.Args{
+			{
+				Name:  "tag",
+				Usage: "Tag to add to or remove from the selected records.",
+				Var:   &tags,
 			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Tag(tags, untag, userNames, userQuery); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var shelves []string
+	var unshelve bool
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "shelve",
+		Usage: "Put the records selected by their name or by a query on shelves. Shelves are virtual collections kept apart from the records' metadata that can be listed using a query like 'Shelves:\"to sort\"'.",
+
+		Flags: append(clapp.Flags{
 			{
-				Name:  "delete-orphans",
-				Usage: "Delete any file of the store's filesystem that is not recorded in the store's database.",
-				Var:   &cfg.DeleteOrphans,
+				Name:  "remove",
+				Usage: "Take the records off the shelves instead of putting them on.",
+				Var:   &unshelve,
 			},
+		}, userSelectFlags...),
+
+		Args: clapp.Args{
 			{
-				Name:  "import-orphans",
-				Usage: "Delete any database entry that does not correspond to an existing file in the store's filesystem (so called ghost record)",
-				Var:   &cfg.ImportOrphans,
+				Name:  "shelf",
+				Usage: "Shelf to put the selected records on or to take them off.",
+				Var:   &shelves,
 			},
 		},
 
@@ -291,16 +452,33 @@ func newApp(cfg *Config) *clapp.Command {
 			}
 			defer gs.Close()
 
-			if err := gs.CheckAndRepair(); err != nil {
+			if err := gs.Shelve(shelves, unshelve, userNames, userQuery); err != nil {
 				return err
 			}
 			return nil
 		},
 	})
 
+	var status, readOn string
 	cmd.SubCommands.Add(&clapp.Command{
-		Name:  "rebuild-index",
-		Usage: "Deletes then rebuild the collection's index from scratch. Useful for example to implement a new mapping strategy or if things are really going bad.",
+		Name:  "status",
+		Usage: "Set the reading status (to-read, reading or read) of the records selected by their name or by a query. Marking records as read also records the date they were read on in field 'ReadDates'. Reading status is kept apart from the records' metadata and can be searched for using a query like 'ReadingStatus:reading'.",
+
+		Flags: append(clapp.Flags{
+			{
+				Name:  "on",
+				Usage: "Date the records were read on. Default to today.",
+				Var:   &readOn,
+			},
+		}, userSelectFlags...),
+
+		Args: clapp.Args{
+			{
+				Name:  "status",
+				Usage: "Reading status, either to-read, reading or read.",
+				Var:   &status,
+			},
+		},
 
 		Execute: func() error {
 			gs, err := openGostore(cfg)
@@ -309,16 +487,27 @@ func newApp(cfg *Config) *clapp.Command {
 			}
 			defer gs.Close()
 
-			if err := gs.RebuildIndex(); err != nil {
+			if err := gs.SetReadingStatus(status, readOn, userNames, userQuery); err != nil {
 				return err
 			}
 			return nil
 		},
 	})
 
+	var rating int64
 	cmd.SubCommands.Add(&clapp.Command{
-		Name:  "fields",
-		Usage: "Lists fields names that are available for search or for templates. Some fields might only be available for a given media Type.",
+		Name:  "rate",
+		Usage: "Rate the records selected by their name or by a query. Rating is kept apart from the records' metadata and can be searched for using a query like 'Rating:>=4'.",
+
+		Flags: userSelectFlags,
+
+		Args: clapp.Args{
+			{
+				Name:  "rating",
+				Usage: "Rating from 1 to 5, 0 removing the records' rating.",
+				Var:   &rating,
+			},
+		},
 
 		Execute: func() error {
 			gs, err := openGostore(cfg)
@@ -327,16 +516,178 @@ func newApp(cfg *Config) *clapp.Command {
 			}
 			defer gs.Close()
 
-			if err := gs.Fields(); err != nil {
+			if err := gs.Rate(int(rating), userNames, userQuery); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var serieNames []string
+	seriesCmd := &clapp.Command{
+		Name:  "series",
+		Usage: "List the collection's series with the positions owned and report missing or duplicated positions. If no serie's name is provided, list all series.",
+
+		Args: clapp.Args{
+			{
+				Name:     "serie",
+				Usage:    "Name of the serie to report on.",
+				Var:      &serieNames,
+				Optional: true,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostoreReadOnly(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Series(serieNames); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	var serieName, serieQuery string
+	var serieNumbered bool
+	seriesCmd.SubCommands.Add(&clapp.Command{
+		Name:  "assign",
+		Usage: "Assign a serie to the records matching the given query.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "query",
+				Usage: "Select records to assign the serie to. Query pattern follows blevesearch query language (https://blevesearch.com/docs/Query-String-Query/).",
+				Var:   &serieQuery,
+			},
+			{
+				Name:  "numbered",
+				Usage: "Give records consecutive positions in the serie, following the order specified by flag '--sort'.",
+				Var:   &serieNumbered,
+			},
+			sortByFlag,
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "serie",
+				Usage: "Name of the serie to assign.",
+				Var:   &serieName,
+			},
+		},
+
+		Execute: func() error {
+			if serieQuery == "" {
+				return fmt.Errorf("no records selected: flag '--query' is missing")
+			}
+
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.AssignSerie(serieName, serieQuery, serieNumbered, sortBy); err != nil {
 				return err
 			}
 			return nil
 		},
 	})
+	cmd.SubCommands.Add(seriesCmd)
 
+	var aliasField string
+	var aliasNames []string
+	var aliasDelete bool
 	cmd.SubCommands.Add(&clapp.Command{
-		Name:  "config",
-		Usage: "Lists known modules, analyzers or styles that can be used for gostore configuration or invocation.",
+		Name:  "aliases",
+		Usage: "List the aliases of a field and their canonical value. Aliases are variants of a field's value (like an author's name) recorded by modules (like authornormalizer) so that they are always resolved to the same canonical value. If no alias is provided, list all the field's aliases.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "delete",
+				Usage: "Delete the given aliases instead of listing them, for example when different values have been wrongly recognized as variants of each other.",
+				Var:   &aliasDelete,
+			},
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "field",
+				Usage: "Name of the field (like Authors).",
+				Var:   &aliasField,
+			},
+			{
+				Name:     "alias",
+				Usage:    "Alias to list or to delete.",
+				Var:      &aliasNames,
+				Optional: true,
+			},
+		},
+
+		Execute: func() error {
+			if !aliasDelete {
+				gs, err := openGostoreReadOnly(cfg)
+				if err != nil {
+					return err
+				}
+				defer gs.Close()
+
+				if err := gs.Aliases(aliasField, aliasNames); err != nil {
+					return err
+				}
+				return nil
+			}
+
+			if len(aliasNames) == 0 {
+				return fmt.Errorf("no aliases to delete")
+			}
+
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.DeleteAliases(aliasField, aliasNames); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var statsAsJSON bool
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "stats",
+		Usage: "Report an overview of the collection: records and disk usage by Type, QALevel distribution, records missing key fields, import activity over time and most frequent authors, publishers or subjects. Statistics are printed using the 'stats' formatter of the selected style.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "json",
+				Usage: "Print statistics as JSON.",
+				Var:   &statsAsJSON,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostoreReadOnly(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Stats(statsAsJSON); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "duplicates",
+		Usage: "Scan the collection for possible duplicates, either matching the queries of the import's dupfinder modules or having the same file's hash. For each duplicate, differences are displayed and the user chooses to keep both records, to merge the duplicate into the other record using user defined's merger or to delete the duplicate. If flag '--auto' is used, duplicates are only reported.",
 
 		Execute: func() error {
 			gs, err := openGostore(cfg)
@@ -345,6 +696,444 @@ func newApp(cfg *Config) *clapp.Command {
 			}
 			defer gs.Close()
 
+			if err := gs.Duplicates(); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "delete",
+		Usage: "Delete an existing record from the collection.",
+
+		Args: clapp.Args{
+			recordIDsArg,
+		},
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Delete(recordIDs); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var dstFolder, convertTo string
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "export",
+		Usage: "Copy a record's media file from the collection to the given destination.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "convert",
+				Usage: "Convert the record's media file to the given Type (like book/kepub) using the configured converters.",
+				Var:   &convertTo,
+			},
+		},
+
+		Args: clapp.Args{
+			recordIDsArg,
+			{
+				Name:     "dst",
+				Usage:    "Destination folder where the record needs to be exported to. Default to current working directory.",
+				Var:      &dstFolder,
+				Optional: true,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostoreReadOnly(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Export(dstFolder, recordIDs, convertTo); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var deviceName string
+	var deleteUnselected bool
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "sync",
+		Usage: "Mirror the records selected by a device's query to the device (like a mounted e-reader). Only new or modified files are copied. Devices are defined in gostore's configuration.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "delete",
+				Usage: "Delete from the device the files previously synchronized that are no more selected by the device's query.",
+				Var:   &deleteUnselected,
+			},
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "device",
+				Usage: "Name of the device to synchronize with.",
+				Var:   &deviceName,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Sync(deviceName, deleteUnselected); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "check",
+		Usage: "Verify collection's consistency and repairs or reports found inconsistencies.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "delete-ghosts",
+				Usage: "Delete any database entries that does not correspond to an existing file in the store's filesystem (so called ghost record)",
+				Var:   &cfg.DeleteGhosts,
+			},
+			{
+				Name:  "delete-orphans",
+				Usage: "Delete any file of the store's filesystem that is not recorded in the store's database.",
+				Var:   &cfg.DeleteOrphans,
+			},
+			{
+				Name:  "import-orphans",
+				Usage: "Delete any database entry that does not correspond to an existing file in the store's filesystem (so called ghost record)",
+				Var:   &cfg.ImportOrphans,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.CheckAndRepair(); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "rebuild-index",
+		Usage: "Deletes then rebuild the collection's index from scratch. Useful for example to implement a new mapping strategy or if things are really going bad.",
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.RebuildIndex(); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var moveTo string
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "move",
+		Usage: "Move records to another collection. Records' files and metadata are moved as is, without going through the import modules of the destination collection.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "to",
+				Usage: "Name of the collection to move records to, as defined in the configuration file.",
+				Var:   &moveTo,
+			},
+		},
+
+		Args: clapp.Args{
+			recordIDsArg,
+		},
+
+		Execute: func() error {
+			if moveTo == "" {
+				return fmt.Errorf("no destination collection: flag '--to' is missing")
+			}
+
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Move(recordIDs, moveTo); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "serve",
+		Usage: "Keep the collection opened and run the commands of other gostore processes on their behalf until interrupted. Commands working on a served collection are delegated to the server instead of waiting for the collection to be released. The server cannot interact with the user, so that commands modifying the collection are only delegated if flag '--auto' or '--pretend' is used. Commands that need the collection to be closed (migrate or restore) cannot be delegated.",
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Serve(); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var dryRun bool
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "migrate",
+		Usage: "Upgrade the collection's database to the current format. A copy of the database is saved before migrating. Migrations are otherwise automatically applied when opening the collection.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "dry-run",
+				Usage: "Only list the migrations to apply without modifying the collection.",
+				Var:   &dryRun,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := newGostore(cfg)
+			if err != nil {
+				return err
+			}
+
+			if err := gs.Migrate(dryRun); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var archive, since string
+	var withIndex bool
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "backup",
+		Usage: "Save a consistent snapshot of the collection (database, media files and optionally index) into an archive.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "since",
+				Usage: "Only save media files of records updated after the given date (incremental backup). The database is always fully saved.",
+				Var:   &since,
+			},
+			{
+				Name:  "with-index",
+				Usage: "Save the collection's index. If not set, the index is rebuilt when restoring.",
+				Var:   &withIndex,
+			},
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "archive",
+				Usage: "Name of the archive to create.",
+				Var:   &archive,
+			},
+		},
+
+		Execute: func() error {
+			var stamp time.Time
+			if since != "" {
+				var err error
+				if stamp, err = util.ParseTime(since); err != nil {
+					return fmt.Errorf("cannot understand date '%s': %v", since, err)
+				}
+			}
+
+			gs, err := openGostoreReadOnly(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Backup(archive, stamp, withIndex); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var rebuildIndex bool
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "restore",
+		Usage: "Restore the collection from an archive generated by 'backup'. Archive's integrity is checked before modifying the collection. Incremental backups are to be restored on top of the backup they are based on.",
+
+		Flags: clapp.Flags{
+			{
+				Name:  "rebuild-index",
+				Usage: "Rebuild the collection's index even if the archive contains it.",
+				Var:   &rebuildIndex,
+			},
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "archive",
+				Usage: "Name of the archive to restore.",
+				Var:   &archive,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := newGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Restore(archive, rebuildIndex); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	var dumpFile string
+	var dumpFields []string
+	var dumpFormat, listSep = "jsonl", "|"
+	var dumpFormatFlag = &clapp.Flag{
+		Name:  "format",
+		Usage: "Format of the dump. Available formats are: jsonl (JSON Lines), csv or yaml. Default to jsonl.",
+		Var:   &dumpFormat,
+	}
+	var listSepFlag = &clapp.Flag{
+		Name:  "list-sep",
+		Usage: "Separator of the items of multi-valued fields for csv format. Default to '|'.",
+		Var:   &listSep,
+	}
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "dump",
+		Usage: "Serialize all collection's records (name, creation and update time stamps and metadata) for review or versioning.",
+
+		Flags: clapp.Flags{
+			dumpFormatFlag,
+			listSepFlag,
+			{
+				Name:  "fields",
+				Usage: "Fields to dump for csv format. Default to all known fields.",
+				Var:   &dumpFields,
+			},
+		},
+
+		Args: clapp.Args{
+			{
+				Name:     "dst",
+				Usage:    "File to dump records to. Default to standard output.",
+				Var:      &dumpFile,
+				Optional: true,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostoreReadOnly(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			w := os.Stdout
+			if dumpFile != "" {
+				if w, err = os.Create(dumpFile); err != nil {
+					return err
+				}
+				defer w.Close()
+			}
+
+			if err := gs.Dump(w, dumpFormat, dumpFields, listSep); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "load",
+		Usage: "Create or update collection's records from a file generated by 'dump'. Loaded fields replace existing ones, empty fields are removed and fields that are not in the dump are kept untouched. New records are only created if their media file already exists in the collection. Records keep their dumped time stamps, are processed by the update modules and trigger the usual hooks.",
+
+		Flags: clapp.Flags{
+			dumpFormatFlag,
+			listSepFlag,
+		},
+
+		Args: clapp.Args{
+			{
+				Name:  "src",
+				Usage: "File to load records from.",
+				Var:   &dumpFile,
+			},
+		},
+
+		Execute: func() error {
+			gs, err := openGostore(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			r, err := os.Open(dumpFile)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+
+			if err := gs.Load(r, dumpFormat, listSep); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "fields",
+		Usage: "Lists fields names that are available for search or for templates. Some fields might only be available for a given media Type.",
+
+		Execute: func() error {
+			gs, err := openGostoreReadOnly(cfg)
+			if err != nil {
+				return err
+			}
+			defer gs.Close()
+
+			if err := gs.Fields(); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+
+	cmd.SubCommands.Add(&clapp.Command{
+		Name:  "config",
+		Usage: "Lists known modules, analyzers or styles that can be used for gostore configuration or invocation.",
+
+		Execute: func() error {
 			fmt.Printf("Known Modules:\n%s\n", strings.Join(cfg.Modules(), "\n"))
 			fmt.Printf("\nKnown Analyzers:\n%s\n", strings.Join(cfg.Analyzers(), "\n"))
 			fmt.Printf("\nKnown Styles:\n%s\n", strings.Join(cfg.Styles(), "\n"))
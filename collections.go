@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	// defaultCollection is the name of the collection described by the
+	// top-level configuration.
+	defaultCollection = "default"
+
+	// collectionField is the name of the field that identifies the
+	// collection of a record when searching across collections.
+	collectionField = "Collection"
+)
+
+// collectionConfig describes a named collection. Settings that are not
+// provided fall back to the ones of the default collection.
+type collectionConfig struct {
+	// Store contains configuration for anything related to the
+	// collection's storage
+	Store *store.Config
+
+	// Import list of actions to apply when importing a record
+	Import pipelineConfig
+
+	// Update list of actions to apply when updating a record
+	Update pipelineConfig
+
+	// Formatters lists, by style, the templates to print records that
+	// replace the default collection's ones
+	Formatters map[string]map[string]string
+}
+
+func (cfg *collectionConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type rawCollectionConfig collectionConfig
+
+	raw := &rawCollectionConfig{Store: store.NewConfig()}
+	if err := unmarshal(raw); err != nil {
+		return err
+	}
+
+	*cfg = collectionConfig(*raw)
+	return nil
+}
+
+// forCollection returns the configuration of the named collection. The
+// top-level configuration is returned for the default collection.
+func (cfg *Config) forCollection(name string) (*Config, error) {
+	if name == "" || name == defaultCollection {
+		return cfg, nil
+	}
+
+	coll, exists := cfg.Collections[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown collection '%s'", name)
+	}
+
+	c := *cfg
+	c.Collection = name
+
+	storeCfg := *coll.Store
+	storeCfg.Logger = cfg.Store.Logger
+	c.Store = &storeCfg
+
+	if coll.Import != nil {
+		c.Import = coll.Import
+	}
+
+	if coll.Update != nil {
+		c.Update = coll.Update
+	}
+
+	if len(coll.Formatters) > 0 {
+		uiCfg := *cfg.UI
+		uiCfg.Formatters = make(map[string]map[string]string)
+		for style, tmpls := range cfg.UI.Formatters {
+			uiCfg.Formatters[style] = make(map[string]string)
+			for typ, tmpl := range tmpls {
+				uiCfg.Formatters[style][typ] = tmpl
+			}
+		}
+
+		for style, tmpls := range coll.Formatters {
+			if uiCfg.Formatters[style] == nil {
+				uiCfg.Formatters[style] = make(map[string]string)
+			}
+			for typ, tmpl := range tmpls {
+				uiCfg.Formatters[style][typ] = tmpl
+			}
+		}
+		c.UI = &uiCfg
+	}
+
+	return &c, nil
+}
+
+// collectionNames lists the names of the known collections, the default
+// collection first.
+func (cfg *Config) collectionNames() []string {
+	var names []string
+	for name := range cfg.Collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return append([]string{defaultCollection}, names...)
+}
+
+// name returns the name of the collection managed by gs.
+func (gs *Gostore) name() string {
+	if gs.config.Collection == "" {
+		return defaultCollection
+	}
+	return gs.config.Collection
+}
+
+// openCollection opens another collection than the one managed by gs,
+// read-only if readOnly is set.
+func (gs *Gostore) openCollection(name string, readOnly bool) (*Gostore, error) {
+	if name == gs.name() {
+		return nil, fmt.Errorf("collection '%s' is already opened", name)
+	}
+
+	cfg := *gs.config
+	cfg.Collection = name
+
+	open := openGostore
+	if readOnly {
+		open = openGostoreReadOnly
+	}
+
+	coll, err := open(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("opening collection '%s' failed: %s", name, err)
+	}
+	coll.log, coll.debug = gs.log, gs.debug
+
+	return coll, nil
+}
+
+// ListQueryAll searches all the known collections for records matching the
+// given query. Records are completed by the name of their collection in
+// the Collection field.
+func (gs *Gostore) ListQueryAll(query string, sortBy []string) error {
+	var found []map[string]interface{}
+
+	for _, name := range gs.config.collectionNames() {
+		coll := gs
+		if name != gs.name() {
+			var err error
+			if coll, err = gs.openCollection(name, true); err != nil {
+				return err
+			}
+		}
+
+		r, err := coll.store.ReadQuery(query)
+		if coll != gs {
+			coll.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("searching collection '%s' failed: %s", name, err)
+		}
+
+		for _, m := range r.Flatted() {
+			m[collectionField] = name
+			found = append(found, m)
+		}
+	}
+
+	gs.ui.PrettyPrint(util.Sort(found, sortBy)...)
+	return nil
+}
+
+// Move moves records from the collection to another one. Records' files and
+// metadata are moved as is, without going through the import modules of the
+// destination collection.
+func (gs *Gostore) Move(pattern []string, to string) error {
+	// Opening a collection sharing the same root would wait for the lock
+	// already held by gs.
+	cfg, err := gs.config.forCollection(to)
+	if err != nil {
+		return fmt.Errorf("moving records failed: %s", err)
+	}
+	cfg.expandEnv()
+
+	if sameDir(gs.root, cfg.Store.Path) {
+		return fmt.Errorf("moving records failed: collection '%s' shares the same root", to)
+	}
+
+	dst, err := gs.openCollection(to, false)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	records, err := gs.glob(pattern)
+	if err != nil {
+		return fmt.Errorf("moving '%s' failed: %s", pattern, err)
+	}
+
+	var moved store.Records
+	var moveErr util.MultiErrors
+	for _, r := range records {
+		gs.log.Printf("Moving '%s' to collection '%s'", r.Key(), to)
+
+		if err := gs.move(r, dst); err != nil {
+			moveErr.Add(fmt.Errorf("moving '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		moved = append(moved, r)
+	}
+
+	if len(moved) != 0 {
+		dst.ui.PrettyPrint(moved.Flatted()...)
+
+		if err := dst.runHooks("post-import", moved...); err != nil {
+			moveErr.Add(err)
+		}
+
+		if err := gs.runHooks("post-delete", moved...); err != nil {
+			moveErr.Add(err)
+		}
+	}
+
+	return moveErr.Err()
+}
+
+// sameDir reports whether two paths point to the same folder.
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+func (gs *Gostore) move(r *store.Record, dst *Gostore) error {
+	f, err := gs.store.OpenRecord(r)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rf, ok := f.(store.Reader)
+	if !ok {
+		return fmt.Errorf("record's file cannot be read")
+	}
+
+	r.SetFile(rf)
+	defer r.SetFile(nil)
+
+	c, err := gs.store.OpenCover(r.Key())
+	switch {
+	case err == nil:
+		defer c.Close()
+
+		rc, ok := c.(store.Reader)
+		if !ok {
+			return fmt.Errorf("record's cover cannot be read")
+		}
+
+		r.SetCover(rc)
+		defer r.SetCover(nil)
+
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if err := dst.runHooks("pre-import", r); err != nil {
+		return err
+	}
+
+	if !gs.pretend {
+		if err := dst.store.Insert(r); err != nil {
+			return err
+		}
+	}
+
+	return gs.delete(r)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func TestForCollection(t *testing.T) {
+	cfg := newConfig()
+	rawcfg := `
+ui:
+    formatters:
+        list:
+            media: '{{ .Name }}'
+            book:  '{{ .Title }}'
+import:
+    - name: mdatareader
+collections:
+    comics:
+        store:
+            path: /tmp/comics
+        formatters:
+            list:
+                book: '{{ .Title }} #{{ .Issue }}'
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	if _, err := cfg.forCollection("unknown"); err == nil {
+		t.Errorf("Selecting an unknown collection should fail")
+	}
+
+	comics, err := cfg.forCollection("comics")
+	if err != nil {
+		t.Fatalf("Fail to select collection: %v", err)
+	}
+
+	if comics.Store.Path != "/tmp/comics" || comics.Store.TypeField != "Type" {
+		t.Errorf("Collection's store config is not as expected: %+v", comics.Store)
+	}
+
+	if !reflect.DeepEqual(comics.Import, cfg.Import) {
+		t.Errorf("Collection's import should fall back to the default one")
+	}
+
+	wantFmt := map[string]string{"media": "{{ .Name }}", "book": "{{ .Title }} #{{ .Issue }}"}
+	if got := comics.UI.Formatters["list"]; !reflect.DeepEqual(got, wantFmt) {
+		t.Errorf("Collection's formatters are not as expected.\nWant: %v\nGot : %v", wantFmt, got)
+	}
+
+	if got := cfg.UI.Formatters["list"]["book"]; got != "{{ .Title }}" {
+		t.Errorf("Default collection's formatters should not be modified: got %v", got)
+	}
+
+	if got, want := cfg.collectionNames(), []string{"default", "comics"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collections' names are not as expected.\nWant: %v\nGot : %v", want, got)
+	}
+}
+
+func TestMoveAndSearchCollections(t *testing.T) {
+	comicsDir, err := verify.NewTestFolder(t.Name() + "_comics")
+	if err != nil {
+		t.Fatalf("Failed to create comics' folder: %v", err)
+	}
+	defer comicsDir.Clean()
+
+	cfg := newConfig()
+	rawcfg := `
+collections:
+    comics:
+        store:
+            path: ` + comicsDir.Root + `
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testCases := []string{
+		filepath.Join(testdataPath, "pg11-images.epub"),
+		filepath.Join(testdataPath, "pg1661-images.epub"),
+	}
+	if err := gs.Import(testCases); err != nil {
+		t.Fatalf("Fail to import epubs '%s': %v", testCases, err)
+	}
+
+	r, err := gs.store.Read("pg1661-images.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+	r.SetCover(strings.NewReader("cover"))
+	if err := gs.store.Update(r.Key(), r); err != nil {
+		t.Fatalf("Fail to add record's cover: %v", err)
+	}
+
+	if err := gs.Move([]string{"pg1661-images.epub"}, "comics"); err != nil {
+		t.Fatalf("Fail to move record: %v", err)
+	}
+
+	if exists, _ := gs.store.Exists("pg1661-images.epub"); exists {
+		t.Errorf("Moved record should be removed from the collection")
+	}
+
+	comics, err := gs.openCollection("comics", true)
+	if err != nil {
+		t.Fatalf("Fail to open collection: %v", err)
+	}
+	if exists, err := comics.store.Exists("pg1661-images.epub"); err != nil || !exists {
+		t.Errorf("Moved record should exist in the destination collection (%v)", err)
+	}
+	if c, err := comics.store.OpenCover("pg1661-images.epub"); err != nil {
+		t.Errorf("Moved record's cover should exist in the destination collection (%v)", err)
+	} else {
+		c.Close()
+	}
+	comics.Close()
+
+	if err := gs.Move([]string{"pg11-images.epub"}, "default"); err == nil {
+		t.Errorf("Moving records to the same collection should fail")
+	}
+
+	gs.config.Collections["mirror"] = &collectionConfig{Store: &store.Config{Path: gs.root + "/"}}
+	start := time.Now()
+	if err := gs.Move([]string{"pg11-images.epub"}, "mirror"); err == nil || !strings.Contains(err.Error(), "shares the same root") {
+		t.Errorf("Moving records to a collection sharing the same root should fail, got: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Moving records to a collection sharing the same root should not wait for collection's lock")
+	}
+	delete(gs.config.Collections, "mirror")
+
+	_ = stdout.String()
+	if err := stdout.Start(); err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	if err := gs.ListQueryAll("*", []string{"Name"}); err != nil {
+		t.Fatalf("Fail to search all collections: %v", err)
+	}
+
+	if got, want := stdout.String(), "pg11-images.epub\npg1661-images.epub\n"; got != want {
+		t.Errorf("Search across collections failed.\nWant: %q\nGot : %q", want, got)
+	}
+}
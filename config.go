@@ -1,9 +1,15 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"time"
 
+	"github.com/pirmd/gostore/media"
 	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/modules/converter"
+	"github.com/pirmd/gostore/modules/dupfinder"
 	"github.com/pirmd/gostore/store"
 	"github.com/pirmd/gostore/ui/cli"
 )
@@ -40,11 +46,43 @@ type Config struct {
 	// UI contains configuration for anything related to user interface
 	UI *cli.Config
 
-	// Import list of actions to apply when importing a record
-	Import []*moduleConfig
+	// Import list of actions to apply when importing a record. Actions can
+	// be defined per media Type
+	Import pipelineConfig
 
-	// Update list of actions to apply when importing a record
-	Update []*moduleConfig
+	// Update list of actions to apply when importing a record. Actions can
+	// be defined per media Type
+	Update pipelineConfig
+
+	// Pipelines lists, by name, user defined series of modules that can be
+	// applied to existing records
+	Pipelines map[string]pipelineConfig
+
+	// Converters lists the available conversions of records' files to
+	// another format
+	Converters converter.Converters
+
+	// Hooks lists, by event, the actions to run when the collection changes
+	Hooks map[string][]*hookConfig
+
+	// Devices lists, by name, the devices (like e-readers) that the
+	// collection can be synchronized with
+	Devices map[string]*deviceConfig
+
+	// Collections lists, by name, the collections that can be managed in
+	// addition to the default one
+	Collections map[string]*collectionConfig
+
+	// Collection is the name of the collection to work with. Default
+	// collection is used if empty
+	Collection string
+
+	// Stats describes the statistics reported on the collection
+	Stats *statsConfig
+
+	// served is the collection opened by the server that runs the command,
+	// if any
+	served *Gostore
 }
 
 // Modules lists available modules.
@@ -66,20 +104,145 @@ func (cfg *Config) expandEnv() {
 	cfg.Store.Path = os.ExpandEnv(cfg.Store.Path)
 	cfg.UI.EditorCmd = os.ExpandEnv(cfg.UI.EditorCmd)
 	cfg.UI.MergerCmd = os.ExpandEnv(cfg.UI.MergerCmd)
+	for _, dev := range cfg.Devices {
+		dev.Path = os.ExpandEnv(dev.Path)
+	}
 }
 
 func newConfig() *Config {
 	return &Config{
 		Store: store.NewConfig(),
 		UI:    cli.NewConfig(),
+		Stats: newStatsConfig(),
+	}
+}
+
+// pipelineConfig lists, by media Type, the modules to apply to a record.
+// It can be unmarshaled either from a list of modules that applies to any
+// media Type or from a map of media Type to a list of modules.
+type pipelineConfig map[string][]*moduleConfig
+
+func (cfg *pipelineConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var mods []*moduleConfig
+	if err := unmarshal(&mods); err == nil {
+		*cfg = pipelineConfig{media.DefaultType: mods}
+		return nil
+	}
+
+	var modsByType map[string][]*moduleConfig
+	if err := unmarshal(&modsByType); err != nil {
+		return err
+	}
+	*cfg = modsByType
+	return nil
+}
+
+func (cfg pipelineConfig) newPipeline(env *modules.Environment) (modules.Pipeline, error) {
+	p := make(modules.Pipeline)
+
+	for typ, mods := range cfg {
+		for _, module := range mods {
+			m, err := module.newModule(env)
+			if err != nil {
+				return nil, err
+			}
+
+			p[typ] = append(p[typ], m)
+		}
 	}
+
+	return p, nil
+}
+
+// dupQueries lists the queries used by the pipeline's dupfinder modules to
+// identify records' duplicates.
+func (cfg pipelineConfig) dupQueries() ([]string, error) {
+	var types []string
+	for typ := range cfg {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	var queries []string
+	seen := make(map[string]bool)
+	for _, typ := range types {
+		for _, module := range cfg[typ] {
+			if module.Name != "dupfinder" {
+				continue
+			}
+
+			dupcfg := &dupfinder.Config{}
+			if err := module.Config.Unmarshal(dupcfg); err != nil {
+				return nil, fmt.Errorf("cannot read module '%s' configuration: %v", module.Name, err)
+			}
+
+			for _, q := range dupcfg.DupQueries {
+				if !seen[q] {
+					seen[q] = true
+					queries = append(queries, q)
+				}
+			}
+		}
+	}
+
+	return queries, nil
 }
 
 type moduleConfig struct {
-	Name   string
+	Name string
+	// When is a condition that governs whether the module applies to a
+	// record. It is a template that is executed against the record's values
+	// and that should output either true or false
+	When   string
 	Config *rawYAMLConfig
 }
 
+func (cfg *moduleConfig) newModule(env *modules.Environment) (modules.Module, error) {
+	m, err := modules.New(cfg.Name, cfg.Config, env)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create module '%s': %v", cfg.Name, err)
+	}
+
+	if cfg.When == "" {
+		return m, nil
+	}
+
+	if m, err = modules.NewConditional(m, cfg.When); err != nil {
+		return nil, fmt.Errorf("cannot create module '%s': %v", cfg.Name, err)
+	}
+	return m, nil
+}
+
+type hookConfig struct {
+	// Cmd is the command to run. Information about the event is sent as
+	// JSON to the command's standard input
+	Cmd string
+
+	// URL is the address where information about the event is POSTed as
+	// JSON
+	URL string
+
+	// Timeout is the maximum duration the hook is allowed to run
+	Timeout time.Duration
+}
+
+type deviceConfig struct {
+	// Path is the folder where the device is mounted
+	Path string
+
+	// Query selects the records to synchronize with the device. If empty,
+	// all collection's records are synchronized
+	Query string
+
+	// Delete is a flag that instructs gostore.Sync to delete device's files
+	// that are no more selected by Query
+	Delete bool
+
+	// Organizer is the configuration of the organizer module used to name
+	// the files on the device. If not set, records' names are used
+	Organizer *rawYAMLConfig
+}
+
 type rawYAMLConfig struct {
 	unmarshal func(interface{}) error
 }
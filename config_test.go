@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestPipelineConfig(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want map[string][]string
+	}{
+		{
+			in: `
+import:
+    - name: mdatareader
+    - name: fetcher
+      when: '{{ not .ISBN }}'
+`,
+			want: map[string][]string{"media": {"mdatareader", "fetcher"}},
+		},
+		{
+			in: `
+import:
+    book:
+        - name: mdatareader
+        - name: fetcher
+    media:
+        - name: mdatareader
+`,
+			want: map[string][]string{"book": {"mdatareader", "fetcher"}, "media": {"mdatareader"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := newConfig()
+		if err := yaml.Unmarshal([]byte(tc.in), cfg); err != nil {
+			t.Fatalf("Fail to read config '%s': %v", tc.in, err)
+		}
+
+		got := make(map[string][]string)
+		for typ, mods := range cfg.Import {
+			for _, m := range mods {
+				got[typ] = append(got[typ], m.Name)
+			}
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to read pipeline config '%s'.\nWant: %v\nGot : %v", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	// dumpTimeFmt is the format used to serialize time stamps in CSV dumps.
+	dumpTimeFmt = time.RFC3339
+)
+
+var (
+	// dumpFormats lists the available formats to dump/load records.
+	dumpFormats = []string{"jsonl", "csv", "yaml"}
+
+	// dumpCSVHeader lists the CSV columns that are always present in dumps.
+	dumpCSVHeader = []string{"Name", "CreatedAt", "UpdatedAt"}
+)
+
+// dumpedRecord represents a record in its serialized form.
+type dumpedRecord struct {
+	Name      string                 `json:"Name" yaml:"Name"`
+	CreatedAt time.Time              `json:"CreatedAt" yaml:"CreatedAt"`
+	UpdatedAt time.Time              `json:"UpdatedAt" yaml:"UpdatedAt"`
+	Data      map[string]interface{} `json:"Data" yaml:"Data"`
+	User      map[string]interface{} `json:"User,omitempty" yaml:"User,omitempty"`
+}
+
+func newDumpedRecord(r *store.Record) *dumpedRecord {
+	v := r.Value()
+
+	d := &dumpedRecord{
+		Name: r.Key(),
+		Data: r.Data(),
+		User: r.UserData(),
+	}
+	d.CreatedAt, _ = v["CreatedAt"].(time.Time)
+	d.UpdatedAt, _ = v["UpdatedAt"].(time.Time)
+
+	return d
+}
+
+// recordEncoder represents a serializer for records.
+type recordEncoder interface {
+	Encode(*dumpedRecord) error
+	Flush() error
+}
+
+// recordDecoder represents a de-serializer for records. It returns io.EOF
+// once all records are read.
+type recordDecoder interface {
+	Decode() (*dumpedRecord, error)
+}
+
+// Dump serializes all collection's records into w using the given format
+// (jsonl, csv or yaml).
+// For csv format, fields lists the record's fields to dump (by default all
+// known fields) and listSep is used to join multi-valued fields.
+func (gs *Gostore) Dump(w io.Writer, format string, fields []string, listSep string) error {
+	records, err := gs.store.ReadAll()
+	if err != nil {
+		return fmt.Errorf("dumping collection failed: %s", err)
+	}
+
+	if len(fields) == 0 {
+		fields = dataFields(records)
+	}
+
+	enc, err := newRecordEncoder(w, format, fields, listSep)
+	if err != nil {
+		return fmt.Errorf("dumping collection failed: %s", err)
+	}
+
+	for _, r := range records {
+		gs.log.Printf("Dumping '%s'", r.Key())
+		if err := enc.Encode(newDumpedRecord(r)); err != nil {
+			return fmt.Errorf("dumping '%s' failed: %s", r.Key(), err)
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return fmt.Errorf("dumping collection failed: %s", err)
+	}
+
+	return nil
+}
+
+// Load reads records serialized by Dump and updates the corresponding
+// collection's records. Loaded fields replace existing ones, fields with an
+// empty value are removed and fields that are not part of the dump are kept
+// untouched. Records keep their dumped creation and update time stamps.
+// Records that do not exist yet are created provided that their file is
+// already present in the collection.
+// Loaded records are processed by the update modules and the usual hooks are
+// run.
+// Loaded values are converted back to the type of the existing value if any,
+// listSep is used to split, for csv format, the fields known to hold a list of
+// values (either because their existing value or their schema is a list).
+func (gs *Gostore) Load(rd io.Reader, format string, listSep string) error {
+	dec, err := newRecordDecoder(rd, format)
+	if err != nil {
+		return fmt.Errorf("loading records failed: %s", err)
+	}
+
+	var created, updated store.Records
+	var loadErr util.MultiErrors
+	for {
+		d, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			loadErr.Add(fmt.Errorf("loading records failed: %s", err))
+			break
+		}
+
+		gs.log.Printf("Loading '%s'", d.Name)
+		r, isNew, err := gs.load(d, listSep)
+		if err != nil {
+			loadErr.Add(fmt.Errorf("loading '%s' failed: %s", d.Name, err))
+			continue
+		}
+
+		if isNew {
+			created = append(created, r)
+		} else {
+			updated = append(updated, r)
+		}
+	}
+
+	if loaded := append(created, updated...); len(loaded) != 0 {
+		gs.ui.PrettyPrint(loaded.Flatted()...)
+	}
+
+	if len(created) != 0 {
+		if err := gs.runHooks("post-import", created...); err != nil {
+			loadErr.Add(err)
+		}
+	}
+
+	if len(updated) != 0 {
+		if err := gs.runHooks("post-update", updated...); err != nil {
+			loadErr.Add(err)
+		}
+	}
+
+	return loadErr.Err()
+}
+
+// load creates or updates the record corresponding to a dumped record,
+// reporting whether the record is new.
+func (gs *Gostore) load(d *dumpedRecord, listSep string) (*store.Record, bool, error) {
+	exists, err := gs.store.Exists(d.Name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	r := store.NewRecord(d.Name, nil)
+	if exists {
+		if r, err = gs.store.Read(d.Name); err != nil {
+			return nil, false, err
+		}
+	}
+
+	data, user := r.Data(), r.UserData()
+	splitUserData(data, user)
+
+	loadedUser := make(map[string]interface{})
+	for k, v := range d.Data {
+		if isUserField(k) {
+			if isUserListField(k) && !isEmptyValue(v) {
+				v = coerce(v, []interface{}{}, listSep)
+			}
+			loadedUser[k] = v
+			continue
+		}
+
+		if isEmptyValue(v) {
+			delete(data, k)
+			continue
+		}
+
+		like := data[k]
+		if like == nil && gs.store.IsListField(data, k) {
+			like = []interface{}{}
+		}
+		data[k] = coerce(v, like, listSep)
+	}
+	r.SetData(data)
+
+	for k, v := range d.User {
+		loadedUser[k] = v
+	}
+	if err := loadUserData(user, loadedUser); err != nil {
+		return nil, false, err
+	}
+	r.SetUserData(user)
+
+	if err := gs.updateModules.ProcessRecord(r); err != nil {
+		return nil, false, err
+	}
+
+	// Modifying a record updates its time stamps, so that dumped ones are
+	// only restored once the record is ready to be stored.
+	r.SetTimeStamps(d.CreatedAt, d.UpdatedAt)
+
+	if !exists {
+		if err := gs.runHooks("pre-import", r); err != nil {
+			return nil, false, err
+		}
+
+		if !gs.pretend {
+			if err := gs.store.Insert(r); err != nil {
+				return nil, false, err
+			}
+		}
+		return r, true, nil
+	}
+
+	if err := gs.runHooks("pre-update", r); err != nil {
+		return nil, false, err
+	}
+
+	if !gs.pretend {
+		if err := gs.store.Update(d.Name, r); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return r, false, nil
+}
+
+// loadUserData updates the end-user's personal information of a record with
+// loaded values, following the same rules than for record's data.
+func loadUserData(user map[string]interface{}, loaded map[string]interface{}) error {
+	for k, v := range loaded {
+		if isEmptyValue(v) {
+			delete(user, k)
+			continue
+		}
+		user[k] = v
+	}
+
+	return checkUserData(user)
+}
+
+// dataFields lists all fields name used by records, including the end-user's
+// personal information, sorted in alphabetical order.
+func dataFields(records store.Records) []string {
+	known := make(map[string]struct{})
+	for _, r := range records {
+		for k := range r.Data() {
+			known[k] = struct{}{}
+		}
+		for k := range r.UserData() {
+			known[k] = struct{}{}
+		}
+	}
+
+	var fields []string
+	for k := range known {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// isEmptyValue reports whether a loaded value is empty (nil, blank text or
+// empty list), zero values like false or 0 being kept.
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// coerce converts a string value to the type of like. Value is only split
+// into a list using sep if like is a list.
+func coerce(v, like interface{}, sep string) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+
+	switch like.(type) {
+	case time.Time:
+		if t, err := util.ParseTime(s); err == nil {
+			return t
+		}
+
+	case int, float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+
+	case bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+
+	case []string, []interface{}:
+		return split(s, sep)
+	}
+
+	return s
+}
+
+func split(s, sep string) []interface{} {
+	if sep == "" {
+		return []interface{}{s}
+	}
+
+	var l []interface{}
+	for _, item := range strings.Split(s, sep) {
+		l = append(l, strings.TrimSpace(item))
+	}
+	return l
+}
+
+func newRecordEncoder(w io.Writer, format string, fields []string, listSep string) (recordEncoder, error) {
+	switch format {
+	case "jsonl":
+		return &jsonlEncoder{json.NewEncoder(w)}, nil
+	case "yaml":
+		return &yamlEncoder{yaml.NewEncoder(w)}, nil
+	case "csv":
+		return newCSVEncoder(w, fields, listSep)
+	default:
+		return nil, fmt.Errorf("unknown format '%s' (available formats: %s)", format, strings.Join(dumpFormats, ", "))
+	}
+}
+
+func newRecordDecoder(r io.Reader, format string) (recordDecoder, error) {
+	switch format {
+	case "jsonl":
+		return &jsonlDecoder{json.NewDecoder(r)}, nil
+	case "yaml":
+		return &yamlDecoder{yaml.NewDecoder(r)}, nil
+	case "csv":
+		return newCSVDecoder(r)
+	default:
+		return nil, fmt.Errorf("unknown format '%s' (available formats: %s)", format, strings.Join(dumpFormats, ", "))
+	}
+}
+
+type jsonlEncoder struct {
+	*json.Encoder
+}
+
+func (enc *jsonlEncoder) Encode(d *dumpedRecord) error {
+	return enc.Encoder.Encode(d)
+}
+
+func (enc *jsonlEncoder) Flush() error {
+	return nil
+}
+
+type jsonlDecoder struct {
+	*json.Decoder
+}
+
+func (dec *jsonlDecoder) Decode() (*dumpedRecord, error) {
+	d := new(dumpedRecord)
+	if err := dec.Decoder.Decode(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type yamlEncoder struct {
+	*yaml.Encoder
+}
+
+func (enc *yamlEncoder) Encode(d *dumpedRecord) error {
+	return enc.Encoder.Encode(d)
+}
+
+func (enc *yamlEncoder) Flush() error {
+	return enc.Encoder.Close()
+}
+
+type yamlDecoder struct {
+	*yaml.Decoder
+}
+
+func (dec *yamlDecoder) Decode() (*dumpedRecord, error) {
+	d := new(dumpedRecord)
+	if err := dec.Decoder.Decode(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	fields  []string
+	listSep string
+}
+
+func newCSVEncoder(w io.Writer, fields []string, listSep string) (*csvEncoder, error) {
+	enc := &csvEncoder{
+		w:       csv.NewWriter(w),
+		fields:  fields,
+		listSep: listSep,
+	}
+
+	if err := enc.w.Write(append(dumpCSVHeader, fields...)); err != nil {
+		return nil, err
+	}
+
+	return enc, nil
+}
+
+func (enc *csvEncoder) Encode(d *dumpedRecord) error {
+	row := []string{d.Name, d.CreatedAt.Format(dumpTimeFmt), d.UpdatedAt.Format(dumpTimeFmt)}
+	for _, f := range enc.fields {
+		v, ok := d.Data[f]
+		if !ok {
+			v = d.User[f]
+		}
+		row = append(row, enc.format(v))
+	}
+	return enc.w.Write(row)
+}
+
+func (enc *csvEncoder) Flush() error {
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+func (enc *csvEncoder) format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+
+	case string:
+		return v
+
+	case time.Time:
+		return v.Format(dumpTimeFmt)
+
+	case []string:
+		return strings.Join(v, enc.listSep)
+
+	case []interface{}:
+		l := make([]string, len(v))
+		for i, item := range v {
+			l[i] = enc.format(item)
+		}
+		return strings.Join(l, enc.listSep)
+
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type csvDecoder struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	dec := &csvDecoder{r: csv.NewReader(r)}
+
+	var err error
+	if dec.header, err = dec.r.Read(); err != nil {
+		return nil, fmt.Errorf("cannot read csv header: %s", err)
+	}
+
+	if len(dec.header) < len(dumpCSVHeader) {
+		return nil, fmt.Errorf("csv header should start with %s", strings.Join(dumpCSVHeader, ","))
+	}
+	for i, col := range dumpCSVHeader {
+		if dec.header[i] != col {
+			return nil, fmt.Errorf("csv header should start with %s", strings.Join(dumpCSVHeader, ","))
+		}
+	}
+
+	return dec, nil
+}
+
+func (dec *csvDecoder) Decode() (*dumpedRecord, error) {
+	row, err := dec.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dumpedRecord{
+		Name: row[0],
+		Data: make(map[string]interface{}),
+	}
+	d.CreatedAt, _ = time.Parse(dumpTimeFmt, row[1])
+	d.UpdatedAt, _ = time.Parse(dumpTimeFmt, row[2])
+
+	for i := len(dumpCSVHeader); i < len(row); i++ {
+		d.Data[dec.header[i]] = row[i]
+	}
+
+	return d, nil
+}
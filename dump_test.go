@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pirmd/verify"
+)
+
+func TestCoerce(t *testing.T) {
+	testCases := []struct {
+		in   interface{}
+		like interface{}
+		want interface{}
+	}{
+		{"1976-01-17", time.Time{}, time.Date(1976, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"42", 1, 42.0},
+		{"true", false, true},
+		{"Jules Verne", []interface{}{}, []interface{}{"Jules Verne"}},
+		{"Jules Verne|Victor Hugo", nil, "Jules Verne|Victor Hugo"},
+		{"Title", "", "Title"},
+		{12.0, "", 12.0},
+	}
+
+	for _, tc := range testCases {
+		got := coerce(tc.in, tc.like, "|")
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("Fail to coerce %v.\nWant: %#v\nGot : %#v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestDumpFormats(t *testing.T) {
+	stamp := time.Date(1976, 1, 17, 0, 0, 0, 0, time.UTC)
+	in := &dumpedRecord{
+		Name:      "Jules Verne - Voyage au centre de la terre.epub",
+		CreatedAt: stamp,
+		UpdatedAt: stamp,
+		Data: map[string]interface{}{
+			"Title":   "Voyage au centre de la terre",
+			"Authors": []interface{}{"Jules Verne"},
+		},
+	}
+
+	for _, format := range dumpFormats {
+		t.Run(format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			enc, err := newRecordEncoder(buf, format, []string{"Authors", "Title"}, "|")
+			if err != nil {
+				t.Fatalf("Fail to create encoder: %v", err)
+			}
+			if err := enc.Encode(in); err != nil {
+				t.Fatalf("Fail to encode: %v", err)
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatalf("Fail to encode: %v", err)
+			}
+
+			dec, err := newRecordDecoder(buf, format)
+			if err != nil {
+				t.Fatalf("Fail to create decoder: %v", err)
+			}
+			got, err := dec.Decode()
+			if err != nil {
+				t.Fatalf("Fail to decode: %v", err)
+			}
+
+			if got.Name != in.Name || !got.CreatedAt.Equal(in.CreatedAt) || !got.UpdatedAt.Equal(in.UpdatedAt) {
+				t.Errorf("Dump round-trip failed.\nWant: %+v\nGot : %+v", in, got)
+			}
+
+			if title := coerce(got.Data["Title"], "", "|"); title != in.Data["Title"] {
+				t.Errorf("Dump round-trip failed for Title.\nWant: %v\nGot : %v", in.Data["Title"], title)
+			}
+
+			if authors := coerce(got.Data["Authors"], []interface{}{}, "|"); !reflect.DeepEqual(authors, in.Data["Authors"]) {
+				t.Errorf("Dump round-trip failed for Authors.\nWant: %#v\nGot : %#v", in.Data["Authors"], authors)
+			}
+
+			if _, err := dec.Decode(); err != io.EOF {
+				t.Errorf("Decoder should reach end of dump, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name() + "_hooks")
+	if err != nil {
+		t.Fatalf("Failed to create hooks' folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	cfg := newConfig()
+	rawcfg := `
+hooks:
+    post-update:
+        - cmd: sh -c 'cat > "` + tstDir.Fullpath("post-update.json") + `"'
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	data := map[string]interface{}{"Title": "Les misérables", "Authors": []string{"Victor Hugo"}, "Read": true}
+	if _, err := gs.store.Create("a.epub", data, verify.MockROFile("0123456789")); err != nil {
+		t.Fatalf("Fail to add record: %v", err)
+	}
+
+	dump := `Name,CreatedAt,UpdatedAt,Authors,SubTitle,Tags,Rating
+a.epub,2001-02-03T04:05:06Z,2002-02-03T04:05:06Z,Victor Hugo|Isabel F. Hapgood,Fantine|Cosette,classic|to sort,4
+`
+	if err := gs.Load(strings.NewReader(dump), "csv", "|"); err != nil {
+		t.Fatalf("Fail to load csv dump: %v", err)
+	}
+
+	dump = `{"Name":"a.epub","Data":{"Read":false,"Pages":0}}`
+	if err := gs.Load(strings.NewReader(dump), "jsonl", "|"); err != nil {
+		t.Fatalf("Fail to load jsonl dump: %v", err)
+	}
+
+	r, err := gs.store.Read("a.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"Title":    "Les misérables",
+		"Authors":  []interface{}{"Victor Hugo", "Isabel F. Hapgood"},
+		"SubTitle": "Fantine|Cosette",
+		"Read":     false,
+		"Pages":    0.0,
+	}
+	if got := r.Data(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fail to load record.\nWant: %#v\nGot : %#v", want, got)
+	}
+
+	wantUser := map[string]interface{}{"Tags": []string{"classic", "to sort"}, "Rating": 4}
+	if got := r.UserData(); !reflect.DeepEqual(got, wantUser) {
+		t.Errorf("Fail to load record's user data.\nWant: %#v\nGot : %#v", wantUser, got)
+	}
+
+	if created := r.Value()["CreatedAt"].(time.Time); !created.Equal(time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)) {
+		t.Errorf("Fail to load record's creation time stamp: got %v", created)
+	}
+
+	if _, err := ioutil.ReadFile(tstDir.Fullpath("post-update.json")); err != nil {
+		t.Errorf("Fail to run post-update hook when loading records: %v", err)
+	}
+}
+
+func TestDumpLoadUserData(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	data := map[string]interface{}{"Title": "Les misérables", "Authors": []string{"Victor Hugo"}}
+	if _, err := gs.store.Create("a.epub", data, verify.MockROFile("0123456789")); err != nil {
+		t.Fatalf("Fail to add record: %v", err)
+	}
+	if err := gs.Tag([]string{"classic", "to sort"}, false, []string{"a.epub"}, ""); err != nil {
+		t.Fatalf("Fail to tag record: %v", err)
+	}
+	if err := gs.Rate(4, []string{"a.epub"}, ""); err != nil {
+		t.Fatalf("Fail to rate record: %v", err)
+	}
+
+	for _, fields := range [][]string{nil, {"Tags", "Rating"}} {
+		buf := new(bytes.Buffer)
+		if err := gs.Dump(buf, "csv", fields, "|"); err != nil {
+			t.Fatalf("Fail to dump collection: %v", err)
+		}
+
+		if err := gs.Load(buf, "csv", "|"); err != nil {
+			t.Fatalf("Fail to load csv dump: %v", err)
+		}
+
+		r, err := gs.store.Read("a.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		want := map[string]interface{}{"Tags": []string{"classic", "to sort"}, "Rating": 4}
+		if got := r.UserData(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Fail to keep user data dumping fields %v.\nWant: %#v\nGot : %#v", fields, want, got)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pirmd/gostore/modules/hasher"
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	dupKeep   = "keep"
+	dupMerge  = "merge"
+	dupDelete = "delete"
+)
+
+// dupGroups gathers records that are possible duplicates of each other. Two
+// records belong to the same group if one matches a duplicates' query of the
+// other or if their files have the same hash.
+type dupGroups struct {
+	parent map[string]string
+}
+
+func newDupGroups() *dupGroups {
+	return &dupGroups{parent: make(map[string]string)}
+}
+
+func (g *dupGroups) root(key string) string {
+	for {
+		p, exists := g.parent[key]
+		if !exists || p == key {
+			return key
+		}
+		g.parent[key] = g.parent[p]
+		key = p
+	}
+}
+
+func (g *dupGroups) join(a, b string) {
+	ra, rb := g.root(a), g.root(b)
+	if ra == rb {
+		return
+	}
+
+	// Keep the smallest key as group's root so that groups are built the
+	// same way whatever the order records are joined.
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	g.parent[ra] = ra
+	g.parent[rb] = ra
+}
+
+// of returns the groups of at least two records, each group and its records
+// being sorted by key.
+func (g *dupGroups) of(records store.Records) []store.Records {
+	byRoot := make(map[string]store.Records)
+	for _, r := range records {
+		if _, exists := g.parent[r.Key()]; exists {
+			root := g.root(r.Key())
+			byRoot[root] = append(byRoot[root], r)
+		}
+	}
+
+	var groups []store.Records
+	for _, group := range byRoot {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Key() < group[j].Key() })
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0].Key() < groups[j][0].Key() })
+
+	return groups
+}
+
+// Duplicates scans the whole collection for possible duplicates, either
+// matching the configured duplicates' queries or having the same file's
+// hash. Each duplicate is displayed side by side with the first record of
+// its group and the user chooses to keep both, to merge the duplicate's
+// metadata into the first record then delete the duplicate or to delete the
+// duplicate.
+func (gs *Gostore) Duplicates() error {
+	groups, err := gs.duplicates()
+	if err != nil {
+		return fmt.Errorf("looking for duplicates failed: %s", err)
+	}
+
+	if len(groups) == 0 {
+		gs.ui.Printf("No duplicates found\n")
+		return nil
+	}
+
+	var updated, deleted store.Records
+	var dupErr util.MultiErrors
+	for _, group := range groups {
+		ref, isUpdated := group[0], false
+
+		for _, dup := range group[1:] {
+			gs.ui.PrettyDiff(ref.Flatted(), dup.Flatted())
+
+			msg := fmt.Sprintf("'%s' is a possible duplicate of '%s':", dup.Key(), ref.Key())
+			switch gs.ui.Choose(msg, dupKeep, dupMerge, dupDelete) {
+			case dupMerge:
+				gs.log.Printf("Merging '%s' into '%s'", dup.Key(), ref.Key())
+
+				mdata, err := gs.ui.Merge(ref.Data(), dup.Data())
+				if err != nil {
+					dupErr.Add(fmt.Errorf("merging '%s' into '%s' failed: %s", dup.Key(), ref.Key(), err))
+					continue
+				}
+
+				if err := gs.update(ref, mdata); err != nil {
+					dupErr.Add(fmt.Errorf("merging '%s' into '%s' failed: %s", dup.Key(), ref.Key(), err))
+					continue
+				}
+				isUpdated = true
+
+				fallthrough
+
+			case dupDelete:
+				gs.log.Printf("Deleting '%s'", dup.Key())
+
+				if err := gs.delete(dup); err != nil {
+					dupErr.Add(fmt.Errorf("deleting '%s' failed: %s", dup.Key(), err))
+					continue
+				}
+				deleted = append(deleted, dup)
+			}
+		}
+
+		if isUpdated {
+			updated = append(updated, ref)
+		}
+	}
+
+	if len(updated) != 0 {
+		gs.ui.PrettyPrint(updated.Flatted()...)
+
+		if err := gs.runHooks("post-update", updated...); err != nil {
+			dupErr.Add(err)
+		}
+	}
+
+	if len(deleted) != 0 {
+		if err := gs.runHooks("post-delete", deleted...); err != nil {
+			dupErr.Add(err)
+		}
+	}
+
+	return dupErr.Err()
+}
+
+// duplicates returns the groups of the collection's records that are
+// possible duplicates of each other.
+func (gs *Gostore) duplicates() ([]store.Records, error) {
+	records, err := gs.store.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := newDupGroups()
+	byHash := make(map[string]string)
+
+	for _, r := range records {
+		if hash, ok := r.Get(hasher.HashField).(string); ok && hash != "" {
+			if key, exists := byHash[hash]; exists {
+				groups.join(key, r.Key())
+			} else {
+				byHash[hash] = r.Key()
+			}
+		}
+
+		dups, err := gs.dupFinder.Find(r)
+		if err != nil {
+			gs.log.Printf("Looking for duplicates of '%s' failed: %s", r.Key(), err)
+			continue
+		}
+
+		for _, key := range dups {
+			groups.join(r.Key(), key)
+		}
+	}
+
+	return groups.of(records), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pirmd/verify"
+)
+
+func TestDuplicates(t *testing.T) {
+	cfg := newConfig()
+	rawcfg := `
+import:
+    - name: dupfinder
+      config:
+          dupqueries:
+              - '{{ if .Title }}Title:"{{ escape .Title }}"{{ end }}'
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testData := map[string]map[string]interface{}{
+		"a.epub":  {"Title": "Les voyages extraordinaires", "SourceHash": "1"},
+		"a2.epub": {"Title": "Les voyages extraordinaires"},
+		"b.epub":  {"Title": "L'étranger", "SourceHash": "2"},
+		"b2.epub": {"Title": "The stranger", "SourceHash": "2"},
+		"c.epub":  {"Title": "Moby Dick", "SourceHash": "3"},
+	}
+	for key, data := range testData {
+		if _, err := gs.store.Create(key, data, verify.MockROFile("")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	groups, err := gs.duplicates()
+	if err != nil {
+		t.Fatalf("Fail to look for duplicates: %v", err)
+	}
+
+	want := [][]string{{"a.epub", "a2.epub"}, {"b.epub", "b2.epub"}}
+	var got [][]string
+	for _, g := range groups {
+		got = append(got, g.Key())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fail to find duplicates.\nWant: %v\nGot : %v", want, got)
+	}
+
+	// In auto mode, duplicates are kept.
+	if err := gs.Duplicates(); err != nil {
+		t.Fatalf("Fail to review duplicates: %v", err)
+	}
+
+	for key := range testData {
+		if exists, err := gs.store.Exists(key); err != nil || !exists {
+			t.Errorf("Record '%s' should have been kept (%v)", key, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ module github.com/pirmd/gostore
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.4.1
 	github.com/RoaringBitmap/roaring v0.5.5 // indirect
 	github.com/blevesearch/bleve v1.0.13
 	github.com/cznic/b v0.0.0-20181122101859-a26611c4d92d // indirect
@@ -12,25 +13,26 @@ require (
 	github.com/facebookgo/stack v0.0.0-20160209184415-751773369052 // indirect
 	github.com/facebookgo/subset v0.0.0-20150612182917-8dac2c3c4870 // indirect
 	github.com/gabriel-vasile/mimetype v1.1.2
+	github.com/gdamore/tcell v1.4.0
 	github.com/glycerine/go-unsnap-stream v0.0.0-20190901134440-81cf024a9e0a // indirect
 	github.com/golang/protobuf v1.4.3 // indirect
 	github.com/golang/snappy v0.0.2 // indirect
 	github.com/jmhodges/levigo v1.0.0 // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
+	github.com/mattn/go-runewidth v0.0.9
 	github.com/pirmd/clapp v0.5.1
 	github.com/pirmd/epub v0.1.0
 	github.com/pirmd/style v0.4.0
 	github.com/pirmd/text v0.5.4
 	github.com/pirmd/verify v0.5.3
-	github.com/remyoudompheng/bigfft v0.0.0-20190728182440-6a916e37a237 // indirect
 	github.com/sanity-io/litter v1.3.0 // indirect
 	github.com/tecbot/gorocksdb v0.0.0-20190705090504-162552197222 // indirect
 	github.com/tinylib/msgp v1.1.5 // indirect
 	github.com/willf/bitset v1.1.11 // indirect
 	go.etcd.io/bbolt v1.3.5
 	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b
-	golang.org/x/sys v0.0.0-20201130171929-760e229fe7c5 // indirect
-	golang.org/x/text v0.3.4 // indirect
+	golang.org/x/text v0.3.4
 	google.golang.org/protobuf v1.25.0 // indirect
 	gopkg.in/yaml.v2 v2.3.0
+	modernc.org/sqlite v1.10.6
 )
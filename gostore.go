@@ -7,40 +7,69 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"time"
 
+	"github.com/pirmd/gostore/media"
+	"github.com/pirmd/gostore/media/books/calibre"
 	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/modules/converter"
+	"github.com/pirmd/gostore/modules/dupfinder"
 	"github.com/pirmd/gostore/store"
 	"github.com/pirmd/gostore/ui"
 	"github.com/pirmd/gostore/ui/cli"
+	"github.com/pirmd/gostore/ui/tui"
 	"github.com/pirmd/gostore/util"
 )
 
 // Gostore represents the main collection manager.
 type Gostore struct {
+	config        *Config
+	root          string
 	log           *log.Logger
 	debug         *log.Logger
 	pretend       bool
+	shared        bool
 	deleteGhosts  bool
 	deleteOrphans bool
 	importOrphans bool
 	store         *store.Store
 	ui            ui.UserInterfacer
-	importModules []modules.Module
-	updateModules []modules.Module
+	importModules modules.Pipeline
+	updateModules modules.Pipeline
+	pipelines     map[string]modules.Pipeline
+	dupFinder     *dupfinder.Finder
+	devices       map[string]*device
+	converters    converter.Converters
+	hooks         map[string][]*hook
 }
 
-func newGostore(cfg *Config) (*Gostore, error) {
+func newGostore(rootCfg *Config) (*Gostore, error) {
+	cfg, err := rootCfg.forCollection(rootCfg.Collection)
+	if err != nil {
+		return nil, err
+	}
 	cfg.expandEnv()
 
+	// Commands delegated to a server are run from the working directory of
+	// the delegating process, so that the served collection's root should
+	// not depend on it.
+	if cfg.Store.Path, err = filepath.Abs(cfg.Store.Path); err != nil {
+		return nil, err
+	}
+
 	gs := &Gostore{
-		log:   log.New(ioutil.Discard, "", log.Ltime|log.Lshortfile),
-		debug: log.New(ioutil.Discard, "", log.Ltime|log.Lshortfile),
+		config: rootCfg,
+		root:   cfg.Store.Path,
+		log:    log.New(ioutil.Discard, "", log.Ltime|log.Lshortfile),
+		debug:  log.New(ioutil.Discard, "", log.Ltime|log.Lshortfile),
 
 		pretend:       cfg.ReadOnly,
 		deleteGhosts:  cfg.DeleteGhosts,
 		deleteOrphans: cfg.DeleteOrphans,
 		importOrphans: cfg.ImportOrphans,
+		converters:    cfg.Converters,
 	}
 
 	if cfg.Verbose || cfg.Debug {
@@ -52,43 +81,95 @@ func newGostore(cfg *Config) (*Gostore, error) {
 		cfg.Store.Logger = gs.debug
 	}
 
-	var err error
-	if gs.store, err = store.NewFromConfig(cfg.Store); err != nil {
+	// Reading status values (like 'to-read') are searched for as a whole.
+	storeCfg := *cfg.Store
+	storeCfg.KeywordFields = append([]string{statusField}, cfg.Store.KeywordFields...)
+
+	if srv := rootCfg.served; srv != nil {
+		if !sameDir(gs.root, srv.root) {
+			return nil, fmt.Errorf("collection '%s' is not the served collection", gs.name())
+		}
+
+		// The server has no terminal to display a full-screen interface.
+		cfg.UI.Fullscreen = false
+		gs.store, gs.shared = srv.store, true
+	} else if gs.store, err = store.NewFromConfig(&storeCfg, store.UsingUserFields(userFields)); err != nil {
 		return nil, err
 	}
 
-	if gs.ui, err = cli.NewFromConfig(cfg.UI); err != nil {
+	if cfg.UI.Fullscreen {
+		if gs.ui, err = tui.NewFromConfig(cfg.UI); err != nil {
+			return nil, err
+		}
+	} else {
+		if gs.ui, err = cli.NewFromConfig(cfg.UI); err != nil {
+			return nil, err
+		}
+	}
+
+	env := &modules.Environment{Logger: gs.log, UI: gs.ui, Store: gs.store, ReadOnly: gs.pretend, HasMerger: cfg.UI.MergerCmd != ""}
+	if gs.importModules, err = cfg.Import.newPipeline(env); err != nil {
 		return nil, err
 	}
 
-	env := &modules.Environment{Logger: gs.log, UI: gs.ui, Store: gs.store}
-	for _, module := range cfg.Import {
-		m, err := modules.New(module.Name, module.Config, env)
-		if err != nil {
-			return nil, fmt.Errorf("cannot create module '%s': %v", module.Name, err)
+	if gs.updateModules, err = cfg.Update.newPipeline(env); err != nil {
+		return nil, err
+	}
+
+	dupQueries, err := cfg.Import.dupQueries()
+	if err != nil {
+		return nil, err
+	}
+	if gs.dupFinder, err = dupfinder.NewFinder(dupQueries, gs.store); err != nil {
+		return nil, fmt.Errorf("cannot create duplicates finder: %v", err)
+	}
+
+	gs.pipelines = make(map[string]modules.Pipeline)
+	for name, pipecfg := range cfg.Pipelines {
+		if gs.pipelines[name], err = pipecfg.newPipeline(env); err != nil {
+			return nil, fmt.Errorf("cannot create pipeline '%s': %v", name, err)
 		}
+	}
 
-		gs.importModules = append(gs.importModules, m)
+	if gs.hooks, err = newHooks(cfg.Hooks); err != nil {
+		return nil, err
 	}
 
-	for _, module := range cfg.Update {
-		m, err := modules.New(module.Name, module.Config, env)
+	gs.devices = make(map[string]*device)
+	for name, devcfg := range cfg.Devices {
+		dev, err := newDevice(devcfg, env)
 		if err != nil {
-			return nil, fmt.Errorf("cannot create module '%s': %v", module.Name, err)
+			return nil, fmt.Errorf("cannot create device '%s': %v", name, err)
 		}
 
-		gs.updateModules = append(gs.updateModules, m)
+		gs.devices[name] = dev
 	}
 
 	return gs, nil
 }
 
+// openGostore opens the collection for read/write operations. If the
+// collection is served by another gostore process, the command line is
+// delegated to the server and openGostore returns errDelegated once done.
+// As the server cannot interact with the user, commands are only delegated
+// in automatic or pretend mode.
 func openGostore(cfg *Config) (*Gostore, error) {
 	gs, err := newGostore(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	if gs.shared {
+		return gs, nil
+	}
+
+	if gs.isServed() {
+		if !cfg.UI.Auto && !cfg.ReadOnly {
+			return nil, fmt.Errorf("collection '%s' is served by another gostore process that cannot interact with the user: use flag '--auto' or '--pretend' to run the command through it", gs.name())
+		}
+		return nil, gs.delegate(os.Args[1:])
+	}
+
 	if err := gs.Open(); err != nil {
 		return nil, err
 	}
@@ -96,6 +177,30 @@ func openGostore(cfg *Config) (*Gostore, error) {
 	return gs, nil
 }
 
+// openGostoreReadOnly opens the collection for read-only operations,
+// delegating the command line to the collection's server if any like
+// openGostore.
+func openGostoreReadOnly(cfg *Config) (*Gostore, error) {
+	gs, err := newGostore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if gs.shared {
+		return gs, nil
+	}
+
+	if gs.isServed() {
+		return nil, gs.delegate(os.Args[1:])
+	}
+
+	if err := gs.OpenReadOnly(); err != nil {
+		return nil, err
+	}
+
+	return gs, nil
+}
+
 // Open opens a gostore for read/write operation
 func (gs *Gostore) Open() error {
 	if err := gs.store.Open(); err != nil {
@@ -105,8 +210,22 @@ func (gs *Gostore) Open() error {
 	return nil
 }
 
-// Close cleanly closes a gostore
+// OpenReadOnly opens a gostore for read-only operations. Several gostore
+// processes can concurrently open the same collection in read-only mode.
+func (gs *Gostore) OpenReadOnly() error {
+	if err := gs.store.OpenReadOnly(); err != nil {
+		return fmt.Errorf("opening gostore failed: %s", err)
+	}
+
+	return nil
+}
+
+// Close cleanly closes a gostore. A served collection is left opened for the
+// server.
 func (gs *Gostore) Close() error {
+	if gs.shared {
+		return nil
+	}
 	return gs.store.Close()
 }
 
@@ -118,17 +237,67 @@ func (gs *Gostore) Import(mediaFiles []string) error {
 	for _, path := range mediaFiles {
 		gs.log.Printf("Importing '%s'", path)
 
-		r, err := gs.insert(path)
+		inserted, err := gs.insert(path, nil, "")
 		if err != nil {
 			importErr.Add(fmt.Errorf("importing '%s' failed: %s", path, err))
+		}
+
+		newRecords = append(newRecords, inserted...)
+	}
+
+	if len(newRecords) != 0 {
+		gs.ui.PrettyPrint(newRecords.Flatted()...)
+
+		if err := gs.runHooks("post-import", newRecords...); err != nil {
+			importErr.Add(err)
+		}
+	}
+
+	return importErr.Err()
+}
+
+// ImportCalibre inserts the books of a Calibre library into the collection.
+// Metadata known by Calibre are merged into the new records before they go
+// through the import modules and books' covers are imported together with
+// the new records. For books available in several formats, formats lists the
+// preferred ones (first listed, first chosen).
+func (gs *Gostore) ImportCalibre(root string, formats []string) error {
+	lib, err := calibre.Open(root)
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+
+	books, err := lib.Books()
+	if err != nil {
+		return err
+	}
+
+	var newRecords store.Records
+	var importErr util.MultiErrors
+
+	for _, b := range books {
+		path := b.File(formats...)
+		if path == "" {
+			importErr.Add(fmt.Errorf("importing calibre's book '%v' failed: no file found", b.Metadata["Title"]))
 			continue
 		}
 
-		newRecords = append(newRecords, r)
+		gs.log.Printf("Importing '%s'", path)
+		inserted, err := gs.insert(path, b.Metadata, b.Cover)
+		if err != nil {
+			importErr.Add(fmt.Errorf("importing '%s' failed: %s", path, err))
+		}
+
+		newRecords = append(newRecords, inserted...)
 	}
 
 	if len(newRecords) != 0 {
 		gs.ui.PrettyPrint(newRecords.Flatted()...)
+
+		if err := gs.runHooks("post-import", newRecords...); err != nil {
+			importErr.Add(err)
+		}
 	}
 
 	return importErr.Err()
@@ -178,11 +347,12 @@ func (gs *Gostore) Edit(pattern []string) error {
 		return fmt.Errorf("editing '%s' failed: %s", pattern, err)
 	}
 
+	var edited store.Records
 	var editErr util.MultiErrors
 	for _, r := range records {
 		gs.log.Printf("Editing '%s'", r.Key())
 
-		mdata, err := gs.ui.Edit(r.Data())
+		mdata, err := gs.edit(r.Data())
 		if err != nil {
 			editErr.Add(fmt.Errorf("editing '%s' failed: %s", r.Key(), err))
 			continue
@@ -193,6 +363,7 @@ func (gs *Gostore) Edit(pattern []string) error {
 			continue
 		}
 
+		edited = append(edited, r)
 		records = append(records, r)
 	}
 
@@ -200,6 +371,12 @@ func (gs *Gostore) Edit(pattern []string) error {
 		gs.ui.PrettyPrint(records.Flatted()...)
 	}
 
+	if len(edited) != 0 {
+		if err := gs.runHooks("post-update", edited...); err != nil {
+			editErr.Add(err)
+		}
+	}
+
 	return editErr.Err()
 }
 
@@ -214,7 +391,7 @@ func (gs *Gostore) MultiEdit(pattern []string) error {
 		return nil
 	}
 
-	mdata, err := gs.ui.MultiEdit(records.Data())
+	mdata, err := gs.multiEdit(records.Data())
 	if err != nil {
 		return fmt.Errorf("editing '%s' failed: %s", pattern, err)
 	}
@@ -223,19 +400,86 @@ func (gs *Gostore) MultiEdit(pattern []string) error {
 		return fmt.Errorf("editing '%s' failed: number of records after multi-edition is inconsistent", pattern)
 	}
 
+	var edited store.Records
 	var editErr util.MultiErrors
 	for i := range mdata {
 		if err := gs.update(records[i], mdata[i]); err != nil {
 			editErr.Add(fmt.Errorf("editing '%s' failed: %s", records[i].Key(), err))
 			continue
 		}
+		edited = append(edited, records[i])
 	}
 
 	gs.ui.PrettyPrint(records.Flatted()...)
 
+	if len(edited) != 0 {
+		if err := gs.runHooks("post-update", edited...); err != nil {
+			editErr.Add(err)
+		}
+	}
+
 	return editErr.Err()
 }
 
+// Process applies a user defined pipeline of modules to the collection's
+// records selected either by a query or by their name (all records if none
+// is provided). Changes to each record
+// are displayed and confirmation is asked before updating the collection.
+func (gs *Gostore) Process(name string, pattern []string, query string) error {
+	pipeline, exists := gs.pipelines[name]
+	if !exists {
+		return fmt.Errorf("processing records failed: unknown pipeline '%s'", name)
+	}
+
+	var records store.Records
+	var err error
+	switch {
+	case query != "":
+		records, err = gs.store.ReadQuery(query)
+	case len(pattern) == 0:
+		records, err = gs.store.ReadAll()
+	default:
+		records, err = gs.glob(pattern)
+	}
+	if err != nil {
+		return fmt.Errorf("processing records failed: %s", err)
+	}
+
+	var processed, linked store.Records
+	var processErr util.MultiErrors
+	for _, r := range records {
+		gs.log.Printf("Processing '%s' with '%s'", r.Key(), name)
+
+		updated, inserted, err := gs.process(r, pipeline)
+		if err != nil {
+			processErr.Add(fmt.Errorf("processing '%s' failed: %s", r.Key(), err))
+		}
+
+		if updated {
+			processed = append(processed, r)
+		}
+		linked = append(linked, inserted...)
+	}
+
+	if len(processed) != 0 {
+		gs.ui.PrettyPrint(processed.Flatted()...)
+
+		if err := gs.runHooks("post-update", processed...); err != nil {
+			processErr.Add(err)
+		}
+	}
+
+	if len(linked) != 0 {
+		gs.ui.PrettyPrint(linked.Flatted()...)
+
+		if err := gs.runHooks("post-import", linked...); err != nil {
+			processErr.Add(err)
+		}
+	}
+
+	return processErr.Err()
+}
+
 // Delete removes a record from the collection.
 func (gs *Gostore) Delete(pattern []string) error {
 	records, err := gs.glob(pattern)
@@ -243,15 +487,22 @@ func (gs *Gostore) Delete(pattern []string) error {
 		return fmt.Errorf("deleting '%s' failed: %s", pattern, err)
 	}
 
+	var deleted store.Records
 	var delErr util.MultiErrors
 	for _, r := range records {
 		gs.log.Printf("Deleting '%s'", r.Key())
 
-		if !gs.pretend {
-			if err := gs.store.Delete(r.Key()); err != nil {
-				delErr.Add(fmt.Errorf("deleting '%s' failed: %s", r.Key(), err))
-				continue
-			}
+		if err := gs.delete(r); err != nil {
+			delErr.Add(fmt.Errorf("deleting '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		deleted = append(deleted, r)
+	}
+
+	if len(deleted) != 0 {
+		if err := gs.runHooks("post-delete", deleted...); err != nil {
+			delErr.Add(err)
 		}
 	}
 
@@ -259,20 +510,41 @@ func (gs *Gostore) Delete(pattern []string) error {
 }
 
 // Export copies a record's media file from the collection to the given destination.
-func (gs *Gostore) Export(dstFolder string, pattern []string) error {
+// If convertTo is not empty, the media file is converted to the given Type
+// using the configured converters.
+func (gs *Gostore) Export(dstFolder string, pattern []string, convertTo string) error {
 	records, err := gs.glob(pattern)
 	if err != nil {
 		return fmt.Errorf("exporting '%s' failed: %s", pattern, err)
 	}
 
+	var exported store.Records
 	var exportErr util.MultiErrors
 	for _, r := range records {
 		gs.log.Printf("Exporting '%s'", r.Key())
 
-		if err := gs.export(r, dstFolder); err != nil {
+		if err := gs.runHooks("pre-export", r); err != nil {
+			exportErr.Add(fmt.Errorf("exporting '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		if convertTo != "" {
+			err = gs.exportConverted(r, dstFolder, convertTo)
+		} else {
+			err = gs.export(r, dstFolder)
+		}
+		if err != nil {
 			exportErr.Add(fmt.Errorf("exporting '%s' failed: %s", r.Key(), err))
 			continue
 		}
+
+		exported = append(exported, r)
+	}
+
+	if len(exported) != 0 {
+		if err := gs.runHooks("post-export", exported...); err != nil {
+			exportErr.Add(err)
+		}
 	}
 
 	return exportErr.Err()
@@ -285,6 +557,10 @@ func (gs *Gostore) Export(dstFolder string, pattern []string) error {
 func (gs *Gostore) CheckAndRepair() error {
 	var errCheck util.MultiErrors
 
+	if err := gs.runHooks("pre-check"); err != nil {
+		return fmt.Errorf("checking collection failed: %s", err)
+	}
+
 	ghosts, err := gs.store.CheckGhosts()
 	if err != nil {
 		errCheck.Add(err)
@@ -326,6 +602,10 @@ func (gs *Gostore) CheckAndRepair() error {
 		errCheck.Add(err)
 	}
 
+	if err := gs.runHooks("post-check"); err != nil {
+		errCheck.Add(err)
+	}
+
 	return errCheck.Err()
 }
 
@@ -339,6 +619,118 @@ func (gs *Gostore) RebuildIndex() error {
 	return nil
 }
 
+// Migrate upgrades the collection's database to the current format. If
+// dryRun is set, migrations are only listed. Migrate expects the collection
+// not to be opened.
+func (gs *Gostore) Migrate(dryRun bool) error {
+	if gs.shared || gs.isServed() {
+		return fmt.Errorf("migrating collection failed: collection is opened by its server")
+	}
+
+	dryRun = dryRun || gs.pretend
+
+	migrations, err := gs.store.Migrate(dryRun)
+	if err != nil {
+		return fmt.Errorf("migrating collection failed: %s", err)
+	}
+
+	switch {
+	case len(migrations) == 0:
+		gs.ui.Printf("Collection's database is up to date\n")
+	case dryRun:
+		gs.ui.Printf("Migrations to apply:\n%s\n", strings.Join(migrations, "\n"))
+	default:
+		gs.ui.Printf("Applied migrations:\n%s\n", strings.Join(migrations, "\n"))
+	}
+
+	return nil
+}
+
+// Backup saves a snapshot of the collection into the dst archive. If since is
+// not zero, only records' files that were updated after since are saved
+// (incremental backup). The collection's index is only saved if withIndex is
+// set, otherwise it will be rebuilt when restoring.
+func (gs *Gostore) Backup(dst string, since time.Time, withIndex bool) (err error) {
+	gs.log.Printf("Backing-up collection to '%s'", dst)
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("backing-up collection failed: %s", err)
+	}
+	defer func() {
+		if e := f.Close(); err == nil && e != nil {
+			err = fmt.Errorf("backing-up collection failed: %s", e)
+		}
+
+		// do not leave a partial archive behind
+		if err != nil {
+			if e := os.Remove(dst); e != nil {
+				err = fmt.Errorf("%s\nFail to clean partial archive: %s", err, e)
+			}
+		}
+	}()
+
+	if err := gs.store.Backup(f, since, withIndex); err != nil {
+		return fmt.Errorf("backing-up collection failed: %s", err)
+	}
+
+	return nil
+}
+
+// Restore restores the collection from an archive generated by Backup then
+// opens it. Restore expects the collection not to be opened and leaves it to
+// the caller to close it, even if Restore fails.
+// The collection's index is rebuilt if the archive does not contain it or if
+// rebuildIndex is set. Once restored, the collection is checked for records
+// whose file is missing, which is for example the case if an incremental
+// backup is restored on its own.
+// In pretend mode, the archive's integrity is only checked.
+func (gs *Gostore) Restore(src string, rebuildIndex bool) error {
+	gs.log.Printf("Restoring collection from '%s'", src)
+
+	if gs.shared || gs.isServed() {
+		return fmt.Errorf("restoring collection failed: collection is opened by its server")
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("restoring collection failed: %s", err)
+	}
+	defer f.Close()
+
+	if gs.pretend {
+		if _, err := store.CheckBackup(f); err != nil {
+			return fmt.Errorf("restoring collection failed: %s", err)
+		}
+		return gs.Open()
+	}
+
+	manifest, err := gs.store.Restore(f)
+	if err != nil {
+		return fmt.Errorf("restoring collection failed: %s", err)
+	}
+
+	if err := gs.Open(); err != nil {
+		return err
+	}
+
+	if rebuildIndex || !manifest.WithIndex {
+		if err := gs.RebuildIndex(); err != nil {
+			return err
+		}
+	}
+
+	ghosts, err := gs.store.CheckGhosts()
+	if err != nil {
+		return fmt.Errorf("checking restored collection failed: %s", err)
+	}
+	if len(ghosts) > 0 {
+		gs.ui.Printf("Restored collection misses files for:\n%s\n", strings.Join(ghosts, "\n"))
+	}
+
+	return nil
+}
+
 // Fields lists fields names that are available for search or for templates.
 // Some fields might only be available for a given media Type.
 func (gs *Gostore) Fields() error {
@@ -365,17 +757,96 @@ func (gs *Gostore) glob(pattern []string) (store.Records, error) {
 	return rec, nil
 }
 
-func (gs *Gostore) insert(path string) (*store.Record, error) {
+// selectRecords retrieves the records selected either by a query or, if no
+// query is provided, by their name's glob pattern.
+func (gs *Gostore) selectRecords(pattern []string, query string) (store.Records, error) {
+	switch {
+	case query != "":
+		return gs.store.ReadQuery(query)
+	case len(pattern) != 0:
+		return gs.glob(pattern)
+	default:
+		return nil, fmt.Errorf("no record selected, use a name or a query")
+	}
+}
+
+// edit lets the user modify a record's data. Should the modified data not
+// follow the collection's schema, the user can edit them again or give up.
+func (gs *Gostore) edit(mdata map[string]interface{}) (map[string]interface{}, error) {
+	edited, err := gs.editValid([]map[string]interface{}{mdata}, func(m []map[string]interface{}) ([]map[string]interface{}, error) {
+		e, err := gs.ui.Edit(m[0])
+		return []map[string]interface{}{e}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return edited[0], nil
+}
+
+// multiEdit is like edit but for the data of several records at once.
+func (gs *Gostore) multiEdit(mdata []map[string]interface{}) ([]map[string]interface{}, error) {
+	return gs.editValid(mdata, gs.ui.MultiEdit)
+}
+
+// editValid modifies records' data using the given editing function until
+// they follow the collection's schema or the user gives up.
+func (gs *Gostore) editValid(mdata []map[string]interface{}, edit func([]map[string]interface{}) ([]map[string]interface{}, error)) ([]map[string]interface{}, error) {
+	for {
+		edited, err := edit(mdata)
+		if err != nil {
+			return nil, err
+		}
+
+		var validErr util.MultiErrors
+		for _, m := range edited {
+			if err := gs.store.Validate(m); err != nil {
+				validErr.Add(err)
+			}
+		}
+
+		err = validErr.Err()
+		if err == nil {
+			return edited, nil
+		}
+
+		gs.ui.Printf("%s\n", err)
+		if gs.ui.Choose("Edited record does not follow its schema:", "abort", "edit") != "edit" {
+			return nil, err
+		}
+		mdata = edited
+	}
+}
+
+// insert imports a new media into the collection. insert returns the stored
+// records, the new record first followed by the records linked to it by the
+// import modules (like converted files). The new record is kept even if
+// storing a linked record fails.
+func (gs *Gostore) insert(path string, mdata map[string]interface{}, cover string) (store.Records, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	r := store.NewRecord(filepath.Base(path), nil)
+	r := store.NewRecord(filepath.Base(path), mdata)
 	r.SetFile(f)
 
-	if err := modules.ProcessRecord(r, gs.importModules); err != nil {
+	if cover != "" {
+		c, err := os.Open(cover)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+
+		r.SetCover(c)
+	}
+
+	if err := gs.importModules.ProcessRecord(r); err != nil {
+		return nil, err
+	}
+
+	if err := gs.runHooks("pre-import", r); err != nil {
 		return nil, err
 	}
 
@@ -385,7 +856,36 @@ func (gs *Gostore) insert(path string) (*store.Record, error) {
 		}
 	}
 
-	return r, nil
+	linked, err := gs.insertLinked(r)
+	return append(store.Records{r}, linked...), err
+}
+
+// insertLinked stores the records linked to r (like converted files) once r
+// is stored, so that they refer to r's final key. insertLinked returns the
+// linked records that are successfully stored.
+func (gs *Gostore) insertLinked(r *store.Record) (store.Records, error) {
+	var inserted store.Records
+	var linkErr util.MultiErrors
+
+	for _, l := range r.Linked() {
+		gs.log.Printf("Importing '%s' linked to '%s'", l.Key(), r.Key())
+
+		if err := gs.runHooks("pre-import", l); err != nil {
+			linkErr.Add(fmt.Errorf("importing '%s' failed: %s", l.Key(), err))
+			continue
+		}
+
+		if !gs.pretend {
+			if err := gs.store.Insert(l); err != nil {
+				linkErr.Add(fmt.Errorf("importing '%s' failed: %s", l.Key(), err))
+				continue
+			}
+		}
+
+		inserted = append(inserted, l)
+	}
+
+	return inserted, linkErr.Err()
 }
 
 func (gs *Gostore) update(r *store.Record, mdata map[string]interface{}) error {
@@ -393,7 +893,11 @@ func (gs *Gostore) update(r *store.Record, mdata map[string]interface{}) error {
 
 	r.SetData(mdata)
 
-	if err := modules.ProcessRecord(r, gs.updateModules); err != nil {
+	if err := gs.updateModules.ProcessRecord(r); err != nil {
+		return err
+	}
+
+	if err := gs.runHooks("pre-update", r); err != nil {
 		return err
 	}
 
@@ -406,9 +910,99 @@ func (gs *Gostore) update(r *store.Record, mdata map[string]interface{}) error {
 	return nil
 }
 
-func (gs *Gostore) export(r *store.Record, dstFolder string) (err error) {
-	dstPath := filepath.Join(dstFolder, r.Key())
+func (gs *Gostore) delete(r *store.Record) error {
+	if err := gs.runHooks("pre-delete", r); err != nil {
+		return err
+	}
+
+	if !gs.pretend {
+		if err := gs.store.Delete(r.Key()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// process applies a pipeline of modules to an existing record. process
+// reports whether the record is updated and returns the records linked to it
+// by the modules (like converted files) that are stored.
+func (gs *Gostore) process(r *store.Record, pipeline modules.Pipeline) (bool, store.Records, error) {
+	key, before := r.Key(), r.Flatted()
+
+	f, err := gs.store.OpenRecord(r)
+	if err != nil {
+		return false, nil, err
+	}
+	defer f.Close()
+
+	if rf, ok := f.(store.Reader); ok {
+		r.SetFile(rf)
+	}
+	err = pipeline.ProcessRecord(r)
+	// Record's file is unchanged and should not be copied again in the store.
+	r.SetFile(nil)
+	if err != nil {
+		return false, nil, err
+	}
+
+	after := r.Flatted()
+	if reflect.DeepEqual(before, after) {
+		gs.log.Printf("'%s' is unchanged", key)
+		linked, err := gs.insertLinked(r)
+		return false, linked, err
+	}
+
+	gs.ui.PrettyDiff(before, after)
+	if !gs.ui.Confirm(fmt.Sprintf("Update '%s'?", key)) {
+		return false, nil, nil
+	}
+
+	if err := gs.runHooks("pre-update", r); err != nil {
+		return false, nil, err
+	}
+
+	if !gs.pretend {
+		if err := gs.store.Update(key, r); err != nil {
+			return false, nil, err
+		}
+	}
+
+	linked, err := gs.insertLinked(r)
+	return true, linked, err
+}
+
+func (gs *Gostore) export(r *store.Record, dstFolder string) error {
+	return gs.exportAs(r, filepath.Join(dstFolder, r.Key()))
+}
+
+func (gs *Gostore) exportConverted(r *store.Record, dstFolder string, to string) error {
+	from := media.TypeOf(r.Flatted())
+	conv := gs.converters.Find(from, to)
+	if conv == nil {
+		return fmt.Errorf("no converter from '%s' to '%s'", from, to)
+	}
+
+	tmpdir, err := ioutil.TempDir("", "gostore")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src := filepath.Join(tmpdir, filepath.Base(r.Key()))
+	if err := gs.exportAs(r, src); err != nil {
+		return err
+	}
+
+	dstPath := filepath.Join(dstFolder, conv.Name(r.Key()))
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0777); err != nil {
+		return err
+	}
+
+	return conv.Convert(src, dstPath)
+}
 
+func (gs *Gostore) exportAs(r *store.Record, dstPath string) (err error) {
 	f, err := gs.store.OpenRecord(r)
 	if err != nil {
 		return err
@@ -420,7 +1014,7 @@ func (gs *Gostore) export(r *store.Record, dstFolder string) (err error) {
 	}
 
 	var w *os.File
-	w, err = os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE, 0666)
+	w, err = os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -299,3 +301,205 @@ func testDelete(t *testing.T, gs *testGostore) {
 		}
 	})
 }
+
+func TestProcess(t *testing.T) {
+	cfg := newConfig()
+	rawcfg := `
+pipelines:
+    check:
+        - name: checker
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testCases := []string{
+		filepath.Join(testdataPath, "pg11-images.epub"),
+		filepath.Join(testdataPath, "pg1661-images.epub"),
+	}
+	if err := gs.Import(testCases); err != nil {
+		t.Fatalf("Fail to import epubs '%s': %v", testCases, err)
+	}
+
+	if err := gs.Process("check", []string{"pg11-images.epub"}, ""); err != nil {
+		t.Fatalf("Fail to process records: %v", err)
+	}
+
+	r, err := gs.store.Read("pg11-images.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+	if _, exists := r.Data()["QALevel"]; !exists {
+		t.Errorf("Fail to process record: QALevel is not set")
+	}
+
+	r, err = gs.store.Read("pg1661-images.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+	if _, exists := r.Data()["QALevel"]; exists {
+		t.Errorf("Record that is not selected should not be processed")
+	}
+
+	if err := gs.Process("unknown", nil, ""); err == nil {
+		t.Errorf("Processing records with an unknown pipeline should fail")
+	}
+}
+
+func TestImportWithTypePipeline(t *testing.T) {
+	cfg := newConfig()
+	rawcfg := `
+import:
+    book:
+        - name: mdatareader
+    media:
+        - name: checker
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	if err := gs.Import([]string{filepath.Join(testdataPath, "pg11-images.epub")}); err != nil {
+		t.Fatalf("Fail to import epub: %v", err)
+	}
+
+	r, err := gs.store.Read("pg11-images.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+
+	if _, exists := r.Data()["Title"]; !exists {
+		t.Errorf("Fail to import record using its media type's pipeline: Title is not set")
+	}
+	if _, exists := r.Data()["QALevel"]; exists {
+		t.Errorf("Fail to import record using its media type's pipeline: default pipeline has been applied")
+	}
+}
+
+func TestImportConverted(t *testing.T) {
+	cfg := newConfig()
+	rawcfg := `
+import:
+    - name: mdatareader
+    - name: converter
+      config:
+          converters:
+              - { from: book/epub, to: book/epub, ext: .copy.epub, cmd: 'cp "%s" "%s"' }
+          targets: [ book/epub ]
+    - name: organizer
+      config:
+          namingschemes:
+              media: 'books/{{ .Name }}'
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	if err := gs.Import([]string{filepath.Join(testdataPath, "pg11-images.epub")}); err != nil {
+		t.Fatalf("Fail to import epub: %v", err)
+	}
+
+	converted, err := gs.store.Read("pg11-images.copy.epub")
+	if err != nil {
+		t.Fatalf("Fail to read converted record: %v", err)
+	}
+	if got := converted.Get("ConvertedFrom"); got != "books/pg11-images.epub" {
+		t.Errorf("Converted record should refer to its source's final name.\nWant: %v\nGot : %v", "books/pg11-images.epub", got)
+	}
+
+	if err := gs.Delete([]string{"books/pg11-images.epub"}); err != nil {
+		t.Fatalf("Fail to delete record: %v", err)
+	}
+
+	if err := gs.Import([]string{filepath.Join(testdataPath, "pg11-images.epub")}); err == nil {
+		t.Errorf("Importing an already converted record should fail")
+	}
+	if exists, _ := gs.store.Exists("books/pg11-images.epub"); !exists {
+		t.Errorf("Record should be imported even if its converted record cannot be stored")
+	}
+}
+
+func TestBackupFailure(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	if _, err := gs.store.Create("a.epub", map[string]interface{}{"Title": "Les misérables"}, verify.MockROFile("0123456789")); err != nil {
+		t.Fatalf("Fail to add record: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(gs.root, "a.epub")); err != nil {
+		t.Fatalf("Fail to remove record's file: %v", err)
+	}
+
+	dst := filepath.Join(gs.root, "backup.tar.gz")
+	if err := gs.Backup(dst, time.Time{}, false); err == nil {
+		t.Fatalf("Backing-up a collection with a missing file should fail")
+	}
+
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("Failed backup should not leave a partial archive behind")
+	}
+}
+
+func TestRestorePretend(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	if _, err := gs.store.Create("a.epub", map[string]interface{}{"Title": "Les misérables"}, verify.MockROFile("0123456789")); err != nil {
+		t.Fatalf("Fail to add record: %v", err)
+	}
+
+	archive := filepath.Join(gs.root, "backup.tar.gz")
+	if err := gs.Backup(archive, time.Time{}, false); err != nil {
+		t.Fatalf("Fail to back-up collection: %v", err)
+	}
+
+	corrupted := filepath.Join(gs.root, "corrupted.tar.gz")
+	if err := ioutil.WriteFile(corrupted, []byte("not an archive"), 0666); err != nil {
+		t.Fatalf("Fail to write corrupted archive: %v", err)
+	}
+
+	if err := gs.Gostore.Close(); err != nil {
+		t.Fatalf("Fail to close collection: %v", err)
+	}
+	gs.pretend = true
+
+	if err := gs.Restore(filepath.Join(gs.root, "missing.tar.gz"), false); err == nil {
+		t.Errorf("Restoring a missing archive in pretend mode should fail")
+	}
+
+	if err := gs.Restore(corrupted, false); err == nil {
+		t.Errorf("Restoring a corrupted archive in pretend mode should fail")
+	}
+
+	if err := gs.Restore(archive, false); err != nil {
+		t.Errorf("Fail to restore archive in pretend mode: %v", err)
+	}
+}
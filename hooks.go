@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	// defaultHookTimeout is the maximum duration a hook is allowed to run if
+	// not otherwise specified.
+	defaultHookTimeout = 30 * time.Second
+)
+
+var (
+	// hookEvents lists the events that hooks can be attached to. "pre-"
+	// hooks run for each record before the collection is modified and can
+	// abort the action, "post-" hooks run once the action is over for all
+	// the affected records.
+	hookEvents = []string{
+		"pre-import", "post-import",
+		"pre-update", "post-update",
+		"pre-delete", "post-delete",
+		"pre-export", "post-export",
+		"pre-check", "post-check",
+	}
+)
+
+// hookPayload is the information sent to hooks.
+type hookPayload struct {
+	Event   string
+	Records []map[string]interface{}
+}
+
+// hook represents an action to run when an event occurs on the collection.
+type hook struct {
+	cmd     []string
+	url     string
+	timeout time.Duration
+}
+
+func newHook(cfg *hookConfig) (*hook, error) {
+	h := &hook{
+		url:     cfg.URL,
+		timeout: cfg.Timeout,
+	}
+
+	if h.timeout == 0 {
+		h.timeout = defaultHookTimeout
+	}
+
+	if cfg.Cmd != "" {
+		var err error
+		if h.cmd, err = shellquote.Split(cfg.Cmd); err != nil {
+			return nil, fmt.Errorf("bad command '%s': %v", cfg.Cmd, err)
+		}
+	}
+
+	if (len(h.cmd) == 0) == (h.url == "") {
+		return nil, fmt.Errorf("either a command or an url should be defined")
+	}
+
+	return h, nil
+}
+
+func (h *hook) run(event string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	if h.url != "" {
+		return h.post(ctx, payload)
+	}
+	return h.exec(ctx, event, payload)
+}
+
+func (h *hook) exec(ctx context.Context, event string, payload []byte) error {
+	cmd := exec.CommandContext(ctx, h.cmd[0], h.cmd[1:]...)
+	cmd.Env = append(os.Environ(), "GOSTORE_EVENT="+event)
+	cmd.Stdin = bytes.NewReader(payload)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("'%s' timed out after %v", h.cmd[0], h.timeout)
+		}
+		return fmt.Errorf("'%s' failed: %v\n%s", h.cmd[0], err, out)
+	}
+
+	return nil
+}
+
+func (h *hook) post(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("'%s' answered '%s'", h.url, resp.Status)
+	}
+
+	return nil
+}
+
+func newHooks(cfg map[string][]*hookConfig) (map[string][]*hook, error) {
+	hooks := make(map[string][]*hook)
+
+	for event, hookscfg := range cfg {
+		if !isHookEvent(event) {
+			return nil, fmt.Errorf("unknown event '%s' (available events: %s)", event, strings.Join(hookEvents, ", "))
+		}
+
+		for _, hookcfg := range hookscfg {
+			h, err := newHook(hookcfg)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create hook for '%s': %v", event, err)
+			}
+			hooks[event] = append(hooks[event], h)
+		}
+	}
+
+	return hooks, nil
+}
+
+func isHookEvent(event string) bool {
+	for _, e := range hookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// runHooks runs the hooks attached to the given event. Hooks are not run in
+// pretend mode as the collection is not modified.
+func (gs *Gostore) runHooks(event string, records ...*store.Record) error {
+	hooks := gs.hooks[event]
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	if gs.pretend {
+		gs.log.Printf("Skipping '%s' hooks in pretend mode", event)
+		return nil
+	}
+
+	p := &hookPayload{Event: event, Records: []map[string]interface{}{}}
+	for _, r := range records {
+		p.Records = append(p.Records, r.Flatted())
+	}
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("running '%s' hooks failed: %s", event, err)
+	}
+
+	var hookErr util.MultiErrors
+	for _, h := range hooks {
+		gs.log.Printf("Running '%s' hook", event)
+		if err := h.run(event, payload); err != nil {
+			hookErr.Add(fmt.Errorf("running '%s' hook failed: %s", event, err))
+		}
+	}
+
+	return hookErr.Err()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pirmd/verify"
+)
+
+func TestHooks(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name() + "_hooks")
+	if err != nil {
+		t.Fatalf("Failed to create hooks' folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	var posted []*hookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p := new(hookPayload)
+		if err := json.NewDecoder(req.Body).Decode(p); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		posted = append(posted, p)
+	}))
+	defer srv.Close()
+
+	cfg := newConfig()
+	rawcfg := `
+hooks:
+    post-import:
+        - cmd: sh -c 'cat > "` + tstDir.Fullpath("post-import.json") + `"'
+        - url: ` + srv.URL + `
+    pre-delete:
+        - cmd: sh -c 'test "$GOSTORE_EVENT" != "pre-delete"'
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testCases := []string{
+		filepath.Join(testdataPath, "pg11-images.epub"),
+		filepath.Join(testdataPath, "pg1661-images.epub"),
+	}
+	if err := gs.Import(testCases); err != nil {
+		t.Fatalf("Fail to import epubs '%s': %v", testCases, err)
+	}
+
+	buf, err := ioutil.ReadFile(tstDir.Fullpath("post-import.json"))
+	if err != nil {
+		t.Fatalf("Fail to run post-import command hook: %v", err)
+	}
+	got := new(hookPayload)
+	if err := json.Unmarshal(buf, got); err != nil {
+		t.Fatalf("Fail to read post-import command hook payload: %v", err)
+	}
+	if got.Event != "post-import" || len(got.Records) != 2 {
+		t.Errorf("Fail to run post-import command hook.\nWant: post-import with 2 records\nGot : %s with %d records", got.Event, len(got.Records))
+	}
+
+	if len(posted) != 1 || posted[0].Event != "post-import" || len(posted[0].Records) != 2 {
+		t.Errorf("Fail to run post-import webhook.\nGot : %+v", posted)
+	}
+
+	if err := gs.Delete([]string{"pg11-images.epub"}); err == nil {
+		t.Errorf("Failing pre-delete hook should abort deletion")
+	}
+	if exists, _ := gs.store.Exists("pg11-images.epub"); !exists {
+		t.Errorf("Failing pre-delete hook should abort deletion")
+	}
+}
+
+func TestNewHooks(t *testing.T) {
+	testCases := []map[string][]*hookConfig{
+		{"post-imported": {{Cmd: "true"}}},
+		{"post-import": {{}}},
+		{"post-import": {{Cmd: "true", URL: "http://localhost"}}},
+	}
+
+	for _, tc := range testCases {
+		if _, err := newHooks(tc); err == nil {
+			t.Errorf("Creating hooks from %+v should fail", tc)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	_ "github.com/pirmd/gostore/media/books"
@@ -10,5 +11,8 @@ import (
 func main() {
 	cfg := newConfig()
 	app := newApp(cfg)
-	app.MustRun(os.Args[1:])
+	if err := app.Run(os.Args[1:]); err != nil && err != errDelegated {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
 }
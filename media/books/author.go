@@ -0,0 +1,234 @@
+package books
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// authorParticles lists the particles that can be found between given
+	// and family names.
+	authorParticles = map[string]bool{
+		"d'": true, "da": true, "das": true, "de": true, "del": true, "della": true,
+		"den": true, "der": true, "des": true, "di": true, "do": true, "dos": true,
+		"du": true, "la": true, "le": true, "ten": true, "ter": true, "van": true,
+		"von": true, "zu": true,
+	}
+
+	// authorSuffixes lists the suffixes that can follow an author's name.
+	authorSuffixes = map[string]bool{
+		"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+		"fils": true, "père": true,
+	}
+
+	// reInitials matches given names written as initials like "V", "V." or
+	// "J.R.R.".
+	reInitials = regexp.MustCompile(`^(\p{Lu}\.)+\p{Lu}?$|^\p{Lu}$`)
+)
+
+// Author represents an author's name split into its components.
+type Author struct {
+	// Given is the author's given names, initials being normalized as "J. R.
+	// R.".
+	Given string
+	// Particle is the particle that precedes the family name ("de", "van"...).
+	Particle string
+	// Family is the author's family name.
+	Family string
+	// Suffix is the generational suffix ("Jr.", "III"...).
+	Suffix string
+}
+
+// ParseAuthor splits an author's name into its components. ParseAuthor
+// understands names written as "Victor Hugo", "Hugo, Victor", "V. Hugo" or
+// "Victor HUGO", and recognizes particles ("Honoré de Balzac", "Balzac,
+// Honoré de") and suffixes ("Martin Luther King, Jr.").
+func ParseAuthor(name string) *Author {
+	a := &Author{}
+
+	var parts []string
+	for _, p := range strings.Split(name, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) > 1 && isSuffix(parts[len(parts)-1]) {
+		a.Suffix = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+
+	switch len(parts) {
+	case 0:
+		return a
+
+	case 1:
+		a.parseNatural(strings.Fields(parts[0]))
+
+	default:
+		a.parseInverted(strings.Fields(parts[0]), strings.Fields(strings.Join(parts[1:], " ")))
+	}
+
+	return a
+}
+
+// parseNatural parses names written as "Given Particle Family Suffix".
+func (a *Author) parseNatural(tokens []string) {
+	if a.Suffix == "" && len(tokens) > 2 && isSuffix(tokens[len(tokens)-1]) {
+		a.Suffix, tokens = tokens[len(tokens)-1], tokens[:len(tokens)-1]
+	}
+
+	last := len(tokens) - 1
+	start, end := last, last
+	for i := 1; i < last; i++ {
+		if isParticle(tokens[i]) {
+			start, end = i, i
+			for end < last && isParticle(tokens[end]) {
+				end++
+			}
+			break
+		}
+	}
+
+	if start == end {
+		// no lower-cased particle, capitalized particles like "Da" or "Du"
+		// are considered as part of the family name.
+		for start > 1 && authorParticles[strings.ToLower(tokens[start-1])] {
+			start--
+		}
+		a.setGiven(tokens[:start])
+		a.Family = normalizeCase(strings.Join(tokens[start:], " "))
+		return
+	}
+
+	a.setGiven(tokens[:start])
+	a.Particle = strings.Join(tokens[start:end], " ")
+	a.Family = normalizeCase(strings.Join(tokens[end:], " "))
+}
+
+// parseInverted parses names written as "Particle Family, Given Particle".
+func (a *Author) parseInverted(family, given []string) {
+	var particle []string
+	for len(family) > 1 && isParticle(family[0]) {
+		particle, family = append(particle, family[0]), family[1:]
+	}
+
+	if len(particle) == 0 {
+		for len(given) > 1 && isParticle(given[len(given)-1]) {
+			particle = append([]string{given[len(given)-1]}, particle...)
+			given = given[:len(given)-1]
+		}
+	}
+
+	a.setGiven(given)
+	a.Particle = strings.Join(particle, " ")
+	a.Family = normalizeCase(strings.Join(family, " "))
+}
+
+func (a *Author) setGiven(tokens []string) {
+	var given []string
+	for _, t := range tokens {
+		if reInitials.MatchString(t) {
+			for _, r := range strings.ReplaceAll(t, ".", "") {
+				given = append(given, string(r)+".")
+			}
+			continue
+		}
+		given = append(given, normalizeCase(t))
+	}
+	a.Given = strings.Join(given, " ")
+}
+
+// String returns the author's name in display order (like "Honoré de
+// Balzac").
+func (a *Author) String() string {
+	return joinNonEmpty(" ", a.Given, a.Particle, a.Family, a.Suffix)
+}
+
+// SortName returns the author's name in sorting order (like "Balzac, Honoré
+// de").
+func (a *Author) SortName() string {
+	return joinNonEmpty(", ", a.Family, joinNonEmpty(" ", a.Given, a.Particle), a.Suffix)
+}
+
+// IsVariantOf checks whether a and b are likely to be written variants of the
+// same author's name, like "V. Hugo" and "Victor Hugo".
+func (a *Author) IsVariantOf(b *Author) bool {
+	if a.Family == "" || !strings.EqualFold(a.Family, b.Family) {
+		return false
+	}
+
+	if a.Particle != "" && b.Particle != "" && !strings.EqualFold(a.Particle, b.Particle) {
+		return false
+	}
+
+	if a.Suffix != "" && b.Suffix != "" && !strings.EqualFold(a.Suffix, b.Suffix) {
+		return false
+	}
+
+	ga, gb := strings.Fields(a.Given), strings.Fields(b.Given)
+	if len(ga) == 0 || len(gb) == 0 {
+		return len(ga) == len(gb)
+	}
+
+	for i := 0; i < len(ga) && i < len(gb); i++ {
+		if !isSameGivenName(ga[i], gb[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isSameGivenName(a, b string) bool {
+	if isInitial(a) || isInitial(b) {
+		ra, _ := utf8.DecodeRuneInString(a)
+		rb, _ := utf8.DecodeRuneInString(b)
+		return unicode.ToLower(ra) == unicode.ToLower(rb)
+	}
+	return strings.EqualFold(a, b)
+}
+
+func isInitial(s string) bool {
+	return utf8.RuneCountInString(s) == 2 && strings.HasSuffix(s, ".")
+}
+
+func isParticle(s string) bool {
+	return authorParticles[s]
+}
+
+func isSuffix(s string) bool {
+	return authorSuffixes[strings.TrimSuffix(strings.ToLower(s), ".")]
+}
+
+// normalizeCase converts upper-cased names like "HUGO" or "D'ALEMBERT" to
+// "Hugo" or "D'Alembert". Already mixed-cased names are kept unchanged.
+func normalizeCase(s string) string {
+	if strings.ToUpper(s) != s || strings.ToLower(s) == s || utf8.RuneCountInString(s) < 2 {
+		return s
+	}
+
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if upper {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		upper = !unicode.IsLetter(r)
+	}
+	return b.String()
+}
+
+func joinNonEmpty(sep string, s ...string) string {
+	var nonEmpty []string
+	for _, str := range s {
+		if str != "" {
+			nonEmpty = append(nonEmpty, str)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
@@ -0,0 +1,68 @@
+package books
+
+import (
+	"testing"
+)
+
+func TestParseAuthor(t *testing.T) {
+	testCases := []struct {
+		in       string
+		want     Author
+		wantName string
+		wantSort string
+	}{
+		{"Victor Hugo", Author{Given: "Victor", Family: "Hugo"}, "Victor Hugo", "Hugo, Victor"},
+		{"Hugo, Victor", Author{Given: "Victor", Family: "Hugo"}, "Victor Hugo", "Hugo, Victor"},
+		{"Victor HUGO", Author{Given: "Victor", Family: "Hugo"}, "Victor Hugo", "Hugo, Victor"},
+		{"V. Hugo", Author{Given: "V.", Family: "Hugo"}, "V. Hugo", "Hugo, V."},
+		{"J.R.R. Tolkien", Author{Given: "J. R. R.", Family: "Tolkien"}, "J. R. R. Tolkien", "Tolkien, J. R. R."},
+		{"Honoré de Balzac", Author{Given: "Honoré", Particle: "de", Family: "Balzac"}, "Honoré de Balzac", "Balzac, Honoré de"},
+		{"Balzac, Honoré de", Author{Given: "Honoré", Particle: "de", Family: "Balzac"}, "Honoré de Balzac", "Balzac, Honoré de"},
+		{"de Balzac, Honoré", Author{Given: "Honoré", Particle: "de", Family: "Balzac"}, "Honoré de Balzac", "Balzac, Honoré de"},
+		{"Jean de La Fontaine", Author{Given: "Jean", Particle: "de", Family: "La Fontaine"}, "Jean de La Fontaine", "La Fontaine, Jean de"},
+		{"Ursula K. Le Guin", Author{Given: "Ursula K.", Family: "Le Guin"}, "Ursula K. Le Guin", "Le Guin, Ursula K."},
+		{"Vincent van Gogh", Author{Given: "Vincent", Particle: "van", Family: "Gogh"}, "Vincent van Gogh", "Gogh, Vincent van"},
+		{"Martin Luther King, Jr.", Author{Given: "Martin Luther", Family: "King", Suffix: "Jr."}, "Martin Luther King Jr.", "King, Martin Luther, Jr."},
+		{"King, Martin Luther, Jr.", Author{Given: "Martin Luther", Family: "King", Suffix: "Jr."}, "Martin Luther King Jr.", "King, Martin Luther, Jr."},
+		{"Alexandre Dumas fils", Author{Given: "Alexandre", Family: "Dumas", Suffix: "fils"}, "Alexandre Dumas fils", "Dumas, Alexandre, fils"},
+		{"Voltaire", Author{Family: "Voltaire"}, "Voltaire", "Voltaire"},
+		{"", Author{}, "", ""},
+	}
+
+	for _, tc := range testCases {
+		got := ParseAuthor(tc.in)
+		if *got != tc.want {
+			t.Errorf("Parsing %#v failed:\nWant: %+v\nGot : %+v", tc.in, tc.want, *got)
+		}
+		if got.String() != tc.wantName {
+			t.Errorf("Display name of %#v failed:\nWant: %s\nGot : %s", tc.in, tc.wantName, got.String())
+		}
+		if got.SortName() != tc.wantSort {
+			t.Errorf("Sort name of %#v failed:\nWant: %s\nGot : %s", tc.in, tc.wantSort, got.SortName())
+		}
+	}
+}
+
+func TestIsVariantOf(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want bool
+	}{
+		{"Victor Hugo", "Hugo, Victor", true},
+		{"V. Hugo", "Victor Hugo", true},
+		{"Victor Hugo", "V. Hugo", true},
+		{"Victor Marie Hugo", "Victor Hugo", true},
+		{"Honoré de Balzac", "H. Balzac", true},
+		{"Victor Hugo", "François-Victor Hugo", false},
+		{"V. Hugo", "F. Hugo", false},
+		{"Victor Hugo", "Hugo", false},
+		{"Victor Hugo", "Victor Hugues", false},
+		{"Martin Luther King, Jr.", "Martin Luther King, Sr.", false},
+	}
+
+	for _, tc := range testCases {
+		if got := ParseAuthor(tc.a).IsVariantOf(ParseAuthor(tc.b)); got != tc.want {
+			t.Errorf("Checking whether %#v is a variant of %#v failed: want %v, got %v", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package calibre reads books and their metadata from a Calibre library.
+//
+// A Calibre library is made of a SQLite database (metadata.db) that stores
+// books' metadata, and of a folder per book that contains the book's files
+// (one per available format) and its cover.
+package calibre
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	// SQLite driver needed to read Calibre's database.
+	_ "modernc.org/sqlite"
+
+	"github.com/pirmd/gostore/media"
+)
+
+const (
+	// dbName is the name of Calibre's database inside a library.
+	dbName = "metadata.db"
+
+	// coverName is the name of a book's cover inside its folder.
+	coverName = "cover.jpg"
+)
+
+// Book represents a book from a Calibre library.
+type Book struct {
+	// ID is the book's identifier in Calibre's database.
+	ID int64
+
+	// Files lists, by format (like "EPUB"), the path to the book's files.
+	Files map[string]string
+
+	// Cover is the path to the book's cover image. It is empty if the book
+	// has no cover.
+	Cover string
+
+	// Metadata is the book's metadata, mapped to gostore's fields.
+	Metadata media.Metadata
+
+	dir string
+}
+
+// File returns the book's file of the first available format from the
+// provided list. If no format matches, the first available format in
+// alphabetical order is returned. File returns an empty string if the book
+// has no file.
+func (b *Book) File(formats ...string) string {
+	for _, f := range formats {
+		if path, ok := b.Files[strings.ToUpper(f)]; ok {
+			return path
+		}
+	}
+
+	var available []string
+	for f := range b.Files {
+		available = append(available, f)
+	}
+	if len(available) == 0 {
+		return ""
+	}
+	sort.Strings(available)
+
+	return b.Files[available[0]]
+}
+
+// Library represents a Calibre library.
+type Library struct {
+	root string
+	db   *sql.DB
+}
+
+// Open opens the Calibre library found at root in read-only mode.
+func Open(root string) (*Library, error) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(root, dbName)+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("calibre: cannot open library '%s': %v", root, err)
+	}
+
+	return &Library{root: root, db: db}, nil
+}
+
+// Close closes the library.
+func (l *Library) Close() error {
+	return l.db.Close()
+}
+
+// Books lists all books of the library.
+func (l *Library) Books() ([]*Book, error) {
+	rows, err := l.db.Query(`SELECT id, title, path, has_cover, pubdate, series_index FROM books ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("calibre: cannot read books: %v", err)
+	}
+	defer rows.Close()
+
+	var books []*Book
+	for rows.Next() {
+		var (
+			id          int64
+			title, path string
+			hasCover    bool
+			pubdate     sql.NullString
+			serieIdx    sql.NullFloat64
+		)
+
+		if err := rows.Scan(&id, &title, &path, &hasCover, &pubdate, &serieIdx); err != nil {
+			return nil, fmt.Errorf("calibre: cannot read books: %v", err)
+		}
+
+		b := &Book{
+			ID:    id,
+			dir:   filepath.Join(l.root, filepath.FromSlash(path)),
+			Files: make(map[string]string),
+			Metadata: media.Metadata{
+				"Title": title,
+			},
+		}
+
+		if hasCover {
+			b.Cover = filepath.Join(b.dir, coverName)
+		}
+
+		if stamp, ok := parseDate(pubdate.String); ok {
+			b.Metadata["PublishedDate"] = stamp
+		}
+
+		if serieIdx.Valid {
+			b.Metadata["SeriePosition"] = seriePosition(serieIdx.Float64)
+		}
+
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("calibre: cannot read books: %v", err)
+	}
+	rows.Close()
+
+	for _, b := range books {
+		if err := l.completeBook(b); err != nil {
+			return nil, fmt.Errorf("calibre: cannot read book %d: %v", b.ID, err)
+		}
+	}
+
+	return books, nil
+}
+
+func (l *Library) completeBook(b *Book) error {
+	authors, err := l.strings(`SELECT a.name FROM authors a JOIN books_authors_link l ON a.id = l.author WHERE l.book = ? ORDER BY l.id`, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(authors) > 0 {
+		b.Metadata["Authors"] = authors
+	}
+
+	tags, err := l.strings(`SELECT t.name FROM tags t JOIN books_tags_link l ON t.id = l.tag WHERE l.book = ? ORDER BY l.id`, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		b.Metadata["Subject"] = tags
+	}
+
+	series, err := l.strings(`SELECT s.name FROM series s JOIN books_series_link l ON s.id = l.series WHERE l.book = ?`, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(series) > 0 {
+		b.Metadata["Serie"] = series[0]
+	} else {
+		delete(b.Metadata, "SeriePosition")
+	}
+
+	publishers, err := l.strings(`SELECT p.name FROM publishers p JOIN books_publishers_link l ON p.id = l.publisher WHERE l.book = ?`, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(publishers) > 0 {
+		b.Metadata["Publisher"] = publishers[0]
+	}
+
+	languages, err := l.strings(`SELECT g.lang_code FROM languages g JOIN books_languages_link l ON g.id = l.lang_code WHERE l.book = ? ORDER BY l.item_order`, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(languages) > 0 {
+		b.Metadata["Language"] = languages[0]
+	}
+
+	comments, err := l.strings(`SELECT text FROM comments WHERE book = ?`, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(comments) > 0 && comments[0] != "" {
+		b.Metadata["Description"] = comments[0]
+	}
+
+	if err := l.readIdentifiers(b); err != nil {
+		return err
+	}
+
+	return l.readFiles(b)
+}
+
+func (l *Library) readIdentifiers(b *Book) error {
+	rows, err := l.db.Query(`SELECT type, val FROM identifiers WHERE book = ? ORDER BY type`, b.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var typ, val string
+		if err := rows.Scan(&typ, &val); err != nil {
+			return err
+		}
+
+		if strings.ToLower(typ) == "isbn" {
+			b.Metadata["ISBN"] = val
+			continue
+		}
+		ids = append(ids, typ+":"+val)
+	}
+	if len(ids) > 0 {
+		b.Metadata["Identifiers"] = ids
+	}
+
+	return rows.Err()
+}
+
+func (l *Library) readFiles(b *Book) error {
+	rows, err := l.db.Query(`SELECT format, name FROM data WHERE book = ?`, b.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var format, name string
+		if err := rows.Scan(&format, &name); err != nil {
+			return err
+		}
+		format = strings.ToUpper(format)
+		b.Files[format] = filepath.Join(b.dir, name+"."+strings.ToLower(format))
+	}
+
+	return rows.Err()
+}
+
+func (l *Library) strings(query string, args ...interface{}) ([]string, error) {
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+
+	return list, rows.Err()
+}
+
+// parseDate reads a Calibre's time stamp. Calibre uses year 101 to
+// represent an unknown date.
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02 15:04:05-07:00", "2006-01-02 15:04:05.999999-07:00", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			if t.Year() <= 101 {
+				return time.Time{}, false
+			}
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// seriePosition returns a serie position as an int if it is a whole number.
+func seriePosition(idx float64) interface{} {
+	if idx == math.Trunc(idx) {
+		return int(idx)
+	}
+	return idx
+}
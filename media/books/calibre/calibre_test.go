@@ -0,0 +1,134 @@
+package calibre
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/media"
+)
+
+var testSchema = []string{
+	`CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, path TEXT, has_cover BOOL, pubdate TIMESTAMP, series_index REAL)`,
+	`CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_authors_link (id INTEGER PRIMARY KEY, book INTEGER, author INTEGER)`,
+	`CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_tags_link (id INTEGER PRIMARY KEY, book INTEGER, tag INTEGER)`,
+	`CREATE TABLE series (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_series_link (id INTEGER PRIMARY KEY, book INTEGER, series INTEGER)`,
+	`CREATE TABLE publishers (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_publishers_link (id INTEGER PRIMARY KEY, book INTEGER, publisher INTEGER)`,
+	`CREATE TABLE languages (id INTEGER PRIMARY KEY, lang_code TEXT)`,
+	`CREATE TABLE books_languages_link (id INTEGER PRIMARY KEY, book INTEGER, lang_code INTEGER, item_order INTEGER)`,
+	`CREATE TABLE comments (id INTEGER PRIMARY KEY, book INTEGER, text TEXT)`,
+	`CREATE TABLE identifiers (id INTEGER PRIMARY KEY, book INTEGER, type TEXT, val TEXT)`,
+	`CREATE TABLE data (id INTEGER PRIMARY KEY, book INTEGER, format TEXT, name TEXT)`,
+}
+
+var testContent = []string{
+	`INSERT INTO books VALUES (1, 'Voyage au centre de la terre', 'Jules Verne/Voyage au centre de la terre (1)', 1, '1864-11-25 00:00:00+00:00', 2.0)`,
+	`INSERT INTO books VALUES (2, 'Les misérables', 'Victor Hugo/Les miserables (2)', 0, '0101-01-01 00:00:00+00:00', 1.0)`,
+	`INSERT INTO authors VALUES (1, 'Jules Verne'), (2, 'Victor Hugo')`,
+	`INSERT INTO books_authors_link VALUES (1, 1, 1), (2, 2, 2)`,
+	`INSERT INTO tags VALUES (1, 'Adventure'), (2, 'Science-Fiction')`,
+	`INSERT INTO books_tags_link VALUES (1, 1, 2), (2, 1, 1)`,
+	`INSERT INTO series VALUES (1, 'Voyages extraordinaires')`,
+	`INSERT INTO books_series_link VALUES (1, 1, 1)`,
+	`INSERT INTO publishers VALUES (1, 'Hetzel')`,
+	`INSERT INTO books_publishers_link VALUES (1, 1, 1)`,
+	`INSERT INTO languages VALUES (1, 'fra')`,
+	`INSERT INTO books_languages_link VALUES (1, 1, 1, 0), (2, 2, 1, 0)`,
+	`INSERT INTO comments VALUES (1, 1, 'A journey to the center of the Earth.')`,
+	`INSERT INTO identifiers VALUES (1, 1, 'isbn', '9782253006329'), (2, 1, 'google', 'abc123')`,
+	`INSERT INTO data VALUES (1, 1, 'EPUB', 'Voyage au centre de la terre - Jules Verne'), (2, 1, 'PDF', 'Voyage au centre de la terre - Jules Verne')`,
+}
+
+func setupLibrary(t *testing.T) *verify.TestFolder {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("cannot create test folder: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", tstDir.Fullpath(dbName))
+	if err != nil {
+		t.Fatalf("cannot create calibre database: %v", err)
+	}
+	defer db.Close()
+
+	for _, stmt := range append(testSchema, testContent...) {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("cannot populate calibre database: %v", err)
+		}
+	}
+
+	return tstDir
+}
+
+func TestBooks(t *testing.T) {
+	tstDir := setupLibrary(t)
+	defer tstDir.Clean()
+
+	lib, err := Open(tstDir.Fullpath(""))
+	if err != nil {
+		t.Fatalf("Fail to open library: %v", err)
+	}
+	defer lib.Close()
+
+	books, err := lib.Books()
+	if err != nil {
+		t.Fatalf("Fail to read books: %v", err)
+	}
+
+	if len(books) != 2 {
+		t.Fatalf("Fail to read books.\nWant: 2 books\nGot : %d books", len(books))
+	}
+
+	vernDir := tstDir.Fullpath("Jules Verne/Voyage au centre de la terre (1)")
+	want := media.Metadata{
+		"Title":         "Voyage au centre de la terre",
+		"Authors":       []string{"Jules Verne"},
+		"Subject":       []string{"Science-Fiction", "Adventure"},
+		"Serie":         "Voyages extraordinaires",
+		"SeriePosition": 2,
+		"Publisher":     "Hetzel",
+		"Language":      "fra",
+		"Description":   "A journey to the center of the Earth.",
+		"ISBN":          "9782253006329",
+		"Identifiers":   []string{"google:abc123"},
+		"PublishedDate": time.Date(1864, 11, 25, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(books[0].Metadata, want) {
+		t.Errorf("Fail to read book's metadata.\nWant: %#v\nGot : %#v", want, books[0].Metadata)
+	}
+
+	if wantCover := filepath.Join(vernDir, coverName); books[0].Cover != wantCover {
+		t.Errorf("Fail to read book's cover.\nWant: %s\nGot : %s", wantCover, books[0].Cover)
+	}
+
+	wantFile := filepath.Join(vernDir, "Voyage au centre de la terre - Jules Verne.pdf")
+	if got := books[0].File("pdf", "epub"); got != wantFile {
+		t.Errorf("Fail to select book's file.\nWant: %v\nGot : %v", wantFile, got)
+	}
+
+	wantFile = filepath.Join(vernDir, "Voyage au centre de la terre - Jules Verne.epub")
+	if got := books[0].File("mobi"); got != wantFile {
+		t.Errorf("Fail to select book's file.\nWant: %v\nGot : %v", wantFile, got)
+	}
+
+	want = media.Metadata{
+		"Title":    "Les misérables",
+		"Authors":  []string{"Victor Hugo"},
+		"Language": "fra",
+	}
+	if !reflect.DeepEqual(books[1].Metadata, want) {
+		t.Errorf("Fail to read book's metadata.\nWant: %#v\nGot : %#v", want, books[1].Metadata)
+	}
+
+	if got := books[1].File(); got != "" {
+		t.Errorf("Fail to select book's file.\nWant: no file\nGot : %v", got)
+	}
+}
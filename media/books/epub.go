@@ -1,12 +1,21 @@
 package books
 
 import (
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+
 	"github.com/pirmd/epub"
 	"github.com/pirmd/gostore/media"
 )
 
 var (
 	_ media.Handler = (*epubHandler)(nil)
+	_ media.Sampler = (*epubHandler)(nil)
 )
 
 type epubHandler struct {
@@ -35,6 +44,105 @@ func (mh *epubHandler) ReadMetadata(f media.File) (media.Metadata, error) {
 	return mdata, nil
 }
 
+// ReadSample reads a sample of the epub's text from its (x)html documents,
+// skipping the navigation and cover documents.
+func (mh *epubHandler) ReadSample(f media.File, size int) (string, error) {
+	fsize, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+
+	z, err := zip.NewReader(f, fsize)
+	if err != nil {
+		return "", err
+	}
+
+	sample := new(strings.Builder)
+	for _, zf := range z.File {
+		if sample.Len() >= size {
+			break
+		}
+
+		switch strings.ToLower(filepath.Ext(zf.Name)) {
+		case ".xhtml", ".html", ".htm":
+		default:
+			continue
+		}
+
+		name := strings.ToLower(filepath.Base(zf.Name))
+		if strings.Contains(name, "toc") || strings.Contains(name, "nav") || strings.Contains(name, "cover") {
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return "", err
+		}
+		err = readHTMLText(rc, sample, size)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if sample.Len() == 0 {
+		return "", media.ErrNoSample
+	}
+
+	s := sample.String()
+	if len(s) > size {
+		s = s[:size]
+		for len(s) > 0 && !utf8.RuneStart(s[len(s)-1]) {
+			s = s[:len(s)-1]
+		}
+		if len(s) > 0 && !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+	return s, nil
+}
+
+// readHTMLText appends the text of an html document to w until w reaches size
+// bytes.
+func readHTMLText(r io.Reader, w *strings.Builder, size int) error {
+	z := html.NewTokenizer(r)
+	var skipUntil string
+
+	for w.Len() < size {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+
+		case html.StartTagToken:
+			if name, _ := z.TagName(); skipUntil == "" {
+				switch string(name) {
+				case "head", "script", "style":
+					skipUntil = string(name)
+				}
+			}
+
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == skipUntil {
+				skipUntil = ""
+			}
+
+		case html.TextToken:
+			if skipUntil != "" {
+				continue
+			}
+			if txt := strings.Join(strings.Fields(string(z.Text())), " "); txt != "" {
+				w.WriteString(txt)
+				w.WriteString(" ")
+			}
+		}
+	}
+
+	return nil
+}
+
 func epub2mdata(epubData *epub.Metadata) media.Metadata {
 	mdata := make(media.Metadata)
 
@@ -58,11 +166,19 @@ func epub2mdata(epubData *epub.Metadata) media.Metadata {
 		mdata["Subject"] = epubData.Subject
 	}
 
+	var altISBN []string
 	for _, id := range epubData.Identifier {
-		if id.ID == "isbn" {
-			mdata["ISBN"] = id.Value
+		if isbn, ok := epubISBN(id); ok {
+			if _, exists := mdata["ISBN"]; !exists {
+				mdata["ISBN"] = isbn
+				continue
+			}
+			altISBN = append(altISBN, "isbn:"+isbn)
 		}
 	}
+	if len(altISBN) > 0 {
+		mdata["Identifiers"] = altISBN
+	}
 
 	if len(epubData.Publisher) > 0 {
 		mdata["Publisher"] = epubData.Publisher[0]
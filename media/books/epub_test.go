@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/pirmd/gostore/media"
@@ -46,3 +47,34 @@ func TestReadMetadata(t *testing.T) {
 		t.Errorf("Metadata is not as expected:\n%v", failure)
 	}
 }
+
+func TestReadSample(t *testing.T) {
+	testCases, err := filepath.Glob(filepath.Join(testdataPath, "*.epub"))
+	if err != nil {
+		t.Fatalf("cannot read test data in %s:%v", testdataPath, err)
+	}
+
+	epubH := &epubHandler{}
+
+	for _, tc := range testCases {
+		f, err := os.Open(tc)
+		if err != nil {
+			t.Fatalf("Failed to open test file %s: %v", tc, err)
+		}
+		defer f.Close()
+
+		sample, err := epubH.ReadSample(f, 512)
+		if err != nil {
+			t.Errorf("Fail to get sample for %s: %v", tc, err)
+			continue
+		}
+
+		if len(sample) == 0 || len(sample) > 512 {
+			t.Errorf("Sample for %s has not the expected size (%d)", tc, len(sample))
+		}
+
+		if strings.ContainsAny(sample, "<>") {
+			t.Errorf("Sample for %s contains html markup:\n%s", tc, sample)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package books
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pirmd/epub"
+)
+
+var (
+	// ErrInvalidISBN is raised when an ISBN is malformed or has a wrong
+	// checksum.
+	ErrInvalidISBN = fmt.Errorf("invalid ISBN")
+
+	// reISBNPrefix matches the prefixes that are commonly found in front of
+	// an ISBN like "urn:isbn:", "ISBN-13:" or "ISBN ".
+	reISBNPrefix = regexp.MustCompile(`^(?i)(?:urn:)?isbn(?:-?1[03])?[:\s]*`)
+)
+
+// ParseISBN validates an ISBN-10 or ISBN-13 and returns it in its canonical
+// form: an ISBN-13 without any formatting (hyphens, spaces or prefixes like
+// "urn:isbn:").
+func ParseISBN(s string) (string, error) {
+	isbn := reISBNPrefix.ReplaceAllString(strings.TrimSpace(s), "")
+	isbn = strings.NewReplacer("-", "", " ", "").Replace(isbn)
+
+	switch len(isbn) {
+	case 10:
+		if !isDigits(isbn[:9]) || (!isDigits(isbn[9:]) && isbn[9] != 'X' && isbn[9] != 'x') {
+			return "", fmt.Errorf("%v '%s': not a number", ErrInvalidISBN, s)
+		}
+
+		var sum int
+		for i := 0; i < 10; i++ {
+			d := 10
+			if i < 9 || (isbn[9] != 'X' && isbn[9] != 'x') {
+				d = int(isbn[i] - '0')
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 != 0 {
+			return "", fmt.Errorf("%v '%s': wrong checksum", ErrInvalidISBN, s)
+		}
+
+		isbn13 := "978" + isbn[:9]
+		return isbn13 + string('0'+isbn13Checksum(isbn13)), nil
+
+	case 13:
+		if !isDigits(isbn) {
+			return "", fmt.Errorf("%v '%s': not a number", ErrInvalidISBN, s)
+		}
+		if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+			return "", fmt.Errorf("%v '%s': unknown prefix", ErrInvalidISBN, s)
+		}
+		if isbn13Checksum(isbn[:12]) != isbn[12]-'0' {
+			return "", fmt.Errorf("%v '%s': wrong checksum", ErrInvalidISBN, s)
+		}
+		return isbn, nil
+
+	default:
+		return "", fmt.Errorf("%v '%s': wrong length", ErrInvalidISBN, s)
+	}
+}
+
+// isbn13Checksum computes the check digit of the first 12 digits of an
+// ISBN-13.
+func isbn13Checksum(digits string) byte {
+	var sum int
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte((10 - sum%10) % 10)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// epubISBN returns the ISBN of an epub identifier. ISBNs are recognized
+// whatever the spelling of their scheme or id attributes ("isbn", "ISBN",
+// "ISBN-13"...) or if their value is prefixed by "urn:isbn:" or "isbn:".
+func epubISBN(id epub.Identifier) (string, bool) {
+	value := strings.TrimSpace(id.Value)
+
+	lower := strings.ToLower(value)
+	for _, prefix := range []string{"urn:isbn:", "isbn:"} {
+		if strings.HasPrefix(lower, prefix) {
+			return strings.TrimSpace(value[len(prefix):]), true
+		}
+	}
+
+	if strings.Contains(strings.ToLower(id.Scheme), "isbn") || strings.Contains(strings.ToLower(id.ID), "isbn") {
+		return value, true
+	}
+
+	return "", false
+}
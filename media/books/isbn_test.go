@@ -0,0 +1,86 @@
+package books
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/epub"
+)
+
+func TestParseISBN(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"9782070360024", "9782070360024", false},
+		{"978-2-07-036002-4", "9782070360024", false},
+		{"urn:isbn:978-2-07-036002-4", "9782070360024", false},
+		{"ISBN 978 2 07 036002 4", "9782070360024", false},
+		{"ISBN-10: 2-07-036002-4", "9782070360024", false},
+		{"2070360024", "9782070360024", false},
+		{"080442957X", "9780804429573", false},
+		{"080442957x", "9780804429573", false},
+		{"9782070360025", "", true},
+		{"2070360025", "", true},
+		{"9772070360024", "", true},
+		{"97820703600", "", true},
+		{"not an isbn", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseISBN(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Parsing %#v should fail", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Fail to parse %#v: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Parsing %#v failed:\nWant: %s\nGot : %s", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestEpubISBN(t *testing.T) {
+	testCases := []struct {
+		in   []epub.Identifier
+		want map[string]interface{}
+	}{
+		{
+			in:   []epub.Identifier{{Value: "http://www.gutenberg.org/11", ID: "id"}},
+			want: map[string]interface{}{},
+		},
+		{
+			in:   []epub.Identifier{{Value: "9782070360024", ID: "isbn"}},
+			want: map[string]interface{}{"ISBN": "9782070360024"},
+		},
+		{
+			in:   []epub.Identifier{{Value: "978-2-07-036002-4", Scheme: "ISBN"}},
+			want: map[string]interface{}{"ISBN": "978-2-07-036002-4"},
+		},
+		{
+			in:   []epub.Identifier{{Value: "urn:isbn:9782070360024", ID: "BookId"}},
+			want: map[string]interface{}{"ISBN": "9782070360024"},
+		},
+		{
+			in: []epub.Identifier{
+				{Value: "urn:uuid:1234", ID: "uuid"},
+				{Value: "ISBN:9782070360024", ID: "pub-id"},
+				{Value: "2070360024", Scheme: "ISBN-10"},
+			},
+			want: map[string]interface{}{"ISBN": "9782070360024", "Identifiers": []string{"isbn:2070360024"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		got := epub2mdata(&epub.Metadata{Identifier: tc.in})
+		if !reflect.DeepEqual(map[string]interface{}(got), tc.want) {
+			t.Errorf("Fail to extract ISBN from %+v.\nWant: %#v\nGot : %#v", tc.in, tc.want, got)
+		}
+	}
+}
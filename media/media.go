@@ -9,6 +9,14 @@ import (
 var (
 	// ErrNoMetadataFound reports an error when no Metadata found
 	ErrNoMetadataFound = errors.New("media: no metadata found")
+
+	// ErrInvalidFile reports an error when a file does not match its expected
+	// media Type
+	ErrInvalidFile = errors.New("media: file does not match its type")
+
+	// ErrNoSample reports an error when no sample of a media content can be
+	// read
+	ErrNoSample = errors.New("media: no content sample available")
 )
 
 // Metadata represents a set of media's metadata, it is essentially a set of
@@ -40,6 +48,24 @@ func ReadMetadata(f File) (Metadata, error) {
 	return mdata, nil
 }
 
+// TypeOfFile guesses the media Type of the provided File from its content.
+// If no Handler knows the File, TypeOfFile feedbacks DefaultType.
+func TypeOfFile(f File) (string, error) {
+	mh, err := handlers.ForReader(f)
+	if _, serr := f.Seek(0, io.SeekStart); serr != nil {
+		return "", serr
+	}
+
+	switch {
+	case err == ErrUnknownMediaType:
+		return DefaultType, nil
+	case err != nil:
+		return "", err
+	}
+
+	return mh.Type(), nil
+}
+
 // ReadMetadataFromFile reads metadata from the provided file name.
 func ReadMetadataFromFile(path string) (Metadata, error) {
 	f, err := os.Open(path)
@@ -51,6 +77,63 @@ func ReadMetadataFromFile(path string) (Metadata, error) {
 	return ReadMetadata(f)
 }
 
+// CheckFile verifies that the provided File is a valid media of the given
+// Type, that is to say that it is recognized by the Type's Handler that is able
+// to read its metadata. CheckFile does nothing if no Handler is registered for
+// the given Type.
+func CheckFile(f File, typ string) error {
+	mh, err := handlers.ForType(typ)
+	if err == ErrUnknownMediaType || (err == nil && mh.Type() != typ) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fh, err := handlers.ForReader(f)
+	if err != nil || fh.Type() != typ {
+		return ErrInvalidFile
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := mh.ReadMetadata(f); err != nil {
+		return ErrInvalidFile
+	}
+
+	return nil
+}
+
+// Sampler is implemented by Handlers that can extract a sample of a media's
+// textual content.
+type Sampler interface {
+	// ReadSample reads up to size bytes of text from the media's content.
+	ReadSample(f File, size int) (string, error)
+}
+
+// ReadSample reads a sample of up to size bytes of the textual content of the
+// provided File. ReadSample returns ErrNoSample if the File's Handler does not
+// know how to read its content.
+func ReadSample(f File, size int) (string, error) {
+	mh, err := handlers.ForReader(f)
+	if err != nil {
+		return "", err
+	}
+
+	sampler, ok := mh.(Sampler)
+	if !ok {
+		return "", ErrNoSample
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return sampler.ReadSample(f, size)
+}
+
 // FetchMetadata retrieves the metadata from an external source (usually an
 // internet data base) that corresponds to the provided known data.
 func FetchMetadata(mdata Metadata) ([]Metadata, error) {
@@ -4,13 +4,19 @@ package all
 
 import (
 	// Silently import all available modules.
+	_ "github.com/pirmd/gostore/modules/authornormalizer"
 	_ "github.com/pirmd/gostore/modules/checker"
+	_ "github.com/pirmd/gostore/modules/converter"
 	_ "github.com/pirmd/gostore/modules/dehtmlizer"
 	_ "github.com/pirmd/gostore/modules/dupfinder"
+	_ "github.com/pirmd/gostore/modules/exec"
 	_ "github.com/pirmd/gostore/modules/fetcher"
 	_ "github.com/pirmd/gostore/modules/hasher"
+	_ "github.com/pirmd/gostore/modules/isbn"
+	_ "github.com/pirmd/gostore/modules/language"
 	_ "github.com/pirmd/gostore/modules/mdatareader"
 	_ "github.com/pirmd/gostore/modules/normalizer"
 	_ "github.com/pirmd/gostore/modules/organizer"
 	_ "github.com/pirmd/gostore/modules/scrubber"
+	_ "github.com/pirmd/gostore/modules/transformer"
 )
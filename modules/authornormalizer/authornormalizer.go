@@ -0,0 +1,189 @@
+// Package authornormalizer is a module that normalizes authors' names to a
+// canonical display form and computes their sort names.
+//
+// Variants of an author's name already known in the collection (like "V.
+// Hugo" for "Victor Hugo") are replaced by the existing name. Once merged,
+// variants are recorded as aliases in the store so that they are always
+// resolved the same way.
+package authornormalizer
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pirmd/gostore/media/books"
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	moduleName = "authornormalizer"
+)
+
+var (
+	_ modules.Module = (*authornormalizer)(nil) // Makes sure that we implement modules.Module interface.
+)
+
+// Config defines the different module's options.
+type Config struct {
+	// Field is the name of the field that holds the authors' names. Default
+	// to Authors.
+	Field string
+
+	// SortField is the name of the field where authors' sort names are
+	// stored. Default to AuthorsSort.
+	SortField string
+}
+
+func newConfig() *Config {
+	return &Config{
+		Field:     "Authors",
+		SortField: "AuthorsSort",
+	}
+}
+
+type authornormalizer struct {
+	log       *log.Logger
+	store     *store.Store
+	readOnly  bool
+	field     string
+	sortField string
+}
+
+func newAuthorNormalizer(cfg *Config, logger *log.Logger, store *store.Store, readOnly bool) (modules.Module, error) {
+	return &authornormalizer{
+		log:       logger,
+		store:     store,
+		readOnly:  readOnly,
+		field:     cfg.Field,
+		sortField: cfg.SortField,
+	}, nil
+}
+
+// ProcessRecord normalizes the record's authors' names and sets their sort
+// names.
+func (n *authornormalizer) ProcessRecord(r *store.Record) error {
+	var names []string
+
+	switch value := r.Get(n.field).(type) {
+	case nil:
+		return nil
+
+	case string:
+		names = []string{value}
+
+	case []string:
+		names = value
+
+	case []interface{}:
+		for _, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("module '%s': fail to normalize field %s: incorrect type (%T)", moduleName, n.field, v)
+			}
+			names = append(names, s)
+		}
+
+	default:
+		return fmt.Errorf("module '%s': fail to normalize field %s: incorrect type (%T)", moduleName, n.field, value)
+	}
+
+	var authors, sortNames []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		author, err := n.normalize(name)
+		if err != nil {
+			return fmt.Errorf("module '%s': fail to normalize '%s': %v", moduleName, name, err)
+		}
+
+		if author == "" || seen[author] {
+			continue
+		}
+		seen[author] = true
+
+		authors = append(authors, author)
+		sortNames = append(sortNames, books.ParseAuthor(author).SortName())
+	}
+
+	if _, isString := r.Get(n.field).(string); isString && len(authors) == 1 {
+		r.Set(n.field, authors[0])
+	} else {
+		r.Set(n.field, authors)
+	}
+	r.Set(n.sortField, strings.Join(sortNames, " & "))
+
+	return nil
+}
+
+// normalize returns the canonical form of an author's name.
+func (n *authornormalizer) normalize(name string) (string, error) {
+	n.log.Printf("Module '%s': normalizing '%s'", moduleName, name)
+
+	alias, err := n.store.Alias(n.field, name)
+	if err != nil {
+		return "", err
+	}
+	if alias != "" {
+		n.log.Printf("Module '%s': '%s' is a known alias of '%s'", moduleName, name, alias)
+		return alias, nil
+	}
+
+	author := books.ParseAuthor(name)
+	canonical := author.String()
+	if author.Family == "" {
+		return canonical, nil
+	}
+
+	_, values, err := n.store.MatchFields(0, n.field, author.Family)
+	if err != nil {
+		return "", err
+	}
+
+	var variant string
+	for _, v := range values[n.field] {
+		candidate, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		if candidate == canonical {
+			n.log.Printf("Module '%s': '%s' already exists in the collection", moduleName, canonical)
+			return canonical, nil
+		}
+
+		if variant == "" && books.ParseAuthor(candidate).IsVariantOf(author) {
+			variant = candidate
+		}
+	}
+
+	if variant == "" {
+		n.log.Printf("Module '%s': no known variant found. Use '%s'", moduleName, canonical)
+		return canonical, nil
+	}
+
+	n.log.Printf("Module '%s': '%s' is a variant of existing '%s'", moduleName, name, variant)
+	if !n.readOnly {
+		if err := n.store.SetAlias(n.field, name, variant); err != nil {
+			return "", err
+		}
+	}
+
+	return variant, nil
+}
+
+// NewFromRawConfig creates a new module from a raw configuration.
+func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
+	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
+	cfg := newConfig()
+
+	if err := rawcfg.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
+	return newAuthorNormalizer(cfg, env.Logger, env.Store, env.ReadOnly)
+}
+
+func init() {
+	modules.Register(moduleName, NewFromRawConfig)
+}
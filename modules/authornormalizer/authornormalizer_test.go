@@ -0,0 +1,109 @@
+package authornormalizer
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func setupStore(tb testing.TB) (*store.Store, func()) {
+	tstDir, err := verify.NewTestFolder(tb.Name())
+	if err != nil {
+		tb.Fatalf("Fail to create test folder: %v", err)
+	}
+
+	s, err := store.New(tstDir.Root)
+	if err != nil {
+		tstDir.Clean()
+		tb.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		tstDir.Clean()
+		tb.Fatalf("Fail to open testing Store: %s", err)
+	}
+
+	return s, func() {
+		tstDir.Clean()
+		if err := s.Close(); err != nil {
+			tb.Fatalf("Fail to properly close testing Store: %s", err)
+		}
+	}
+}
+
+func TestProcessRecord(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	if _, err := s.Create("hugo.epub", map[string]interface{}{"Authors": []string{"Victor Hugo"}}, verify.MockROFile("")); err != nil {
+		t.Fatalf("Fail to populate testing Store: %v", err)
+	}
+
+	n, err := newAuthorNormalizer(newConfig(), log.New(ioutil.Discard, "", 0), s, false)
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	testCases := []struct {
+		in       interface{}
+		want     interface{}
+		wantSort string
+	}{
+		{"Hugo, Victor", "Victor Hugo", "Hugo, Victor"},
+		{[]string{"V. Hugo"}, []string{"Victor Hugo"}, "Hugo, Victor"},
+		{[]interface{}{"VERNE, Jules", "Victor HUGO"}, []string{"Jules Verne", "Victor Hugo"}, "Verne, Jules & Hugo, Victor"},
+		{[]string{"Honoré de Balzac", "Balzac, Honoré de"}, []string{"Honoré de Balzac"}, "Balzac, Honoré de"},
+	}
+
+	for _, tc := range testCases {
+		r := store.NewRecord("test.epub", map[string]interface{}{"Authors": tc.in})
+		if err := n.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to process record for '%v': %v", tc.in, err)
+			continue
+		}
+
+		if got := r.Get("Authors"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to normalize authors.\nWant: %#v\nGot : %#v", tc.want, got)
+		}
+
+		if got := r.Get("AuthorsSort"); got != tc.wantSort {
+			t.Errorf("Fail to set authors' sort names.\nWant: %#v\nGot : %#v", tc.wantSort, got)
+		}
+	}
+
+	aliases, err := s.Aliases("Authors")
+	if err != nil {
+		t.Fatalf("Fail to read aliases: %v", err)
+	}
+	if want := map[string]string{"V. Hugo": "Victor Hugo"}; !reflect.DeepEqual(aliases, want) {
+		t.Errorf("Fail to record aliases.\nWant: %#v\nGot : %#v", want, aliases)
+	}
+}
+
+func TestProcessRecordWithAlias(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	if err := s.SetAlias("Authors", "Hugo", "Victor Hugo"); err != nil {
+		t.Fatalf("Fail to populate testing Store: %v", err)
+	}
+
+	n, err := newAuthorNormalizer(newConfig(), log.New(ioutil.Discard, "", 0), s, false)
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	r := store.NewRecord("test.epub", map[string]interface{}{"Authors": []string{"Hugo"}})
+	if err := n.ProcessRecord(r); err != nil {
+		t.Fatalf("Fail to process record: %v", err)
+	}
+
+	if got, want := r.Get("Authors"), []string{"Victor Hugo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fail to resolve alias.\nWant: %#v\nGot : %#v", want, got)
+	}
+}
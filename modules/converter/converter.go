@@ -0,0 +1,220 @@
+// Package converter converts a record's file into other formats using external
+// commands. Converted files are linked to the record they are converted from
+// (see store.Record.Link) so that they are stored as new records of the
+// collection once the record they are converted from is stored.
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+
+	"github.com/pirmd/gostore/media"
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	moduleName = "converter"
+
+	// ConvertedFromField is the name of the field of a converted record that
+	// contains the key of the record it is converted from.
+	ConvertedFromField = "ConvertedFrom"
+)
+
+var (
+	_ modules.Module = (*converter)(nil) // Makes sure that we implement modules.Module interface.
+)
+
+// Converter describes an external command that converts a media file from
+// one Type to another.
+type Converter struct {
+	// From is the Type of the media to convert (like "book/epub").
+	From string
+
+	// To is the Type of the converted media (like "book/kepub").
+	To string
+
+	// Ext is the extension of the converted files (like ".kepub.epub").
+	Ext string
+
+	// Cmd is the command line to convert a file. It accepts two arguments:
+	// the name of the file to convert and the name of the converted file.
+	Cmd string
+}
+
+// Name returns the name of a converted file.
+func (c *Converter) Name(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + c.Ext
+}
+
+// Convert converts src file into dst file. The converted file is checked to
+// be a valid media of the expected Type.
+func (c *Converter) Convert(src, dst string) error {
+	cmdline, err := shellquote.Split(fmt.Sprintf(c.Cmd, src, dst))
+	if err != nil {
+		return err
+	}
+	if len(cmdline) == 0 {
+		return fmt.Errorf("no conversion command defined from '%s' to '%s'", c.From, c.To)
+	}
+
+	if out, err := exec.Command(cmdline[0], cmdline[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("conversion from '%s' to '%s' failed: %v\n%s", c.From, c.To, err, out)
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return media.CheckFile(f, c.To)
+}
+
+// Converters represents a set of Converter.
+type Converters []*Converter
+
+// Find returns the Converter from a Type to another. Find returns nil if no
+// Converter is found.
+func (cs Converters) Find(from, to string) *Converter {
+	for _, c := range cs {
+		if c.From == from && c.To == to {
+			return c
+		}
+	}
+	return nil
+}
+
+// Config defines the different module's options.
+type Config struct {
+	// Converters lists the available conversions.
+	Converters Converters
+
+	// Targets lists the Types that records are to be converted to. Records
+	// for which no Converter to a given target exists are not converted.
+	Targets []string
+}
+
+func newConfig() *Config {
+	return &Config{}
+}
+
+type converter struct {
+	log *log.Logger
+
+	converters Converters
+	targets    []string
+}
+
+func newConverter(cfg *Config, logger *log.Logger) (*converter, error) {
+	for _, c := range cfg.Converters {
+		if c.Cmd == "" {
+			return nil, fmt.Errorf("module '%s': no command defined to convert from '%s' to '%s'", moduleName, c.From, c.To)
+		}
+	}
+
+	return &converter{
+		log:        logger,
+		converters: cfg.Converters,
+		targets:    cfg.Targets,
+	}, nil
+}
+
+// ProcessRecord converts the record's file to each target Type and links the
+// converted files to the record as new records.
+// Converted records share the metadata of the record they are converted from
+// and refer to it through ConvertedFromField.
+func (c *converter) ProcessRecord(r *store.Record) error {
+	if r.File() == nil {
+		c.log.Printf("Module '%s': no record's file available for %s", moduleName, r.Key())
+		return nil
+	}
+
+	typ := media.TypeOf(r.Flatted())
+	for _, target := range c.targets {
+		conv := c.converters.Find(typ, target)
+		if conv == nil {
+			c.log.Printf("Module '%s': no converter from '%s' to '%s'", moduleName, typ, target)
+			continue
+		}
+
+		if err := c.convert(r, conv); err != nil {
+			return fmt.Errorf("module '%s': fail to convert '%s' to '%s': %v", moduleName, r.Key(), target, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *converter) convert(r *store.Record, conv *Converter) error {
+	tmpdir, err := ioutil.TempDir("", moduleName)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src := filepath.Join(tmpdir, filepath.Base(r.Key()))
+	if err := writeFile(src, r.File()); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(tmpdir, conv.Name("converted"+filepath.Ext(r.Key())))
+	if err := conv.Convert(src, dst); err != nil {
+		return err
+	}
+
+	// Converted file is kept in memory as it is stored after the temporary
+	// folder is removed.
+	buf, err := ioutil.ReadFile(dst)
+	if err != nil {
+		return err
+	}
+
+	converted := store.NewRecord(conv.Name(r.Key()), r.Data())
+	converted.Set(media.TypeField, conv.To)
+	converted.SetFile(bytes.NewReader(buf))
+
+	c.log.Printf("Module '%s': linking '%s' converted from '%s'", moduleName, converted.Key(), r.Key())
+	r.Link(converted, ConvertedFromField)
+	return nil
+}
+
+func writeFile(path string, r io.Reader) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	_, err = io.Copy(f, r)
+	return
+}
+
+// NewFromRawConfig creates a new module from a raw configuration.
+func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
+	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
+	cfg := newConfig()
+
+	if err := rawcfg.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
+	return newConverter(cfg, env.Logger)
+}
+
+func init() {
+	modules.Register(moduleName, NewFromRawConfig)
+}
@@ -0,0 +1,119 @@
+package converter
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/media"
+	_ "github.com/pirmd/gostore/media/books"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	testdataPath = "../../testdata" //Use test data of the main gostore package
+)
+
+func TestConverterName(t *testing.T) {
+	c := &Converter{From: "book/epub", To: "book/kepub", Ext: ".kepub.epub"}
+
+	testCases := []struct {
+		in   string
+		want string
+	}{
+		{"Jules Verne - Voyage au centre de la terre.epub", "Jules Verne - Voyage au centre de la terre.kepub.epub"},
+		{"books/pg11-images.epub", "books/pg11-images.kepub.epub"},
+		{"README", "README.kepub.epub"},
+	}
+
+	for _, tc := range testCases {
+		if got := c.Name(tc.in); got != tc.want {
+			t.Errorf("Fail to name converted file for %s.\nWant: %v\nGot : %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestConvertersFind(t *testing.T) {
+	toKepub := &Converter{From: "book/epub", To: "book/kepub"}
+	toMobi := &Converter{From: "book/epub", To: "book/mobi"}
+	cs := Converters{toKepub, toMobi}
+
+	if got := cs.Find("book/epub", "book/mobi"); got != toMobi {
+		t.Errorf("Fail to find converter.\nWant: %v\nGot : %v", toMobi, got)
+	}
+
+	if got := cs.Find("book/pdf", "book/mobi"); got != nil {
+		t.Errorf("Fail to find converter.\nWant: %v\nGot : %v", nil, got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("cannot create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	if err := tstDir.Populate([]string{"empty.txt"}); err != nil {
+		t.Fatalf("cannot populate test folder: %v", err)
+	}
+
+	c := &Converter{From: "book/epub", To: "book/epub", Ext: ".epub", Cmd: `cp "%s" "%s"`}
+
+	if err := c.Convert(filepath.Join(testdataPath, "pg11-images.epub"), tstDir.Fullpath("pg11.epub")); err != nil {
+		t.Errorf("Fail to convert file: %v", err)
+	}
+
+	if err := tstDir.ShouldHaveFile("pg11.epub"); err != nil {
+		t.Errorf("Fail to convert file: %v", err)
+	}
+
+	if err := c.Convert(tstDir.Fullpath("empty.txt"), tstDir.Fullpath("empty.epub")); err != media.ErrInvalidFile {
+		t.Errorf("Converting to an invalid file should fail.\nWant: %v\nGot : %v", media.ErrInvalidFile, err)
+	}
+
+	c.Cmd = "false"
+	if err := c.Convert(filepath.Join(testdataPath, "pg11-images.epub"), tstDir.Fullpath("pg11-bis.epub")); err == nil {
+		t.Errorf("Failing conversion command should fail")
+	}
+}
+
+func TestProcessRecord(t *testing.T) {
+	c, err := newConverter(&Config{
+		Converters: Converters{{From: "book/epub", To: "book/epub", Ext: ".copy.epub", Cmd: `cp "%s" "%s"`}},
+		Targets:    []string{"book/epub"},
+	}, log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(testdataPath, "pg11-images.epub"))
+	if err != nil {
+		t.Fatalf("Fail to open test file: %v", err)
+	}
+	defer f.Close()
+
+	r := store.NewRecord("pg11.epub", map[string]interface{}{"Type": "book/epub", "Title": "Alice"})
+	r.SetFile(f)
+
+	if err := c.ProcessRecord(r); err != nil {
+		t.Fatalf("Fail to process record: %v", err)
+	}
+
+	r.SetKey("books/pg11.epub")
+	linked := r.Linked()
+	if len(linked) != 1 {
+		t.Fatalf("Fail to link converted record: got %d linked records", len(linked))
+	}
+
+	if linked[0].Key() != "pg11.copy.epub" || linked[0].Get("ConvertedFrom") != "books/pg11.epub" || linked[0].Get("Title") != "Alice" {
+		t.Errorf("Converted record is not as expected: %s %v", linked[0].Key(), linked[0].Data())
+	}
+	if linked[0].File() == nil {
+		t.Errorf("Converted record should have a file")
+	}
+}
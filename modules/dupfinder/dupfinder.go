@@ -3,10 +3,8 @@
 package dupfinder
 
 import (
-	"bytes"
 	"fmt"
 	"log"
-	"text/template"
 
 	"github.com/pirmd/gostore/modules"
 	"github.com/pirmd/gostore/store"
@@ -33,43 +31,31 @@ func newConfig() *Config {
 }
 
 type dupfinder struct {
-	log     *log.Logger
-	store   *store.Store
-	finders *template.Template
+	log    *log.Logger
+	finder *Finder
 }
 
 func newDupfinder(cfg *Config, logger *log.Logger, store *store.Store) (modules.Module, error) {
-	d := &dupfinder{
-		log:     logger,
-		store:   store,
-		finders: template.New("dupfinder"),
+	finder, err := NewFinder(cfg.DupQueries, store)
+	if err != nil {
+		return nil, err
 	}
-	d.finders.Funcs(funcmap)
 
-	for _, txt := range cfg.DupQueries {
-		if _, err := d.finders.New("").Parse(txt); err != nil {
-			return nil, err
-		}
-	}
-
-	return d, nil
+	return &dupfinder{
+		log:    logger,
+		finder: finder,
+	}, nil
 }
 
 // ProcessRecord searches for duplicates of a records and failed if any is found.
 func (d *dupfinder) ProcessRecord(r *store.Record) error {
-	for _, tmpl := range d.finders.Templates() {
-		query := new(bytes.Buffer)
-		if err := tmpl.Execute(query, r.Flatted()); err != nil {
-			return fmt.Errorf("module '%s': fail to look for duplicate: %v", moduleName, err)
-		}
-
-		matches, err := d.store.SearchQuery(query.String())
-		if err != nil {
-			return fmt.Errorf("module '%s': fail to look for duplicate: %v", moduleName, err)
-		}
-		if len(matches) > 0 {
-			return fmt.Errorf("module '%s': possible duplicate(s) of record (%v) found in the database", moduleName, matches)
-		}
+	matches, err := d.finder.Find(r)
+	if err != nil {
+		return fmt.Errorf("module '%s': fail to look for duplicate: %v", moduleName, err)
+	}
+
+	if len(matches) > 0 {
+		return fmt.Errorf("module '%s': possible duplicate(s) of record (%v) found in the database", moduleName, matches)
 	}
 
 	return nil
@@ -0,0 +1,66 @@
+package dupfinder
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pirmd/gostore/store"
+)
+
+// Finder looks for the duplicates of a record in a store.
+type Finder struct {
+	store   *store.Store
+	queries *template.Template
+}
+
+// NewFinder creates a Finder that looks for duplicates using the provided
+// queries templates. Queries, once expanded with the record's values, should
+// follow Store.SearchQuery query syntax.
+func NewFinder(queries []string, s *store.Store) (*Finder, error) {
+	f := &Finder{
+		store:   s,
+		queries: template.New("dupfinder"),
+	}
+	f.queries.Funcs(funcmap)
+
+	for _, txt := range queries {
+		if _, err := f.queries.New("").Parse(txt); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Find returns the keys of the records of the store that are possible
+// duplicates of r. r itself is never reported as a duplicate.
+func (f *Finder) Find(r *store.Record) ([]string, error) {
+	var dups []string
+	seen := map[string]bool{r.Key(): true}
+
+	for _, tmpl := range f.queries.Templates() {
+		query := new(bytes.Buffer)
+		if err := tmpl.Execute(query, r.Flatted()); err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(query.String()) == "" {
+			continue
+		}
+
+		matches, err := f.store.SearchQuery(query.String())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range matches {
+			if !seen[key] {
+				seen[key] = true
+				dups = append(dups, key)
+			}
+		}
+	}
+
+	return dups, nil
+}
@@ -0,0 +1,264 @@
+// Package exec delegates the processing of a record to an external command.
+//
+// The record's values are sent as JSON to the command's standard input and
+// the modified values are read back as JSON from the command's standard
+// output.
+//
+// The command's output is merged over the record's values: fields absent from
+// the output are left unchanged and a field is only removed if its output
+// value is an explicit JSON null.
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	moduleName = "exec"
+
+	// OnErrorFail aborts the processing of the record if the command fails.
+	OnErrorFail = "fail"
+	// OnErrorSkip leaves the record unchanged if the command fails.
+	OnErrorSkip = "skip"
+	// OnErrorKeep keeps the values output by the command, if any, even if the
+	// command fails.
+	OnErrorKeep = "keep"
+)
+
+var (
+	_ modules.Module = (*execer)(nil) // Makes sure that we implement modules.Module interface.
+)
+
+// Config defines the different module's options.
+type Config struct {
+	// Cmd is the command line to execute.
+	Cmd string
+
+	// WithFile is a flag that governs whether the record's file is provided
+	// to the command. If set, the path to a copy of the record's file is
+	// given as the last command's argument.
+	WithFile bool
+
+	// Timeout is the maximum duration the command is allowed to run. Default
+	// to one minute.
+	Timeout time.Duration
+
+	// Env lists additional environment variables to provide to the command.
+	// The record's name is always available in GOSTORE_NAME.
+	Env map[string]string
+
+	// OnError governs the behaviour when the command fails (non-zero exit
+	// code or timeout): 'fail' (default) aborts the record's processing,
+	// 'skip' leaves the record unchanged and 'keep' uses the values output
+	// by the command, if any.
+	OnError string
+}
+
+func newConfig() *Config {
+	return &Config{
+		Timeout: time.Minute,
+		OnError: OnErrorFail,
+	}
+}
+
+type execer struct {
+	log   *log.Logger
+	store *store.Store
+
+	cmd      []string
+	withFile bool
+	timeout  time.Duration
+	env      []string
+	onError  string
+}
+
+func newExecer(cfg *Config, logger *log.Logger) (*execer, error) {
+	cmd, err := shellquote.Split(cfg.Cmd)
+	if err != nil {
+		return nil, fmt.Errorf("module '%s': bad command '%s': %v", moduleName, cfg.Cmd, err)
+	}
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("module '%s': no command defined", moduleName)
+	}
+
+	switch cfg.OnError {
+	case OnErrorFail, OnErrorSkip, OnErrorKeep:
+	default:
+		return nil, fmt.Errorf("module '%s': unknown error behaviour '%s'. Select fail, skip or keep", moduleName, cfg.OnError)
+	}
+
+	e := &execer{
+		log:      logger,
+		cmd:      cmd,
+		withFile: cfg.WithFile,
+		timeout:  cfg.Timeout,
+		onError:  cfg.OnError,
+	}
+
+	for k, v := range cfg.Env {
+		e.env = append(e.env, k+"="+v)
+	}
+
+	return e, nil
+}
+
+// ProcessRecord sends the record's values to the command and updates the
+// record with the values read back from the command, a null value removing
+// the corresponding field.
+// The record is renamed if the command modifies its Name, records' time
+// stamps cannot be modified. Values of the end-user's personal information
+// (like Tags or Rating) are kept apart from the record's data.
+func (e *execer) ProcessRecord(r *store.Record) error {
+	in, err := json.Marshal(r.Flatted())
+	if err != nil {
+		return fmt.Errorf("module '%s': fail to encode record: %v", moduleName, err)
+	}
+
+	args := e.cmd[1:]
+	if e.withFile {
+		tmpdir, err := ioutil.TempDir("", moduleName)
+		if err != nil {
+			return fmt.Errorf("module '%s': %v", moduleName, err)
+		}
+		defer os.RemoveAll(tmpdir)
+
+		path := filepath.Join(tmpdir, filepath.Base(r.Key()))
+		if err := writeFile(path, r.File()); err != nil {
+			return fmt.Errorf("module '%s': fail to copy record's file: %v", moduleName, err)
+		}
+		args = append(append([]string{}, args...), path)
+	}
+
+	e.log.Printf("Module '%s': running '%s' for '%s'", moduleName, e.cmd[0], r.Key())
+	out, errRun := e.run(r.Key(), in, args)
+	if errRun != nil {
+		switch e.onError {
+		case OnErrorSkip:
+			e.log.Printf("Module '%s': %v. Skipping '%s'", moduleName, errRun, r.Key())
+			return nil
+		case OnErrorFail:
+			return fmt.Errorf("module '%s': %v", moduleName, errRun)
+		}
+		e.log.Printf("Module '%s': %v. Keeping output for '%s'", moduleName, errRun, r.Key())
+	}
+
+	if len(bytes.TrimSpace(out)) == 0 {
+		e.log.Printf("Module '%s': no output, '%s' is unchanged", moduleName, r.Key())
+		return nil
+	}
+
+	var mdata map[string]interface{}
+	if err := json.Unmarshal(out, &mdata); err != nil {
+		return fmt.Errorf("module '%s': fail to decode command's output: %v", moduleName, err)
+	}
+
+	if name, ok := mdata["Name"].(string); ok && name != "" && name != r.Key() {
+		e.log.Printf("Module '%s': renaming '%s' to '%s'", moduleName, r.Key(), name)
+		r.SetKey(name)
+	}
+	delete(mdata, "Name")
+	delete(mdata, "CreatedAt")
+	delete(mdata, "UpdatedAt")
+
+	data, user := r.Data(), r.UserData()
+	for k, v := range mdata {
+		switch _, isUser := user[k]; {
+		case v == nil:
+			delete(data, k)
+			delete(user, k)
+		case isUser || e.isUserField(k):
+			user[k] = v
+		default:
+			data[k] = v
+		}
+	}
+
+	r.SetData(data)
+	r.SetUserData(user)
+	return nil
+}
+
+func (e *execer) isUserField(field string) bool {
+	return e.store != nil && e.store.IsUserField(field)
+}
+
+func (e *execer) run(name string, in []byte, args []string) ([]byte, error) {
+	ctx := context.Background()
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, e.cmd[0], args...)
+	cmd.Env = append(append(os.Environ(), "GOSTORE_NAME="+name), e.env...)
+	cmd.Stdin = bytes.NewReader(in)
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return stdout.Bytes(), fmt.Errorf("command timed out after %v", e.timeout)
+		}
+		return stdout.Bytes(), fmt.Errorf("command failed: %v\n%s", err, stderr)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func writeFile(path string, r io.Reader) (err error) {
+	if r == nil {
+		return fmt.Errorf("no record's file available")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	_, err = io.Copy(f, r)
+	return
+}
+
+// NewFromRawConfig creates a new module from a raw configuration.
+func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
+	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
+	cfg := newConfig()
+
+	if err := rawcfg.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
+	e, err := newExecer(cfg, env.Logger)
+	if err != nil {
+		return nil, err
+	}
+	e.store = env.Store
+
+	return e, nil
+}
+
+func init() {
+	modules.Register(moduleName, NewFromRawConfig)
+}
@@ -0,0 +1,148 @@
+package exec
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func TestProcessRecord(t *testing.T) {
+	testCases := []struct {
+		cfg      *Config
+		wantName string
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			cfg:      &Config{Cmd: "cat", OnError: OnErrorFail},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Title": "Voyage au centre de la terre"},
+		},
+		{
+			cfg:      &Config{Cmd: `sh -c 'echo "{\"Name\": \"verne.epub\", \"Title\": \"$TITLE\"}"'`, Env: map[string]string{"TITLE": "Voyages"}, OnError: OnErrorFail},
+			wantName: "verne.epub",
+			want:     map[string]interface{}{"Title": "Voyages"},
+		},
+		{
+			cfg:      &Config{Cmd: `sh -c 'echo "{\"Title\": \"$GOSTORE_NAME\"}"'`, OnError: OnErrorFail},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Title": "test.epub"},
+		},
+		{
+			cfg:      &Config{Cmd: "true", OnError: OnErrorFail},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Title": "Voyage au centre de la terre"},
+		},
+		{
+			cfg:      &Config{Cmd: `sh -c 'echo "{\"Authors\": [\"Jules Verne\"]}"'`, OnError: OnErrorFail},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Title": "Voyage au centre de la terre", "Authors": []interface{}{"Jules Verne"}},
+		},
+		{
+			cfg:      &Config{Cmd: `sh -c 'echo "{\"Title\": null, \"Authors\": [\"Jules Verne\"]}"'`, OnError: OnErrorFail},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Authors": []interface{}{"Jules Verne"}},
+		},
+		{
+			cfg:     &Config{Cmd: "false", OnError: OnErrorFail},
+			wantErr: true,
+		},
+		{
+			cfg:      &Config{Cmd: `sh -c 'echo "{\"Title\": \"Voyages\"}"; exit 1'`, OnError: OnErrorSkip},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Title": "Voyage au centre de la terre"},
+		},
+		{
+			cfg:      &Config{Cmd: `sh -c 'echo "{\"Title\": \"Voyages\"}"; exit 1'`, OnError: OnErrorKeep},
+			wantName: "test.epub",
+			want:     map[string]interface{}{"Title": "Voyages"},
+		},
+		{
+			cfg:     &Config{Cmd: "sleep 5", Timeout: 100 * time.Millisecond, OnError: OnErrorFail},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		e, err := newExecer(tc.cfg, log.New(ioutil.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Fail to create module for '%s': %v", tc.cfg.Cmd, err)
+		}
+
+		r := store.NewRecord("test.epub", map[string]interface{}{"Title": "Voyage au centre de la terre"})
+		err = e.ProcessRecord(r)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Processing record with '%s' should fail", tc.cfg.Cmd)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Fail to process record with '%s': %v", tc.cfg.Cmd, err)
+			continue
+		}
+
+		if r.Key() != tc.wantName {
+			t.Errorf("Fail to process record name with '%s'.\nWant: %v\nGot : %v", tc.cfg.Cmd, tc.wantName, r.Key())
+		}
+
+		if got := r.Data(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to process record with '%s'.\nWant: %v\nGot : %v", tc.cfg.Cmd, tc.want, got)
+		}
+	}
+}
+
+func TestProcessRecordWithFile(t *testing.T) {
+	e, err := newExecer(&Config{Cmd: `sh -c 'printf "{\"Content\": \"%s\"}" "$(cat "$0")"'`, WithFile: true, OnError: OnErrorFail}, log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	r := store.NewRecord("test.txt", nil)
+	r.SetFile(strings.NewReader("Hello world"))
+
+	if err := e.ProcessRecord(r); err != nil {
+		t.Fatalf("Fail to process record: %v", err)
+	}
+
+	if got := r.Get("Content"); got != "Hello world" {
+		t.Errorf("Fail to provide record's file to command.\nWant: %v\nGot : %v", "Hello world", got)
+	}
+}
+
+func TestNewExecer(t *testing.T) {
+	for _, cfg := range []*Config{{Cmd: "", OnError: OnErrorFail}, {Cmd: "cat", OnError: "ignore"}} {
+		if _, err := newExecer(cfg, log.New(ioutil.Discard, "", 0)); err == nil {
+			t.Errorf("Creating module with config %+v should fail", cfg)
+		}
+	}
+}
+
+func TestProcessRecordWithUserData(t *testing.T) {
+	e, err := newExecer(&Config{Cmd: `sed -e 's/"classic"/"novel"/'`, OnError: OnErrorFail}, log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	r := store.NewRecord("test.epub", map[string]interface{}{"Title": "Voyage au centre de la terre"})
+	r.SetUserData(map[string]interface{}{"Tags": []interface{}{"classic"}, "Rating": 4.0})
+
+	if err := e.ProcessRecord(r); err != nil {
+		t.Fatalf("Fail to process record: %v", err)
+	}
+
+	want := map[string]interface{}{"Title": "Voyage au centre de la terre"}
+	if got := r.Data(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fail to keep user data out of record's data.\nWant: %v\nGot : %v", want, got)
+	}
+
+	wantUser := map[string]interface{}{"Tags": []interface{}{"novel"}, "Rating": 4.0}
+	if got := r.UserData(); !reflect.DeepEqual(got, wantUser) {
+		t.Errorf("Fail to update record's user data.\nWant: %v\nGot : %v", wantUser, got)
+	}
+}
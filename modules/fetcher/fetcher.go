@@ -23,26 +23,45 @@ var (
 
 // Config defines the different module's options.
 type Config struct {
+	// Merge describes how fetched metadata are merged with the record's
+	// stored values and with the metadata read from its media file.
+	Merge *modules.MergeConfig
+
+	// UseMerger delegates the merge of metadata to the user interface's
+	// merger (like mergercmd) instead of the built-in field-level merge.
+	// Default to true if the user interface has a merger configured.
+	UseMerger bool
 }
 
 func newConfig() *Config {
-	return &Config{}
+	return &Config{
+		Merge: modules.NewMergeConfig(),
+	}
 }
 
 type fetcher struct {
-	log *log.Logger
-	ui  ui.UserInterfacer
+	log       *log.Logger
+	ui        ui.UserInterfacer
+	merge     *modules.MergeConfig
+	useMerger bool
 }
 
 func newFetcher(cfg *Config, logger *log.Logger, UI ui.UserInterfacer) (*fetcher, error) {
+	if err := cfg.Merge.Check(); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
 	return &fetcher{
-		log: logger,
-		ui:  UI,
+		log:       logger,
+		ui:        UI,
+		merge:     cfg.Merge,
+		useMerger: cfg.UseMerger,
 	}, nil
 }
 
-// ProcessRecord updates a record's metadata based on the first result returned
-// by media.FetchMetadata.
+// ProcessRecord updates a record's metadata based on the result returned by
+// media.FetchMetadata. If several results are found, the user is asked to
+// pick the most relevant one.
 func (f *fetcher) ProcessRecord(r *store.Record) error {
 	f.log.Printf("Module '%s': fetch metadata for '%v'", moduleName, r.Data())
 	matches, err := media.FetchMetadata(r.Data())
@@ -55,13 +74,17 @@ func (f *fetcher) ProcessRecord(r *store.Record) error {
 		return nil
 	}
 
-	bestMatch := r.Data()
-	for k, v := range matches[0] {
-		bestMatch[k] = v
+	picked := 0
+	if len(matches) > 1 {
+		picked = f.ui.Pick(fmt.Sprintf("Found %d matches, pick the most relevant one", len(matches)), matches...)
+		if picked < 0 {
+			f.log.Printf("Module '%s': no match picked, aborting", moduleName)
+			return nil
+		}
 	}
 
-	f.log.Printf("Module '%s': found %d match(es), use the first one: %v", moduleName, len(matches), bestMatch)
-	mdata, err := f.ui.Merge(bestMatch, r.Data())
+	f.log.Printf("Module '%s': found %d match(es), use match #%d: %v", moduleName, len(matches), picked+1, matches[picked])
+	mdata, err := f.mergeMatch(r, matches[picked])
 	if err != nil {
 		return fmt.Errorf("module '%s': fail to merge fetched metadata: %v", moduleName, err)
 	}
@@ -72,10 +95,38 @@ func (f *fetcher) ProcessRecord(r *store.Record) error {
 	return nil
 }
 
+// mergeMatch merges the fetched metadata with the record's stored values.
+// Unless the merge is delegated to the user interface, metadata read from the
+// record's media file (if available) are also taken into account so that the
+// user can choose among the stored, file's or fetched values.
+func (f *fetcher) mergeMatch(r *store.Record, match map[string]interface{}) (map[string]interface{}, error) {
+	if f.useMerger {
+		bestMatch := r.Data()
+		for k, v := range match {
+			bestMatch[k] = v
+		}
+		return f.ui.Merge(bestMatch, r.Data())
+	}
+
+	var sources []*modules.MergeSource
+	if r.File() != nil {
+		mdataFromFile, err := media.ReadMetadata(r.File())
+		if err != nil {
+			f.log.Printf("Module '%s': fail to read metadata from file: %v", moduleName, err)
+		} else {
+			sources = append(sources, &modules.MergeSource{Name: "file", Data: mdataFromFile})
+		}
+	}
+	sources = append(sources, &modules.MergeSource{Name: "fetched", Data: match})
+
+	return f.merge.Merge(f.ui, r.Data(), sources...)
+}
+
 // NewFromRawConfig creates a new module from a raw configuration.
 func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
 	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
 	cfg := newConfig()
+	cfg.UseMerger = env.HasMerger
 
 	if err := rawcfg.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
@@ -0,0 +1,190 @@
+// Package isbn is a module that validates records' ISBN and converts them to
+// a canonical form (ISBN-13 without formatting) so that they can be reliably
+// searched for.
+package isbn
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pirmd/gostore/media/books"
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	moduleName = "isbn"
+
+	// isbnPrefix is the prefix of ISBNs stored in the list of alternate
+	// identifiers.
+	isbnPrefix = "isbn:"
+)
+
+var (
+	_ modules.Module = (*isbner)(nil) // Makes sure that we implement modules.Module interface.
+)
+
+// Config defines the different module's options.
+type Config struct {
+	// Field is the name of the field holding the record's ISBN. Default to
+	// ISBN.
+	Field string
+
+	// IdentifiersField is the name of the field holding the list of the
+	// record's alternate identifiers. When a record has several ISBNs, the
+	// first valid one is kept in Field, the others are kept in
+	// IdentifiersField as "isbn:<ISBN-13>". Default to Identifiers.
+	IdentifiersField string
+
+	// InvalidField is the name of the field where invalid ISBNs are moved to
+	// flag them. It is cleared once the record's ISBNs are valid. Default to
+	// InvalidISBN.
+	InvalidField string
+
+	// Strict is a flag that governs whether a record with an invalid ISBN is
+	// rejected.
+	Strict bool
+}
+
+func newConfig() *Config {
+	return &Config{
+		Field:            "ISBN",
+		IdentifiersField: "Identifiers",
+		InvalidField:     "InvalidISBN",
+	}
+}
+
+type isbner struct {
+	log *log.Logger
+
+	field        string
+	idsField     string
+	invalidField string
+	strict       bool
+}
+
+func newISBNer(cfg *Config, logger *log.Logger) (modules.Module, error) {
+	return &isbner{
+		log:          logger,
+		field:        cfg.Field,
+		idsField:     cfg.IdentifiersField,
+		invalidField: cfg.InvalidField,
+		strict:       cfg.Strict,
+	}, nil
+}
+
+// ProcessRecord validates and canonicalizes the record's ISBNs.
+func (m *isbner) ProcessRecord(r *store.Record) error {
+	candidates, err := stringsOf(r.Get(m.field))
+	if err != nil {
+		return fmt.Errorf("module '%s': fail to read field %s: %v", moduleName, m.field, err)
+	}
+
+	identifiers, err := stringsOf(r.Get(m.idsField))
+	if err != nil {
+		return fmt.Errorf("module '%s': fail to read field %s: %v", moduleName, m.idsField, err)
+	}
+
+	var otherIDs []string
+	for _, id := range identifiers {
+		if strings.HasPrefix(strings.ToLower(id), isbnPrefix) {
+			candidates = append(candidates, id[len(isbnPrefix):])
+			continue
+		}
+		otherIDs = append(otherIDs, id)
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var isbns, invalids []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		isbn, err := books.ParseISBN(c)
+		if err != nil {
+			m.log.Printf("Module '%s': %v", moduleName, err)
+			invalids = append(invalids, c)
+			continue
+		}
+
+		if !seen[isbn] {
+			seen[isbn] = true
+			isbns = append(isbns, isbn)
+		}
+	}
+
+	if len(invalids) > 0 && m.strict {
+		return fmt.Errorf("module '%s': invalid ISBN: %s", moduleName, strings.Join(invalids, ", "))
+	}
+
+	r.Del(m.field)
+	if len(isbns) > 0 {
+		m.log.Printf("Module '%s': canonical ISBN is '%s'", moduleName, isbns[0])
+		r.Set(m.field, isbns[0])
+	}
+
+	if len(isbns) > 1 {
+		for _, isbn := range isbns[1:] {
+			otherIDs = append(otherIDs, isbnPrefix+isbn)
+		}
+	}
+	r.Del(m.idsField)
+	if len(otherIDs) > 0 {
+		r.Set(m.idsField, otherIDs)
+	}
+
+	r.Del(m.invalidField)
+	if len(invalids) > 0 {
+		r.Set(m.invalidField, invalids)
+	}
+
+	return nil
+}
+
+func stringsOf(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+
+	case []string:
+		return append([]string{}, v...), nil
+
+	case []interface{}:
+		var l []string
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("incorrect type (%T)", item)
+			}
+			l = append(l, s)
+		}
+		return l, nil
+
+	default:
+		return nil, fmt.Errorf("incorrect type (%T)", v)
+	}
+}
+
+// NewFromRawConfig creates a new module from a raw configuration.
+func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
+	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
+	cfg := newConfig()
+
+	if err := rawcfg.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
+	return newISBNer(cfg, env.Logger)
+}
+
+func init() {
+	modules.Register(moduleName, NewFromRawConfig)
+}
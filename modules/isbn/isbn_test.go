@@ -0,0 +1,85 @@
+package isbn
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func TestProcessRecord(t *testing.T) {
+	testCases := []struct {
+		in   map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			in:   map[string]interface{}{"Title": "L'étranger"},
+			want: map[string]interface{}{"Title": "L'étranger"},
+		},
+		{
+			in:   map[string]interface{}{"ISBN": "urn:isbn:978-2-07-036002-4"},
+			want: map[string]interface{}{"ISBN": "9782070360024"},
+		},
+		{
+			in:   map[string]interface{}{"ISBN": "2-07-036002-4"},
+			want: map[string]interface{}{"ISBN": "9782070360024"},
+		},
+		{
+			in:   map[string]interface{}{"ISBN": "9782070360025"},
+			want: map[string]interface{}{"InvalidISBN": []string{"9782070360025"}},
+		},
+		{
+			in:   map[string]interface{}{"ISBN": "9782070360024", "InvalidISBN": []string{"9782070360025"}},
+			want: map[string]interface{}{"ISBN": "9782070360024"},
+		},
+		{
+			in:   map[string]interface{}{"Title": "L'étranger", "InvalidISBN": []string{"9782070360025"}},
+			want: map[string]interface{}{"Title": "L'étranger", "InvalidISBN": []string{"9782070360025"}},
+		},
+		{
+			in: map[string]interface{}{
+				"ISBN":        []interface{}{"9782070360025", "2070360024"},
+				"Identifiers": []string{"uuid:1234", "isbn:080442957X", "isbn:9782070360024"},
+			},
+			want: map[string]interface{}{
+				"ISBN":        "9782070360024",
+				"Identifiers": []string{"uuid:1234", "isbn:9780804429573"},
+				"InvalidISBN": []string{"9782070360025"},
+			},
+		},
+	}
+
+	m, err := newISBNer(newConfig(), log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	for _, tc := range testCases {
+		r := store.NewRecord("test.epub", tc.in)
+		if err := m.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to process record %v: %v", tc.in, err)
+			continue
+		}
+
+		if got := r.Data(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to process ISBN.\nWant: %#v\nGot : %#v", tc.want, got)
+		}
+	}
+}
+
+func TestProcessRecordStrict(t *testing.T) {
+	cfg := newConfig()
+	cfg.Strict = true
+
+	m, err := newISBNer(cfg, log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	r := store.NewRecord("test.epub", map[string]interface{}{"ISBN": "9782070360025"})
+	if err := m.ProcessRecord(r); err == nil {
+		t.Errorf("Processing record with an invalid ISBN should fail in strict mode")
+	}
+}
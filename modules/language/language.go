@@ -0,0 +1,254 @@
+// Package language is a module that normalizes records' language to a BCP-47
+// tag (like "en" or "en-US") and, when missing, tries to detect it from the
+// record's text fields or from a sample of the record's content.
+//
+// Language detection relies on counting stop words of each known language,
+// which is rough but good enough to tell apart most languages of a
+// collection.
+package language
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/lang/da"
+	"github.com/blevesearch/bleve/analysis/lang/de"
+	"github.com/blevesearch/bleve/analysis/lang/en"
+	"github.com/blevesearch/bleve/analysis/lang/es"
+	"github.com/blevesearch/bleve/analysis/lang/fi"
+	"github.com/blevesearch/bleve/analysis/lang/fr"
+	"github.com/blevesearch/bleve/analysis/lang/hu"
+	"github.com/blevesearch/bleve/analysis/lang/it"
+	"github.com/blevesearch/bleve/analysis/lang/nl"
+	"github.com/blevesearch/bleve/analysis/lang/no"
+	"github.com/blevesearch/bleve/analysis/lang/pt"
+	"github.com/blevesearch/bleve/analysis/lang/ro"
+	"github.com/blevesearch/bleve/analysis/lang/ru"
+	"github.com/blevesearch/bleve/analysis/lang/sv"
+	"github.com/blevesearch/bleve/analysis/lang/tr"
+	"github.com/blevesearch/bleve/registry"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+
+	"github.com/pirmd/gostore/media"
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	moduleName = "language"
+
+	// minStopWords is the minimum number of stop words that a text should
+	// contain for its language to be considered as detected.
+	minStopWords = 3
+)
+
+var (
+	_ modules.Module = (*languager)(nil) // Makes sure that we implement modules.Module interface.
+
+	// stopWords lists, by language, the name of bleve's stop words token map
+	// used to detect a text's language.
+	stopWords = map[string]string{
+		"da": da.StopName, "de": de.StopName, "en": en.StopName,
+		"es": es.StopName, "fi": fi.StopName, "fr": fr.StopName,
+		"hu": hu.StopName, "it": it.StopName, "nl": nl.StopName,
+		"no": no.StopName, "pt": pt.StopName, "ro": ro.StopName,
+		"ru": ru.StopName, "sv": sv.StopName, "tr": tr.StopName,
+	}
+)
+
+// Config defines the different module's options.
+type Config struct {
+	// Field is the name of the field that holds the record's language.
+	// Default to Language.
+	Field string
+
+	// DetectFrom lists the fields used to detect the record's language when
+	// missing. Default to Title and Description.
+	DetectFrom []string
+
+	// SampleSize is the size (in bytes) of the sample of the record's content
+	// that is read to detect the record's language when DetectFrom fields are
+	// not conclusive. Set it to 0 to disable content sampling. Default to
+	// 4096.
+	SampleSize int
+}
+
+func newConfig() *Config {
+	return &Config{
+		Field:      "Language",
+		DetectFrom: []string{"Title", "Description"},
+		SampleSize: 4096,
+	}
+}
+
+type languager struct {
+	log        *log.Logger
+	field      string
+	detectFrom []string
+	sampleSize int
+
+	stopWords map[string]analysis.TokenMap
+	names     map[string]language.Tag
+}
+
+func newLanguager(cfg *Config, logger *log.Logger) (modules.Module, error) {
+	cache := registry.NewCache()
+	sw := make(map[string]analysis.TokenMap, len(stopWords))
+	for lang, name := range stopWords {
+		tm, err := cache.TokenMapNamed(name)
+		if err != nil {
+			return nil, fmt.Errorf("module '%s': fail to load stop words for '%s': %v", moduleName, lang, err)
+		}
+		sw[lang] = tm
+	}
+
+	return &languager{
+		log:        logger,
+		field:      cfg.Field,
+		detectFrom: cfg.DetectFrom,
+		sampleSize: cfg.SampleSize,
+		stopWords:  sw,
+		names:      languageNames(),
+	}, nil
+}
+
+// ProcessRecord normalizes the record's language or detects it if missing.
+func (m *languager) ProcessRecord(r *store.Record) error {
+	if value, ok := r.Get(m.field).(string); ok && strings.TrimSpace(value) != "" {
+		tag, err := m.normalize(value)
+		if err == nil {
+			m.log.Printf("Module '%s': normalize language '%s' to '%s'", moduleName, value, tag)
+			r.Set(m.field, tag.String())
+			return nil
+		}
+		m.log.Printf("Module '%s': %v", moduleName, err)
+	}
+
+	lang, ok := m.detectFromFields(r)
+	if !ok {
+		lang, ok = m.detectFromContent(r)
+	}
+
+	if !ok {
+		m.log.Printf("Module '%s': fail to detect language", moduleName)
+		return nil
+	}
+
+	m.log.Printf("Module '%s': detected language is '%s'", moduleName, lang)
+	r.Set(m.field, lang)
+	return nil
+}
+
+// normalize converts a language code (like "eng" or "en_US") or a language
+// name (like "English" or "français") to a BCP-47 tag.
+func (m *languager) normalize(value string) (language.Tag, error) {
+	value = strings.TrimSpace(value)
+
+	if tag, err := language.Parse(value); err == nil && tag != language.Und {
+		return tag, nil
+	}
+
+	if tag, exists := m.names[strings.ToLower(value)]; exists {
+		return tag, nil
+	}
+
+	return language.Und, fmt.Errorf("unknown language '%s'", value)
+}
+
+func (m *languager) detectFromFields(r *store.Record) (string, bool) {
+	var txt []string
+	for _, field := range m.detectFrom {
+		if s, ok := r.Get(field).(string); ok {
+			txt = append(txt, s)
+		}
+	}
+
+	return m.detect(strings.Join(txt, " "))
+}
+
+func (m *languager) detectFromContent(r *store.Record) (string, bool) {
+	if m.sampleSize <= 0 || r.File() == nil {
+		return "", false
+	}
+
+	sample, err := media.ReadSample(r.File(), m.sampleSize)
+	if err != nil {
+		m.log.Printf("Module '%s': fail to read content sample: %v", moduleName, err)
+		return "", false
+	}
+
+	return m.detect(sample)
+}
+
+// detect guesses the language of a text as the language with the most stop
+// words in it. Detection fails if the text is too short or if no language
+// stands out.
+func (m *languager) detect(txt string) (string, bool) {
+	words := strings.FieldsFunc(strings.ToLower(txt), func(c rune) bool {
+		return !unicode.IsLetter(c)
+	})
+
+	var best, second int
+	var lang string
+	for l, tm := range m.stopWords {
+		var n int
+		for _, w := range words {
+			if tm[w] {
+				n++
+			}
+		}
+
+		switch {
+		case n > best:
+			best, second, lang = n, best, l
+		case n > second:
+			second = n
+		}
+	}
+
+	if best < minStopWords || best == second {
+		return "", false
+	}
+
+	return lang, true
+}
+
+// languageNames lists known languages by their lower-cased English and native
+// names.
+func languageNames() map[string]language.Tag {
+	names := make(map[string]language.Tag)
+	english := display.English.Languages()
+
+	for _, tag := range display.Supported.Tags() {
+		base, _ := tag.Base()
+		tag = language.Make(base.String())
+
+		for _, n := range []string{english.Name(tag), display.Self.Name(tag)} {
+			if _, exists := names[strings.ToLower(n)]; n != "" && !exists {
+				names[strings.ToLower(n)] = tag
+			}
+		}
+	}
+
+	return names
+}
+
+// NewFromRawConfig creates a new module from a raw configuration.
+func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
+	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
+	cfg := newConfig()
+
+	if err := rawcfg.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
+	return newLanguager(cfg, env.Logger)
+}
+
+func init() {
+	modules.Register(moduleName, NewFromRawConfig)
+}
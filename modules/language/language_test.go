@@ -0,0 +1,103 @@
+package language
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/gostore/store"
+
+	_ "github.com/pirmd/gostore/media/books" // Register epub handler.
+)
+
+func TestProcessRecord(t *testing.T) {
+	testCases := []struct {
+		in   map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			in:   map[string]interface{}{"Language": "eng"},
+			want: map[string]interface{}{"Language": "en"},
+		},
+		{
+			in:   map[string]interface{}{"Language": "en_US"},
+			want: map[string]interface{}{"Language": "en-US"},
+		},
+		{
+			in:   map[string]interface{}{"Language": "English"},
+			want: map[string]interface{}{"Language": "en"},
+		},
+		{
+			in:   map[string]interface{}{"Language": "français"},
+			want: map[string]interface{}{"Language": "fr"},
+		},
+		{
+			in: map[string]interface{}{
+				"Title":       "L'étranger",
+				"Description": "Le roman raconte la vie d'un homme qui est jugé pour un meurtre et pour ce qu'il est.",
+			},
+			want: map[string]interface{}{
+				"Title":       "L'étranger",
+				"Description": "Le roman raconte la vie d'un homme qui est jugé pour un meurtre et pour ce qu'il est.",
+				"Language":    "fr",
+			},
+		},
+		{
+			in: map[string]interface{}{
+				"Language":    "unknown",
+				"Description": "The story of a man who is judged for a murder and for what he is.",
+			},
+			want: map[string]interface{}{
+				"Language":    "en",
+				"Description": "The story of a man who is judged for a murder and for what he is.",
+			},
+		},
+		{
+			in:   map[string]interface{}{"Title": "Moby Dick"},
+			want: map[string]interface{}{"Title": "Moby Dick"},
+		},
+	}
+
+	m, err := newLanguager(newConfig(), log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	for _, tc := range testCases {
+		r := store.NewRecord("test.epub", tc.in)
+		if err := m.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to process record %v: %v", tc.in, err)
+			continue
+		}
+
+		if got := r.Data(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to process language.\nWant: %#v\nGot : %#v", tc.want, got)
+		}
+	}
+}
+
+func TestDetectFromContent(t *testing.T) {
+	f, err := os.Open("../../testdata/pg11-images.epub")
+	if err != nil {
+		t.Fatalf("Fail to open test file: %v", err)
+	}
+	defer f.Close()
+
+	m, err := newLanguager(newConfig(), log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	r := store.NewRecord("test.epub", map[string]interface{}{"Title": "Alice's Adventures in Wonderland"})
+	r.SetFile(f)
+
+	if err := m.ProcessRecord(r); err != nil {
+		t.Fatalf("Fail to process record: %v", err)
+	}
+
+	if got := r.Get("Language"); got != "en" {
+		t.Errorf("Fail to detect language from content.\nWant: en\nGot : %v", got)
+	}
+}
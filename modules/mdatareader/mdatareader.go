@@ -22,25 +22,44 @@ var (
 
 // Config defines the different module's options.
 type Config struct {
+	// Merge describes how metadata read from the media file are merged with
+	// the record's stored values.
+	Merge *modules.MergeConfig
+
+	// UseMerger delegates the merge of metadata to the user interface's
+	// merger (like mergercmd) instead of the built-in field-level merge.
+	// Default to true if the user interface has a merger configured.
+	UseMerger bool
 }
 
 func newConfig() *Config {
-	return &Config{}
+	return &Config{
+		Merge: modules.NewMergeConfig(),
+	}
 }
 
 type mdataReader struct {
-	log *log.Logger
-	ui  ui.UserInterfacer
+	log       *log.Logger
+	ui        ui.UserInterfacer
+	merge     *modules.MergeConfig
+	useMerger bool
 }
 
 func newMdataReader(cfg *Config, logger *log.Logger, UI ui.UserInterfacer) (modules.Module, error) {
+	if err := cfg.Merge.Check(); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
 	return &mdataReader{
-		log: logger,
-		ui:  UI,
+		log:       logger,
+		ui:        UI,
+		merge:     cfg.Merge,
+		useMerger: cfg.UseMerger,
 	}, nil
 }
 
-// ProcessRecord completes a record's metadata with a quality level.
+// ProcessRecord completes a record's metadata with the metadata read from
+// its media file.
 func (m *mdataReader) ProcessRecord(r *store.Record) error {
 	if r.File() == nil {
 		m.log.Printf("Module '%s': no record's file available for %s", moduleName, r.Key())
@@ -53,7 +72,12 @@ func (m *mdataReader) ProcessRecord(r *store.Record) error {
 	}
 
 	m.log.Printf("Module '%s': found metadata: %v", moduleName, mdataFromFile)
-	mdata, err := m.ui.Merge(mdataFromFile, r.Data())
+	var mdata map[string]interface{}
+	if m.useMerger {
+		mdata, err = m.ui.Merge(mdataFromFile, r.Data())
+	} else {
+		mdata, err = m.merge.Merge(m.ui, r.Data(), &modules.MergeSource{Name: "file", Data: mdataFromFile})
+	}
 	if err != nil {
 		return fmt.Errorf("module '%s': fail to merge metadata: %v", moduleName, err)
 	}
@@ -68,6 +92,7 @@ func (m *mdataReader) ProcessRecord(r *store.Record) error {
 func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
 	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
 	cfg := newConfig()
+	cfg.UseMerger = env.HasMerger
 
 	if err := rawcfg.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
@@ -0,0 +1,290 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pirmd/gostore/ui"
+)
+
+// Merge policies to solve conflicting values of a record's field.
+const (
+	// PreferExisting keeps the stored value or, if none, the oldest proposed
+	// value.
+	PreferExisting = "prefer-existing"
+	// PreferNew keeps the newest proposed value.
+	PreferNew = "prefer-new"
+	// Union keeps all values of lists.
+	Union = "union"
+	// Longest keeps the longest value (like the most detailed description).
+	Longest = "longest"
+
+	// storedSource is the name of the source of the stored values.
+	storedSource = "stored"
+
+	// optionValueWidth is the maximum length of values displayed when
+	// asking the user to solve a conflict.
+	optionValueWidth = 50
+)
+
+// MergeConfig describes how a record's stored values are merged with new
+// values proposed by a module (like values read from the media file or
+// fetched from online databases).
+type MergeConfig struct {
+	// Default is the policy applied to fields without specific policy.
+	// Default to prefer-new.
+	Default string
+
+	// Fields maps fields' names to their merge policy (prefer-existing,
+	// prefer-new, union or longest).
+	Fields map[string]string
+}
+
+// NewMergeConfig creates a new MergeConfig with default values.
+func NewMergeConfig() *MergeConfig {
+	return &MergeConfig{
+		Default: PreferNew,
+	}
+}
+
+// Check verifies that the configured policies are known.
+func (cfg *MergeConfig) Check() error {
+	if !isPolicy(cfg.Default) {
+		return fmt.Errorf("unknown merge policy '%s'", cfg.Default)
+	}
+
+	for field, policy := range cfg.Fields {
+		if !isPolicy(policy) {
+			return fmt.Errorf("unknown merge policy '%s' for field '%s'", policy, field)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *MergeConfig) policy(field string) string {
+	if policy, exists := cfg.Fields[field]; exists {
+		return policy
+	}
+	return cfg.Default
+}
+
+func isPolicy(policy string) bool {
+	switch policy {
+	case PreferExisting, PreferNew, Union, Longest:
+		return true
+	}
+	return false
+}
+
+// MergeSource is a set of values proposed for a record.
+type MergeSource struct {
+	// Name identifies the source to the user (like "file" or "fetched").
+	Name string
+	// Data contains the proposed values.
+	Data map[string]interface{}
+}
+
+// candidate is a value of a field together with the name of its source.
+type candidate struct {
+	source string
+	value  interface{}
+}
+
+// Merge performs a field-level merge of a record's stored values with the
+// values proposed by sources, sources being ordered from the oldest to the
+// newest.
+// Fields where only one distinct value exists take this value. Others are in
+// conflict: the field's merge policy chooses the value, the user being asked
+// to confirm it or to pick another one (unless the user interface is in
+// automatic mode).
+func (cfg *MergeConfig) Merge(UI ui.UserInterfacer, stored map[string]interface{}, sources ...*MergeSource) (map[string]interface{}, error) {
+	merged := make(map[string]interface{}, len(stored))
+	for k, v := range stored {
+		merged[k] = v
+	}
+
+	fields := make(map[string]bool)
+	for k := range stored {
+		fields[k] = true
+	}
+	for _, src := range sources {
+		for k := range src.Data {
+			fields[k] = true
+		}
+	}
+
+	for _, field := range sortedFields(fields) {
+		candidates := []*candidate{{storedSource, stored[field]}}
+		for _, src := range sources {
+			if v, exists := src.Data[field]; exists && !isEmptyValue(v) {
+				candidates = append(candidates, &candidate{src.Name, v})
+			}
+		}
+
+		distinct := distinctValues(candidates)
+		switch len(distinct) {
+		case 0:
+			// keep stored value, if any
+		case 1:
+			merged[field] = distinct[0].value
+		default:
+			merged[field] = solveConflict(UI, field, cfg.policy(field), distinct)
+		}
+	}
+
+	return merged, nil
+}
+
+// solveConflict chooses a value among conflicting candidates, proposing the
+// value selected by the merge policy as default.
+func solveConflict(UI ui.UserInterfacer, field string, policy string, candidates []*candidate) interface{} {
+	chosen := applyPolicy(policy, candidates)
+
+	options := []string{optionLabel(1, chosen)}
+	values := []interface{}{chosen.value}
+	for _, c := range candidates {
+		if c != chosen {
+			options = append(options, optionLabel(len(options)+1, c))
+			values = append(values, c.value)
+		}
+	}
+
+	// Truncated values can be alike, options are told apart by their number.
+	answer := UI.Choose(fmt.Sprintf("Conflicting values for '%s', keep", field), options...)
+	var i int
+	if _, err := fmt.Sscanf(answer, "%d)", &i); err != nil || i < 1 || i > len(values) {
+		return chosen.value
+	}
+	return values[i-1]
+}
+
+// applyPolicy selects among candidates the value to keep according to
+// policy. candidates are ordered from the stored value to the newest one and
+// have distinct non-empty values.
+func applyPolicy(policy string, candidates []*candidate) *candidate {
+	switch policy {
+	case PreferExisting:
+		return candidates[0]
+
+	case Union:
+		if union, ok := unionOf(candidates); ok {
+			return &candidate{Union, union}
+		}
+
+	case Longest:
+		longest := candidates[len(candidates)-1]
+		for i := len(candidates) - 2; i >= 0; i-- {
+			if len(valueText(candidates[i].value)) > len(valueText(longest.value)) {
+				longest = candidates[i]
+			}
+		}
+		return longest
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+// unionOf collects the values of candidates, at least one of them being a
+// list, removing duplicates.
+func unionOf(candidates []*candidate) ([]interface{}, bool) {
+	var union []interface{}
+	var hasList bool
+	seen := make(map[string]bool)
+
+	for _, c := range candidates {
+		values, isList := toList(c.value)
+		hasList = hasList || isList
+
+		for _, v := range values {
+			if key := valueText(v); !seen[key] {
+				seen[key] = true
+				union = append(union, v)
+			}
+		}
+	}
+
+	return union, hasList
+}
+
+// distinctValues removes candidates without value or with a value already
+// proposed by a previous candidate.
+func distinctValues(candidates []*candidate) []*candidate {
+	var distinct []*candidate
+	seen := make(map[string]bool)
+
+	for _, c := range candidates {
+		if isEmptyValue(c.value) {
+			continue
+		}
+
+		if key := valueText(c.value); !seen[key] {
+			seen[key] = true
+			distinct = append(distinct, c)
+		}
+	}
+
+	return distinct
+}
+
+// optionLabel describes the i-th candidate proposed to the user.
+func optionLabel(i int, c *candidate) string {
+	text := strings.Join(strings.Fields(valueText(c.value)), " ")
+	if r := []rune(text); len(r) > optionValueWidth {
+		text = string(r[:optionValueWidth-1]) + "…"
+	}
+	return fmt.Sprintf("%d) %s: %s", i, c.source, text)
+}
+
+// valueText gives a textual representation of a value, so that values can
+// be compared whatever their underlying type (like []string and
+// []interface{}).
+func valueText(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	if buf, err := json.Marshal(v); err == nil {
+		return string(buf)
+	}
+	return fmt.Sprint(v)
+}
+
+func toList(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case []string:
+		l := make([]interface{}, len(v))
+		for i, s := range v {
+			l[i] = s
+		}
+		return l, true
+	case nil:
+		return nil, false
+	}
+	return []interface{}{v}, false
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+
+	l, isList := toList(v)
+	return isList && len(l) == 0
+}
+
+func sortedFields(fields map[string]bool) []string {
+	sorted := make([]string, 0, len(fields))
+	for f := range fields {
+		sorted = append(sorted, f)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pirmd/gostore/ui/cli"
+)
+
+// chooser is a User Interface that answers conflicts by choosing the option
+// coming from a given source and records the conflicts it is asked about.
+type chooser struct {
+	*cli.CLI
+	source    string
+	conflicts []string
+}
+
+func (c *chooser) Choose(msg string, options ...string) string {
+	c.conflicts = append(c.conflicts, msg)
+	for _, o := range options {
+		if strings.Contains(o, ") "+c.source+":") {
+			return o
+		}
+	}
+	return options[0]
+}
+
+func TestMerge(t *testing.T) {
+	stored := map[string]interface{}{
+		"Title":       "Les miserables",
+		"Authors":     []string{"Victor Hugo"},
+		"Description": "A novel.",
+		"Publisher":   "",
+	}
+	file := &MergeSource{"file", map[string]interface{}{
+		"Title":       "Les misérables",
+		"Authors":     []interface{}{"Victor Hugo"},
+		"Description": "A French historical novel.",
+		"Publisher":   "Hachette",
+	}}
+	fetched := &MergeSource{"fetched", map[string]interface{}{
+		"Title":       "Les Misérables",
+		"Authors":     []interface{}{"Victor Hugo", "Isabel F. Hapgood"},
+		"Description": "",
+		"Subject":     []interface{}{"Fiction"},
+	}}
+
+	testCases := []struct {
+		cfg  *MergeConfig
+		want map[string]interface{}
+	}{
+		{
+			cfg: NewMergeConfig(),
+			want: map[string]interface{}{
+				"Title":       "Les Misérables",
+				"Authors":     []interface{}{"Victor Hugo", "Isabel F. Hapgood"},
+				"Description": "A French historical novel.",
+				"Publisher":   "Hachette",
+				"Subject":     []interface{}{"Fiction"},
+			},
+		},
+		{
+			cfg: &MergeConfig{
+				Default: PreferExisting,
+				Fields:  map[string]string{"Description": Longest, "Authors": Union},
+			},
+			want: map[string]interface{}{
+				"Title":       "Les miserables",
+				"Authors":     []interface{}{"Victor Hugo", "Isabel F. Hapgood"},
+				"Description": "A French historical novel.",
+				"Publisher":   "Hachette",
+				"Subject":     []interface{}{"Fiction"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		UI := &chooser{CLI: cli.New(), source: "none"}
+
+		got, err := tc.cfg.Merge(UI, stored, file, fetched)
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Merge with policy %+v failed:\nwant: %#v\ngot : %#v", tc.cfg, tc.want, got)
+		}
+
+		if len(UI.conflicts) != 3 {
+			t.Errorf("Merge should only ask about the 3 conflicting fields, got: %v", UI.conflicts)
+		}
+	}
+}
+
+func TestMergeInteractive(t *testing.T) {
+	stored := map[string]interface{}{"Title": "Les miserables", "Publisher": "Hachette"}
+	fetched := &MergeSource{"fetched", map[string]interface{}{"Title": "Les Misérables", "Publisher": "Hachette"}}
+
+	UI := &chooser{CLI: cli.New(), source: "stored"}
+	got, err := NewMergeConfig().Merge(UI, stored, fetched)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, stored) {
+		t.Errorf("Merge failed to keep user's choice:\nwant: %#v\ngot : %#v", stored, got)
+	}
+}
+
+// picker is a User Interface that answers conflicts by choosing the n-th
+// option.
+type picker struct {
+	*cli.CLI
+	n int
+}
+
+func (p *picker) Choose(msg string, options ...string) string {
+	return options[p.n]
+}
+
+func TestMergeInteractiveWithAlikeOptions(t *testing.T) {
+	long := strings.Repeat("Les misérables ", 5)
+	stored := map[string]interface{}{"Title": long + "tome 1"}
+	fetched := &MergeSource{"stored", map[string]interface{}{"Title": long + "tome 2"}}
+
+	cfg := NewMergeConfig()
+	cfg.Fields = map[string]string{"Title": PreferExisting}
+
+	got, err := cfg.Merge(&picker{CLI: cli.New(), n: 1}, stored, fetched)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if want := long + "tome 2"; got["Title"] != want {
+		t.Errorf("Merge failed to keep user's choice:\nwant: %#v\ngot : %#v", want, got["Title"])
+	}
+}
+
+func TestMergeConfigCheck(t *testing.T) {
+	if err := NewMergeConfig().Check(); err != nil {
+		t.Errorf("Default merge configuration should be valid: %v", err)
+	}
+
+	cfg := &MergeConfig{Default: PreferNew, Fields: map[string]string{"Title": "prefer-nothing"}}
+	if err := cfg.Check(); err == nil {
+		t.Errorf("Unknown merge policy should be reported")
+	}
+}
@@ -40,6 +40,11 @@ type Environment struct {
 	UI ui.UserInterfacer
 	// Store provides a collection's access facilities to a module.
 	Store *store.Store
+	// ReadOnly indicates that a module should not alter the collection.
+	ReadOnly bool
+	// HasMerger indicates that the user interface is configured with an
+	// external merger (like mergercmd).
+	HasMerger bool
 }
 
 // factory represents a module provider.
@@ -68,6 +68,7 @@ func (n *normalizer) ProcessRecord(r *store.Record) error {
 		case nil:
 
 		case []string:
+			normValue := []string{}
 			uniqueValue := make(map[string]struct{})
 			for _, v := range value {
 				normVal, err := n.normalize(field, v)
@@ -75,19 +76,17 @@ func (n *normalizer) ProcessRecord(r *store.Record) error {
 					return fmt.Errorf("module '%s': fail to normalize: %v", moduleName, err)
 				}
 				if normVal != nil {
-					uniqueValue[normVal.(string)] = struct{}{}
-				} else {
+					v = normVal.(string)
+				}
+				if _, exists := uniqueValue[v]; !exists {
 					uniqueValue[v] = struct{}{}
+					normValue = append(normValue, v)
 				}
 			}
-
-			normValue := []string{}
-			for k := range uniqueValue {
-				normValue = append(normValue, k)
-			}
 			r.Set(field, normValue)
 
 		case []interface{}:
+			normValue := []interface{}{}
 			uniqueValue := make(map[interface{}]struct{})
 			for _, v := range value {
 				normVal, err := n.normalize(field, v)
@@ -95,16 +94,13 @@ func (n *normalizer) ProcessRecord(r *store.Record) error {
 					return fmt.Errorf("module '%s': fail to normalize: %v", moduleName, err)
 				}
 				if normVal != nil {
-					uniqueValue[normVal] = struct{}{}
-				} else {
+					v = normVal
+				}
+				if _, exists := uniqueValue[v]; !exists {
 					uniqueValue[v] = struct{}{}
+					normValue = append(normValue, v)
 				}
 			}
-
-			normValue := []interface{}{}
-			for k := range uniqueValue {
-				normValue = append(normValue, k)
-			}
 			r.Set(field, normValue)
 
 		case interface{}:
@@ -0,0 +1,72 @@
+package normalizer
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func setupStore(tb testing.TB) (*store.Store, func()) {
+	tstDir, err := verify.NewTestFolder(tb.Name())
+	if err != nil {
+		tb.Fatalf("Fail to create test folder: %v", err)
+	}
+
+	s, err := store.New(tstDir.Root)
+	if err != nil {
+		tstDir.Clean()
+		tb.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		tstDir.Clean()
+		tb.Fatalf("Fail to open testing Store: %s", err)
+	}
+
+	return s, func() {
+		tstDir.Clean()
+		if err := s.Close(); err != nil {
+			tb.Fatalf("Fail to properly close testing Store: %s", err)
+		}
+	}
+}
+
+func TestProcessRecord(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	if _, err := s.Create("hugo.epub", map[string]interface{}{"Authors": []string{"Victor Hugo"}}, verify.MockROFile("")); err != nil {
+		t.Fatalf("Fail to populate testing Store: %v", err)
+	}
+
+	n, err := newNormalizer(&Config{Fields: []string{"Authors"}}, log.New(ioutil.Discard, "", 0), s)
+	if err != nil {
+		t.Fatalf("Fail to create module: %v", err)
+	}
+
+	testCases := []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{"victor hugo", "Victor Hugo"},
+		{[]string{"Jules Verne", "victor hugo", "Alexandre Dumas", "Victor Hugo"}, []string{"Jules Verne", "Victor Hugo", "Alexandre Dumas"}},
+		{[]interface{}{"Jules Verne", "victor hugo", "Alexandre Dumas", "Victor Hugo"}, []interface{}{"Jules Verne", "Victor Hugo", "Alexandre Dumas"}},
+	}
+
+	for _, tc := range testCases {
+		r := store.NewRecord("test.epub", map[string]interface{}{"Authors": tc.in})
+		if err := n.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to normalize %v: %v", tc.in, err)
+			continue
+		}
+
+		if got := r.Get("Authors"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to normalize %v.\nWant: %#v\nGot : %#v", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pirmd/gostore/media"
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+var (
+	_ Module = (*conditional)(nil) // Makes sure that we implement Module interface.
+	_ Module = (Pipeline)(nil)     // Makes sure that we implement Module interface.
+)
+
+// conditional represents a module that only applies to records matching a
+// condition.
+type conditional struct {
+	Module
+	when *template.Template
+}
+
+// NewConditional creates a Module that only applies m to the records for which
+// the when condition is true.
+// The when condition is a template that is executed against the record's
+// values and that should output either "true" or "false". Extensions to
+// template idiom are available to simplify common operations:
+// - isOfType: check if a record is of a given media Type,
+// - tmpl/tmplExec/tmplFile: execute a sub-template.
+func NewConditional(m Module, when string) (Module, error) {
+	t := template.New("when")
+	funcs := template.FuncMap{
+		"isOfType": media.IsOfType,
+	}
+	for stName, stFunc := range util.FuncMap(t) {
+		funcs[stName] = stFunc
+	}
+
+	if _, err := t.Funcs(funcs).Parse(when); err != nil {
+		return nil, fmt.Errorf("bad condition '%s': %v", when, err)
+	}
+
+	return &conditional{Module: m, when: t}, nil
+}
+
+// ProcessRecord applies the module to the record if the condition is true.
+func (c *conditional) ProcessRecord(r *store.Record) error {
+	buf := new(bytes.Buffer)
+	if err := c.when.Execute(buf, r.Flatted()); err != nil {
+		return fmt.Errorf("fail to evaluate condition: %v", err)
+	}
+
+	ok, err := strconv.ParseBool(strings.TrimSpace(buf.String()))
+	if err != nil {
+		return fmt.Errorf("condition should evaluate to true or false, got '%s'", buf.String())
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return c.Module.ProcessRecord(r)
+}
+
+// Pipeline represents the series of modules to apply to records, by media
+// Type. The series of modules defined for media.DefaultType applies to any
+// records whose Type has no specific series of modules.
+type Pipeline map[string][]Module
+
+// ProcessRecord applies the series of modules that corresponds to the
+// record's Type. Records without Type (like newly imported ones) get the
+// series of modules of the Type guessed from their file. If an error occurs,
+// ProcessRecord stops and feedback the error.
+func (p Pipeline) ProcessRecord(r *store.Record) error {
+	typ := media.TypeOf(r.Flatted())
+
+	if typ == media.DefaultType && r.File() != nil {
+		var err error
+		if typ, err = media.TypeOfFile(r.File()); err != nil {
+			return fmt.Errorf("fail to guess media type: %v", err)
+		}
+	}
+
+	return ProcessRecord(r, p.For(typ))
+}
+
+// For returns the series of modules defined for a given media Type. For falls
+// back to the series of modules of the Type's sub-family, family then to
+// media.DefaultType.
+func (p Pipeline) For(typ string) []Module {
+	for _, t := range []string{typ, filepath.Base(typ), filepath.Dir(typ)} {
+		if mods, exists := p[t]; exists {
+			return mods
+		}
+	}
+
+	return p[media.DefaultType]
+}
@@ -0,0 +1,90 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/gostore/store"
+)
+
+// tagger is a Module that records in the Record's "Tags" field that it was
+// applied.
+type tagger string
+
+func (t tagger) ProcessRecord(r *store.Record) error {
+	tags, _ := r.Get("Tags").([]string)
+	r.Set("Tags", append(tags, string(t)))
+	return nil
+}
+
+func TestConditional(t *testing.T) {
+	testCases := []struct {
+		when string
+		in   map[string]interface{}
+		want interface{}
+	}{
+		{`{{ isOfType . "book" }}`, map[string]interface{}{"Type": "book/epub"}, []string{"applied"}},
+		{`{{ isOfType . "book" }}`, map[string]interface{}{"Type": "music/mp3"}, nil},
+		{`{{ not .ISBN }}`, map[string]interface{}{"Title": "Voyage au centre de la terre"}, []string{"applied"}},
+		{`{{ not .ISBN }}`, map[string]interface{}{"ISBN": "9782253006329"}, nil},
+	}
+
+	for _, tc := range testCases {
+		m, err := NewConditional(tagger("applied"), tc.when)
+		if err != nil {
+			t.Fatalf("Fail to create conditional module for '%s': %v", tc.when, err)
+		}
+
+		r := store.NewRecord("test.epub", tc.in)
+		if err := m.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to process record for '%s': %v", tc.when, err)
+		}
+
+		if got := r.Get("Tags"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to apply condition '%s' to %v.\nWant: %v\nGot : %v", tc.when, tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestConditionalNotBoolean(t *testing.T) {
+	m, err := NewConditional(tagger("applied"), `{{ .Title }}`)
+	if err != nil {
+		t.Fatalf("Fail to create conditional module: %v", err)
+	}
+
+	r := store.NewRecord("test.epub", map[string]interface{}{"Title": "Voyage au centre de la terre"})
+	if err := m.ProcessRecord(r); err == nil {
+		t.Errorf("Condition that does not evaluate to a boolean should fail")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p := Pipeline{
+		"media":     {tagger("media")},
+		"book":      {tagger("book")},
+		"book/epub": {tagger("epub"), tagger("epub2")},
+		"mp3":       {tagger("mp3")},
+	}
+
+	testCases := []struct {
+		in   string
+		want []string
+	}{
+		{"book/epub", []string{"epub", "epub2"}},
+		{"book/pdf", []string{"book"}},
+		{"music/mp3", []string{"mp3"}},
+		{"video/mkv", []string{"media"}},
+		{"", []string{"media"}},
+	}
+
+	for _, tc := range testCases {
+		r := store.NewRecord("test", map[string]interface{}{"Type": tc.in})
+		if err := p.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to process record of type '%s': %v", tc.in, err)
+		}
+
+		if got := r.Get("Tags"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to apply pipeline to record of type '%s'.\nWant: %v\nGot : %v", tc.in, tc.want, got)
+		}
+	}
+}
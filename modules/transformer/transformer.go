@@ -0,0 +1,296 @@
+// Package transformer modifies a record's fields according to a set of
+// declarative rules (renaming, regex replacement, case normalization...).
+package transformer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	moduleName = "transformer"
+)
+
+var (
+	_ modules.Module = (*transformer)(nil) // Makes sure that we implement modules.Module interface.
+)
+
+// Rule describes a transformation of a record's field.
+type Rule struct {
+	// Field is the name of the field to transform.
+	Field string
+
+	// Action is the transformation to apply to Field:
+	// - rename: rename Field to To,
+	// - copy: copy Field's value to To,
+	// - replace: replace Field's value parts that match Pattern by
+	//   Replacement (Replacement can refer to Pattern's sub-matches using $1),
+	// - split: split Field's value into a list using Sep,
+	// - join: join Field's list of values into a string using Sep,
+	// - lower/upper/title: normalize Field's value case,
+	// - trim: remove leading and trailing spaces from Field's value,
+	// - default: set Field to Value if Field is empty,
+	// - set: set Field to the output of Value that is a template executed
+	//   against the record's values.
+	// Actions that operates on a string are applied to each item of a list.
+	Action string
+
+	// To is the destination field for rename or copy actions.
+	To string
+
+	// Pattern is the regular expression to look for for replace action.
+	Pattern string
+
+	// Replacement is the replacement text for replace action.
+	Replacement string
+
+	// Sep is the separator for split or join actions.
+	Sep string
+
+	// Value is the value to set for default action or the template for set
+	// action.
+	Value interface{}
+}
+
+// Config defines the different module's options.
+type Config struct {
+	// Rules lists the transformations to apply to records. Rules are
+	// applied in the provided order.
+	Rules []*Rule
+}
+
+func newConfig() *Config {
+	return &Config{}
+}
+
+// transformation represents a compiled Rule.
+type transformation func(*store.Record) error
+
+type transformer struct {
+	log *log.Logger
+
+	rules []*Rule
+	funcs []transformation
+}
+
+func newTransformer(cfg *Config, logger *log.Logger) (*transformer, error) {
+	t := &transformer{
+		log:   logger,
+		rules: cfg.Rules,
+	}
+
+	for _, rule := range cfg.Rules {
+		fn, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("module '%s': bad rule '%s' for '%s': %v", moduleName, rule.Action, rule.Field, err)
+		}
+		t.funcs = append(t.funcs, fn)
+	}
+
+	return t, nil
+}
+
+// ProcessRecord applies transformation rules to the record.
+func (t *transformer) ProcessRecord(r *store.Record) error {
+	for i, fn := range t.funcs {
+		t.log.Printf("Module '%s': apply '%s' to field '%s'", moduleName, t.rules[i].Action, t.rules[i].Field)
+		if err := fn(r); err != nil {
+			return fmt.Errorf("module '%s': fail to apply '%s' to field '%s': %v", moduleName, t.rules[i].Action, t.rules[i].Field, err)
+		}
+	}
+	return nil
+}
+
+func compile(rule *Rule) (transformation, error) {
+	if rule.Field == "" {
+		return nil, fmt.Errorf("no field defined")
+	}
+
+	switch rule.Action {
+	case "rename", "copy":
+		if rule.To == "" {
+			return nil, fmt.Errorf("no destination field defined")
+		}
+		if rule.To == rule.Field {
+			return nil, fmt.Errorf("destination field is the same as the source field")
+		}
+		return func(r *store.Record) error {
+			v := r.Get(rule.Field)
+			if v == nil {
+				return nil
+			}
+			r.Set(rule.To, v)
+			if rule.Action == "rename" {
+				r.Del(rule.Field)
+			}
+			return nil
+		}, nil
+
+	case "replace":
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return mapString(rule.Field, func(s string) string {
+			return re.ReplaceAllString(s, rule.Replacement)
+		}), nil
+
+	case "lower":
+		return mapString(rule.Field, strings.ToLower), nil
+
+	case "upper":
+		return mapString(rule.Field, strings.ToUpper), nil
+
+	case "title":
+		return mapString(rule.Field, func(s string) string {
+			return strings.Title(strings.ToLower(s))
+		}), nil
+
+	case "trim":
+		return mapString(rule.Field, strings.TrimSpace), nil
+
+	case "split":
+		if rule.Sep == "" {
+			return nil, fmt.Errorf("no separator defined")
+		}
+		return func(r *store.Record) error {
+			s, ok := r.Get(rule.Field).(string)
+			if !ok {
+				return nil
+			}
+			var l []interface{}
+			for _, item := range strings.Split(s, rule.Sep) {
+				if item = strings.TrimSpace(item); item != "" {
+					l = append(l, item)
+				}
+			}
+			r.Set(rule.Field, l)
+			return nil
+		}, nil
+
+	case "join":
+		return func(r *store.Record) error {
+			l, ok := asList(r.Get(rule.Field))
+			if !ok {
+				return nil
+			}
+			items := make([]string, len(l))
+			for i, item := range l {
+				items[i] = fmt.Sprint(item)
+			}
+			r.Set(rule.Field, strings.Join(items, rule.Sep))
+			return nil
+		}, nil
+
+	case "default":
+		return func(r *store.Record) error {
+			if isEmpty(r.Get(rule.Field)) {
+				r.Set(rule.Field, rule.Value)
+			}
+			return nil
+		}, nil
+
+	case "set":
+		txt, ok := rule.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value should be a template")
+		}
+		tmpl := template.New(rule.Field)
+		if _, err := tmpl.Funcs(util.FuncMap(tmpl)).Parse(txt); err != nil {
+			return nil, err
+		}
+		return func(r *store.Record) error {
+			buf := new(bytes.Buffer)
+			if err := tmpl.Execute(buf, r.Flatted()); err != nil {
+				return err
+			}
+			if buf.Len() == 0 {
+				r.Del(rule.Field)
+				return nil
+			}
+			r.Set(rule.Field, buf.String())
+			return nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown action")
+	}
+}
+
+// mapString creates a transformation that applies fn to a field's string
+// value or to each string of a field's list of values.
+func mapString(field string, fn func(string) string) transformation {
+	return func(r *store.Record) error {
+		switch v := r.Get(field).(type) {
+		case string:
+			r.Set(field, fn(v))
+
+		case []string, []interface{}:
+			l, _ := asList(v)
+			mapped := make([]interface{}, len(l))
+			for i, item := range l {
+				if s, ok := item.(string); ok {
+					mapped[i] = fn(s)
+				} else {
+					mapped[i] = item
+				}
+			}
+			r.Set(field, mapped)
+		}
+		return nil
+	}
+}
+
+func asList(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case []string:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = item
+		}
+		return l, true
+	default:
+		return nil, false
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	if util.IsZero(v) {
+		return true
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map:
+		return val.Len() == 0
+	default:
+		return false
+	}
+}
+
+// NewFromRawConfig creates a new module from a raw configuration.
+func NewFromRawConfig(rawcfg modules.Unmarshaler, env *modules.Environment) (modules.Module, error) {
+	env.Logger.Printf("Module '%s': new module with config '%v'", moduleName, rawcfg)
+	cfg := newConfig()
+
+	if err := rawcfg.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("module '%s': bad configuration: %v", moduleName, err)
+	}
+
+	return newTransformer(cfg, env.Logger)
+}
+
+func init() {
+	modules.Register(moduleName, NewFromRawConfig)
+}
@@ -0,0 +1,128 @@
+package transformer
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func TestProcessRecord(t *testing.T) {
+	testCases := []struct {
+		rules []*Rule
+		in    map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{
+			rules: []*Rule{{Field: "Author", Action: "rename", To: "Authors"}},
+			in:    map[string]interface{}{"Author": "Jules Verne"},
+			want:  map[string]interface{}{"Authors": "Jules Verne"},
+		},
+		{
+			rules: []*Rule{{Field: "Title", Action: "copy", To: "SortTitle"}},
+			in:    map[string]interface{}{"Title": "Voyages"},
+			want:  map[string]interface{}{"Title": "Voyages", "SortTitle": "Voyages"},
+		},
+		{
+			rules: []*Rule{{Field: "Missing", Action: "rename", To: "Other"}},
+			in:    map[string]interface{}{"Title": "Voyages"},
+			want:  map[string]interface{}{"Title": "Voyages"},
+		},
+		{
+			rules: []*Rule{{Field: "Publisher", Action: "replace", Pattern: `(?i)^editions?\s+(.*)$`, Replacement: "$1"}},
+			in:    map[string]interface{}{"Publisher": "Editions Hetzel"},
+			want:  map[string]interface{}{"Publisher": "Hetzel"},
+		},
+		{
+			rules: []*Rule{{Field: "Subject", Action: "split", Sep: ";"}},
+			in:    map[string]interface{}{"Subject": "Adventure; Science-Fiction;"},
+			want:  map[string]interface{}{"Subject": []interface{}{"Adventure", "Science-Fiction"}},
+		},
+		{
+			rules: []*Rule{{Field: "Authors", Action: "join", Sep: " & "}},
+			in:    map[string]interface{}{"Authors": []string{"Jules Verne", "Pierre-Jules Hetzel"}},
+			want:  map[string]interface{}{"Authors": "Jules Verne & Pierre-Jules Hetzel"},
+		},
+		{
+			rules: []*Rule{{Field: "Subject", Action: "lower"}},
+			in:    map[string]interface{}{"Subject": []interface{}{"Adventure", "SF"}},
+			want:  map[string]interface{}{"Subject": []interface{}{"adventure", "sf"}},
+		},
+		{
+			rules: []*Rule{{Field: "Language", Action: "upper"}},
+			in:    map[string]interface{}{"Language": "fr"},
+			want:  map[string]interface{}{"Language": "FR"},
+		},
+		{
+			rules: []*Rule{{Field: "Title", Action: "title"}},
+			in:    map[string]interface{}{"Title": "VOYAGE au centre"},
+			want:  map[string]interface{}{"Title": "Voyage Au Centre"},
+		},
+		{
+			rules: []*Rule{{Field: "Title", Action: "trim"}},
+			in:    map[string]interface{}{"Title": "  Voyages\n"},
+			want:  map[string]interface{}{"Title": "Voyages"},
+		},
+		{
+			rules: []*Rule{{Field: "Language", Action: "default", Value: "en"}},
+			in:    map[string]interface{}{"Language": ""},
+			want:  map[string]interface{}{"Language": "en"},
+		},
+		{
+			rules: []*Rule{{Field: "Language", Action: "default", Value: "en"}},
+			in:    map[string]interface{}{"Language": "fr"},
+			want:  map[string]interface{}{"Language": "fr"},
+		},
+		{
+			rules: []*Rule{{Field: "FullTitle", Action: "set", Value: "{{ .Title }}{{ with .SubTitle }}: {{ . }}{{ end }}"}},
+			in:    map[string]interface{}{"Title": "Voyages", "SubTitle": "Tome 1"},
+			want:  map[string]interface{}{"Title": "Voyages", "SubTitle": "Tome 1", "FullTitle": "Voyages: Tome 1"},
+		},
+		{
+			rules: []*Rule{
+				{Field: "Subject", Action: "split", Sep: ","},
+				{Field: "Subject", Action: "lower"},
+				{Field: "Subject", Action: "rename", To: "Tags"},
+			},
+			in:   map[string]interface{}{"Subject": "Adventure, SF"},
+			want: map[string]interface{}{"Tags": []interface{}{"adventure", "sf"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tr, err := newTransformer(&Config{Rules: tc.rules}, log.New(ioutil.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Fail to create module for '%v': %v", tc.rules, err)
+		}
+
+		r := store.NewRecord("test.epub", tc.in)
+		if err := tr.ProcessRecord(r); err != nil {
+			t.Errorf("Fail to process record with '%v': %v", tc.rules, err)
+			continue
+		}
+
+		if got := r.Data(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to transform record.\nWant: %#v\nGot : %#v", tc.want, got)
+		}
+	}
+}
+
+func TestBadRules(t *testing.T) {
+	testCases := []*Rule{
+		{Action: "lower"},
+		{Field: "Title", Action: "unknown"},
+		{Field: "Title", Action: "rename"},
+		{Field: "Title", Action: "rename", To: "Title"},
+		{Field: "Title", Action: "replace", Pattern: "("},
+		{Field: "Title", Action: "split"},
+		{Field: "Title", Action: "set", Value: "{{ .Title "},
+	}
+
+	for _, tc := range testCases {
+		if _, err := newTransformer(&Config{Rules: []*Rule{tc}}, log.New(ioutil.Discard, "", 0)); err == nil {
+			t.Errorf("Creating module with rule '%+v' should fail", tc)
+		}
+	}
+}
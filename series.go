@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+var (
+	// rePositionRange matches omnibus positions like "1-3", "1–3" or "1..3".
+	rePositionRange = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*(?:-|–|\.\.)\s*(\d+(?:\.\d+)?)\s*$`)
+)
+
+// seriePosition represents the position of a record within a serie. Omnibus
+// records cover a range of positions.
+type seriePosition struct {
+	from, to float64
+}
+
+// parseSeriePosition reads a record's SeriePosition that is either a number
+// (possibly fractional like 1.5) or a range of positions for omnibus (like
+// "1-3").
+func parseSeriePosition(v interface{}) (*seriePosition, bool) {
+	switch pos := v.(type) {
+	case int:
+		return &seriePosition{float64(pos), float64(pos)}, true
+
+	case float64:
+		return &seriePosition{pos, pos}, true
+
+	case string:
+		if nb, err := strconv.ParseFloat(strings.TrimSpace(pos), 64); err == nil {
+			return &seriePosition{nb, nb}, true
+		}
+
+		if m := rePositionRange.FindStringSubmatch(pos); m != nil {
+			from, _ := strconv.ParseFloat(m[1], 64)
+			to, _ := strconv.ParseFloat(m[2], 64)
+			if from <= to {
+				return &seriePosition{from, to}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// positions lists the positions covered by p. Ranges cover all the whole
+// positions between their bounds.
+func (p *seriePosition) positions() []float64 {
+	if p.from == p.to {
+		return []float64{p.from}
+	}
+
+	var pos []float64
+	for i := math.Ceil(p.from); i <= p.to; i++ {
+		pos = append(pos, i)
+	}
+	return pos
+}
+
+// serie summarizes the records owned for a given serie.
+type serie struct {
+	Name string
+	// Owned lists the positions owned in the serie.
+	Owned []float64
+	// Missing lists the whole positions that are not owned, between the
+	// first position and the last owned one.
+	Missing []float64
+	// Duplicates lists the positions owned more than once.
+	Duplicates []float64
+	// Unnumbered lists the records of the serie without a known position.
+	Unnumbered []string
+}
+
+// seriesOf gathers records by serie and reports on positions owned, missing
+// or duplicated. Series are sorted by name.
+func seriesOf(records store.Records) []*serie {
+	count := make(map[string]map[float64]int)
+	bySerie := make(map[string]*serie)
+
+	for _, r := range records {
+		name, ok := r.Get("Serie").(string)
+		if !ok || name == "" {
+			continue
+		}
+
+		s, exists := bySerie[name]
+		if !exists {
+			s = &serie{Name: name}
+			bySerie[name], count[name] = s, make(map[float64]int)
+		}
+
+		pos, ok := parseSeriePosition(r.Get("SeriePosition"))
+		if !ok {
+			s.Unnumbered = append(s.Unnumbered, r.Key())
+			continue
+		}
+
+		for _, p := range pos.positions() {
+			count[name][p]++
+		}
+	}
+
+	var series []*serie
+	for name, s := range bySerie {
+		var last float64
+		for p, n := range count[name] {
+			s.Owned = append(s.Owned, p)
+			if n > 1 {
+				s.Duplicates = append(s.Duplicates, p)
+			}
+			if p > last {
+				last = p
+			}
+		}
+
+		for p := 1.0; p < last; p++ {
+			if _, owned := count[name][p]; !owned {
+				s.Missing = append(s.Missing, p)
+			}
+		}
+
+		sort.Float64s(s.Owned)
+		sort.Float64s(s.Duplicates)
+		sort.Strings(s.Unnumbered)
+		series = append(series, s)
+	}
+
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series
+}
+
+// String summarizes a serie's status in a human readable way.
+func (s *serie) String() string {
+	str := fmt.Sprintf("%s: %s", s.Name, formatPositions(s.Owned))
+
+	var details []string
+	if len(s.Missing) > 0 {
+		details = append(details, "missing "+formatPositions(s.Missing))
+	}
+	if len(s.Duplicates) > 0 {
+		details = append(details, "duplicates "+formatPositions(s.Duplicates))
+	}
+	if len(s.Unnumbered) > 0 {
+		details = append(details, "unnumbered "+strings.Join(s.Unnumbered, ", "))
+	}
+
+	if len(details) > 0 {
+		str += " (" + strings.Join(details, "; ") + ")"
+	}
+	return str
+}
+
+// formatPositions displays a sorted list of positions, merging consecutive
+// whole positions into ranges (like "1-3, 3.5, 5").
+func formatPositions(pos []float64) string {
+	var str []string
+
+	for i := 0; i < len(pos); i++ {
+		j := i
+		for j+1 < len(pos) && isWhole(pos[j]) && pos[j+1] == pos[j]+1 {
+			j++
+		}
+
+		if j > i {
+			str = append(str, fmt.Sprintf("%s-%s", formatPosition(pos[i]), formatPosition(pos[j])))
+		} else {
+			str = append(str, formatPosition(pos[i]))
+		}
+		i = j
+	}
+
+	return strings.Join(str, ", ")
+}
+
+func formatPosition(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+func isWhole(p float64) bool {
+	return p == math.Trunc(p)
+}
+
+// Series lists the collection's series with the positions owned, missing or
+// owned more than once. If names are provided, only the corresponding series
+// are listed.
+func (gs *Gostore) Series(names []string) error {
+	records, err := gs.store.ReadAll()
+	if err != nil {
+		return fmt.Errorf("listing series failed: %s", err)
+	}
+
+	for _, s := range seriesOf(records) {
+		if len(names) > 0 && !containsFold(names, s.Name) {
+			continue
+		}
+		gs.ui.Printf("%s\n", s)
+	}
+
+	return nil
+}
+
+// AssignSerie sets the serie of the records matching the given query. If
+// numbered is set, records are given consecutive positions following the
+// sortBy order, otherwise the position of records that change of serie is
+// removed as it is meaningless in the new serie.
+func (gs *Gostore) AssignSerie(name string, query string, numbered bool, sortBy []string) error {
+	records, err := gs.store.ReadQuery(query)
+	if err != nil {
+		return fmt.Errorf("assigning serie '%s' failed: %s", name, err)
+	}
+
+	if len(sortBy) > 0 {
+		byKey := make(map[string]*store.Record, len(records))
+		for _, r := range records {
+			byKey[r.Key()] = r
+		}
+
+		sorted := store.Records{}
+		for _, m := range util.Sort(records.Flatted(), sortBy) {
+			sorted = append(sorted, byKey[m[store.KeyField].(string)])
+		}
+		records = sorted
+	}
+
+	var assigned store.Records
+	var assignErr util.MultiErrors
+	for i, r := range records {
+		gs.log.Printf("Assigning serie '%s' to '%s'", name, r.Key())
+
+		mdata := r.Data()
+		if serie, _ := mdata["Serie"].(string); !strings.EqualFold(serie, name) {
+			delete(mdata, "SeriePosition")
+		}
+		mdata["Serie"] = name
+		if numbered {
+			mdata["SeriePosition"] = i + 1
+		}
+
+		if err := gs.update(r, mdata); err != nil {
+			assignErr.Add(fmt.Errorf("assigning serie '%s' to '%s' failed: %s", name, r.Key(), err))
+			continue
+		}
+		assigned = append(assigned, r)
+	}
+
+	if len(assigned) != 0 {
+		gs.ui.PrettyPrint(assigned.Flatted()...)
+
+		if err := gs.runHooks("post-update", assigned...); err != nil {
+			assignErr.Add(err)
+		}
+	}
+
+	return assignErr.Err()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/store"
+)
+
+func TestSeriesOf(t *testing.T) {
+	records := store.Records{
+		store.NewRecord("1.epub", map[string]interface{}{"Serie": "Voyages", "SeriePosition": 1}),
+		store.NewRecord("2.epub", map[string]interface{}{"Serie": "Voyages", "SeriePosition": float64(2)}),
+		store.NewRecord("2b.epub", map[string]interface{}{"Serie": "Voyages", "SeriePosition": "2"}),
+		store.NewRecord("2.5.epub", map[string]interface{}{"Serie": "Voyages", "SeriePosition": "2.5"}),
+		store.NewRecord("5-7.epub", map[string]interface{}{"Serie": "Voyages", "SeriePosition": "5-7"}),
+		store.NewRecord("x.epub", map[string]interface{}{"Serie": "Voyages"}),
+		store.NewRecord("a.epub", map[string]interface{}{"Serie": "Aventures", "SeriePosition": "1..3"}),
+		store.NewRecord("none.epub", map[string]interface{}{"Title": "Alone"}),
+	}
+
+	want := []*serie{
+		{Name: "Aventures", Owned: []float64{1, 2, 3}},
+		{Name: "Voyages", Owned: []float64{1, 2, 2.5, 5, 6, 7}, Missing: []float64{3, 4}, Duplicates: []float64{2}, Unnumbered: []string{"x.epub"}},
+	}
+
+	got := seriesOf(records)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fail to gather series.\nWant: %+v\nGot : %+v", want, got)
+	}
+
+	wantStr := []string{
+		"Aventures: 1-3",
+		"Voyages: 1-2, 2.5, 5-7 (missing 3-4; duplicates 2; unnumbered x.epub)",
+	}
+	for i, s := range got {
+		if s.String() != wantStr[i] {
+			t.Errorf("Fail to display serie.\nWant: %s\nGot : %s", wantStr[i], s.String())
+		}
+	}
+}
+
+func TestAssignSerie(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testCases := []string{
+		filepath.Join(testdataPath, "pg11-images.epub"),
+		filepath.Join(testdataPath, "pg1661-images.epub"),
+	}
+	if err := gs.Import(testCases); err != nil {
+		t.Fatalf("Fail to import epubs '%s': %v", testCases, err)
+	}
+
+	if err := gs.AssignSerie("Classics", "*", true, []string{"Name"}); err != nil {
+		t.Fatalf("Fail to assign serie: %v", err)
+	}
+
+	for name, pos := range map[string]int{"pg11-images.epub": 1, "pg1661-images.epub": 2} {
+		r, err := gs.store.Read(name)
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		if r.Get("Serie") != "Classics" || r.Get("SeriePosition") != pos {
+			t.Errorf("Fail to assign serie to '%s': got %v #%v", name, r.Get("Serie"), r.Get("SeriePosition"))
+		}
+	}
+
+	if err := gs.AssignSerie("classics", "*", false, nil); err != nil {
+		t.Fatalf("Fail to assign serie: %v", err)
+	}
+
+	if err := gs.AssignSerie("Adventures", "SeriePosition:2", false, nil); err != nil {
+		t.Fatalf("Fail to assign serie: %v", err)
+	}
+
+	for name, want := range map[string][]interface{}{"pg11-images.epub": {"classics", 1}, "pg1661-images.epub": {"Adventures", nil}} {
+		r, err := gs.store.Read(name)
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		if r.Get("Serie") != want[0] || r.Get("SeriePosition") != want[1] {
+			t.Errorf("Fail to assign serie to '%s'.\nWant: %v #%v\nGot : %v #%v", name, want[0], want[1], r.Get("Serie"), r.Get("SeriePosition"))
+		}
+	}
+}
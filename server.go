@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var (
+	// errDelegated signals that a command has been run by the collection's
+	// server instead of by the current process.
+	errDelegated = errors.New("command is delegated to the collection's server")
+)
+
+// delegatedCmd describes a command that is delegated to the collection's
+// server.
+type delegatedCmd struct {
+	// Args is the command line, without the program's name.
+	Args []string
+	// Dir is the working directory the command is run from.
+	Dir string
+}
+
+// delegatedResult is the outcome of a command run by the collection's
+// server.
+type delegatedResult struct {
+	// Output is what the command printed to the standard output.
+	Output string
+	// Err is the command's error message, if any.
+	Err string
+}
+
+// Serve keeps the collection opened and runs the commands delegated by other
+// gostore processes until it is interrupted. Serve expects the collection to
+// be opened.
+// Delegated commands are run one at a time. The server cannot interact with
+// the user: questions are answered by their default choice, which is why
+// commands modifying the collection are only delegated in automatic or
+// pretend mode (see openGostore). Commands that need the collection to be
+// closed (like migrate or restore) cannot be delegated.
+func (gs *Gostore) Serve() error {
+	if gs.shared {
+		return fmt.Errorf("serving collection failed: collection is already served")
+	}
+
+	l, err := gs.listen()
+	if err != nil {
+		return fmt.Errorf("serving collection failed: %s", err)
+	}
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	go func() {
+		<-sig
+		close(stop)
+		l.Close()
+	}()
+
+	gs.ui.Printf("Serving collection '%s'\n", gs.name())
+	return gs.serve(l, stop)
+}
+
+// listen opens the collection's socket.
+func (gs *Gostore) listen() (net.Listener, error) {
+	path := gs.store.SocketPath()
+
+	// The collection is locked so that a left-over socket can only come from
+	// a server that has not been properly stopped.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return net.Listen("unix", path)
+}
+
+// serve runs the commands received on l until stop is closed.
+func (gs *Gostore) serve(l net.Listener, stop <-chan struct{}) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+				return fmt.Errorf("serving collection failed: %s", err)
+			}
+		}
+
+		if err := gs.handle(conn); err != nil {
+			gs.log.Printf("Fail to run delegated command: %s", err)
+		}
+		conn.Close()
+	}
+}
+
+// handle runs the command received on conn and sends back its result.
+func (gs *Gostore) handle(conn net.Conn) error {
+	var cmd delegatedCmd
+	if err := json.NewDecoder(conn).Decode(&cmd); err != nil {
+		if err == io.EOF {
+			// isServed only checks that the server is listening.
+			return nil
+		}
+		return err
+	}
+
+	gs.log.Printf("Running delegated command '%v'", cmd.Args)
+	res := new(delegatedResult)
+
+	var err error
+	res.Output, err = captureStdout(func() error {
+		return gs.run(&cmd)
+	})
+	if err != nil {
+		res.Err = err.Error()
+	}
+
+	return json.NewEncoder(conn).Encode(res)
+}
+
+// run executes a delegated command on the served collection.
+func (gs *Gostore) run(cmd *delegatedCmd) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(cmd.Dir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	// Delegated commands cannot read the user's answers.
+	stdin := os.Stdin
+	if os.Stdin, err = os.Open(os.DevNull); err != nil {
+		os.Stdin = stdin
+		return err
+	}
+	defer func() {
+		os.Stdin.Close()
+		os.Stdin = stdin
+	}()
+
+	cfg := newConfig()
+	cfg.served = gs
+	return newApp(cfg).Run(cmd.Args)
+}
+
+// isServed reports whether a server is running for the collection.
+func (gs *Gostore) isServed() bool {
+	conn, err := net.Dial("unix", gs.store.SocketPath())
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// delegate runs the given command line using the collection's server and
+// prints its output. It returns errDelegated if the command succeeds.
+func (gs *Gostore) delegate(args []string) error {
+	gs.log.Printf("Delegating '%v' to collection's server", args)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("unix", gs.store.SocketPath())
+	if err != nil {
+		return fmt.Errorf("delegating command to collection's server failed: %s", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(&delegatedCmd{Args: args, Dir: wd}); err != nil {
+		return fmt.Errorf("delegating command to collection's server failed: %s", err)
+	}
+
+	res := new(delegatedResult)
+	if err := json.NewDecoder(conn).Decode(res); err != nil {
+		return fmt.Errorf("delegating command to collection's server failed: %s", err)
+	}
+
+	fmt.Print(res.Output)
+	if res.Err != "" {
+		return errors.New(res.Err)
+	}
+	return errDelegated
+}
+
+// captureStdout returns what fn prints to the standard output.
+func captureStdout(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	out := make(chan string)
+	go func() {
+		buf := new(bytes.Buffer)
+		io.Copy(buf, r)
+		out <- buf.String()
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	errFn := fn()
+	os.Stdout = stdout
+
+	w.Close()
+	return <-out, errFn
+}
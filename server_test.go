@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+func TestServe(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	if _, err := gs.store.Create("a.epub", map[string]interface{}{"Title": "Les misérables"}, verify.MockROFile("0123456789")); err != nil {
+		t.Fatalf("Fail to add record: %v", err)
+	}
+
+	l, err := gs.listen()
+	if err != nil {
+		t.Fatalf("Fail to listen to collection's socket: %v", err)
+	}
+
+	stop, done := make(chan struct{}), make(chan error)
+	go func() { done <- gs.serve(l, stop) }()
+
+	cfg := newConfig()
+	cfg.Store.Path = gs.root
+	client, err := newGostore(cfg)
+	if err != nil {
+		t.Fatalf("Fail to create client: %v", err)
+	}
+
+	if !client.isServed() {
+		t.Fatalf("Collection should be reported as served")
+	}
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	if err := client.delegate([]string{"--root=" + gs.root, "list"}); err != errDelegated {
+		t.Errorf("Fail to delegate list command: %v", err)
+	}
+	if out := stdout.String(); !strings.Contains(out, "a.epub") {
+		t.Errorf("Delegated command's output is not as expected: %q", out)
+	}
+
+	if _, err := openGostore(cfg); err == nil || !strings.Contains(err.Error(), "served") {
+		t.Errorf("Commands modifying a served collection should not be delegated unless in automatic mode, got: %v", err)
+	}
+
+	if err := client.delegate([]string{"--root=" + gs.root, "--auto", "delete", "a.epub"}); err != errDelegated {
+		t.Errorf("Fail to delegate delete command: %v", err)
+	}
+	if exists, _ := gs.store.Exists("a.epub"); exists {
+		t.Errorf("Delegated command should modify the served collection")
+	}
+
+	if err := client.delegate([]string{"--root=" + gs.root, "migrate"}); err == nil || err == errDelegated {
+		t.Errorf("Delegating migrate command should fail")
+	}
+
+	if err := client.Migrate(true); err == nil {
+		t.Errorf("Migrating a served collection should fail")
+	}
+
+	close(stop)
+	l.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Fail to stop serving collection: %v", err)
+	}
+
+	if client.isServed() {
+		t.Errorf("Collection should not be reported as served once server is stopped")
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+// Operations on a record's field.
+const (
+	setOp    = "set"
+	addOp    = "add"
+	removeOp = "remove"
+	unsetOp  = "unset"
+)
+
+var (
+	// reAssignment matches field's assignments like "Publisher:Gallimard",
+	// "Publisher=Gallimard", "Tags+=classic" or "Tags-=classic".
+	reAssignment = regexp.MustCompile(`^([\w.]+)(\+=|-=|=|:)(.*)$`)
+
+	// reUnset matches field's removal like "Comment-".
+	reUnset = regexp.MustCompile(`^([\w.]+)-$`)
+)
+
+// fieldAssignment is a modification of a record's field.
+type fieldAssignment struct {
+	field string
+	op    string
+	value string
+}
+
+// parseAssignment reads a field's assignment that is either:
+// - 'Field:value' or 'Field=value' to set Field to value,
+// - 'Field+=value' to add value to Field's list of values,
+// - 'Field-=value' to remove value from Field's list of values,
+// - 'Field-' to remove Field.
+func parseAssignment(s string) (*fieldAssignment, error) {
+	if m := reUnset.FindStringSubmatch(s); m != nil {
+		return &fieldAssignment{field: m[1], op: unsetOp}, nil
+	}
+
+	m := reAssignment.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid assignment '%s'", s)
+	}
+
+	a := &fieldAssignment{field: m[1], value: strings.TrimSpace(m[3])}
+	switch m[2] {
+	case "+=":
+		a.op = addOp
+	case "-=":
+		a.op = removeOp
+	default:
+		a.op = setOp
+	}
+
+	return a, nil
+}
+
+// apply modifies mdata according to the assignment.
+func (a *fieldAssignment) apply(mdata map[string]interface{}) {
+	switch a.op {
+	case setOp:
+		mdata[a.field] = a.value
+
+	case unsetOp:
+		delete(mdata, a.field)
+
+	case addOp:
+		values := valuesOf(mdata[a.field])
+		for _, v := range values {
+			if fmt.Sprint(v) == a.value {
+				return
+			}
+		}
+		mdata[a.field] = append(values, a.value)
+
+	case removeOp:
+		var kept []interface{}
+		for _, v := range valuesOf(mdata[a.field]) {
+			if fmt.Sprint(v) != a.value {
+				kept = append(kept, v)
+			}
+		}
+
+		switch {
+		case len(kept) == 0:
+			delete(mdata, a.field)
+		case reflect.ValueOf(mdata[a.field]).Kind() == reflect.Slice:
+			mdata[a.field] = kept
+		default:
+			mdata[a.field] = kept[0]
+		}
+	}
+}
+
+// valuesOf returns a field's value as a list of values.
+func valuesOf(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []interface{}{v}
+	case []string:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = item
+		}
+		return l
+	case []interface{}:
+		return append([]interface{}{}, v...)
+	default:
+		return []interface{}{v}
+	}
+}
+
+// Set applies a list of field's assignments to the records selected either
+// by their name or by a query. Changes are processed by the update modules,
+// then displayed and confirmation is asked before updating each record.
+// Assignments follow the syntax 'Field:value' (or 'Field=value') to set a
+// field, 'Field+=value' or 'Field-=value' to add or remove a value from a
+// list and 'Field-' to remove a field. Fields of the end-user's personal
+// information (like Tags or Rating) are modified the same way.
+func (gs *Gostore) Set(assignments []string, pattern []string, query string) error {
+	var assigns []*fieldAssignment
+	for _, s := range assignments {
+		a, err := parseAssignment(s)
+		if err != nil {
+			return fmt.Errorf("setting fields failed: %s", err)
+		}
+		assigns = append(assigns, a)
+	}
+
+	records, err := gs.selectRecords(pattern, query)
+	if err != nil {
+		return fmt.Errorf("setting fields failed: %s", err)
+	}
+
+	var updated store.Records
+	var setErr util.MultiErrors
+	for _, r := range records {
+		gs.log.Printf("Setting fields of '%s'", r.Key())
+
+		ok, err := gs.set(r, assigns)
+		if err != nil {
+			setErr.Add(fmt.Errorf("setting fields of '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		if ok {
+			updated = append(updated, r)
+		}
+	}
+
+	if len(updated) != 0 {
+		gs.ui.PrettyPrint(updated.Flatted()...)
+
+		if err := gs.runHooks("post-update", updated...); err != nil {
+			setErr.Add(err)
+		}
+	}
+
+	return setErr.Err()
+}
+
+// set applies assignments to a record, reporting whether the record has
+// been updated.
+func (gs *Gostore) set(r *store.Record, assigns []*fieldAssignment) (bool, error) {
+	key, before := r.Key(), r.Flatted()
+
+	mdata, user := r.Data(), r.UserData()
+	splitUserData(mdata, user)
+
+	for _, a := range assigns {
+		if isUserField(a.field) {
+			a.apply(user)
+		} else {
+			a.apply(mdata)
+		}
+	}
+
+	if err := gs.store.Validate(mdata); err != nil {
+		return false, err
+	}
+
+	if err := checkUserData(user); err != nil {
+		return false, err
+	}
+
+	r.SetData(mdata)
+	r.SetUserData(user)
+	if err := gs.updateModules.ProcessRecord(r); err != nil {
+		return false, err
+	}
+
+	after := r.Flatted()
+	if reflect.DeepEqual(before, after) {
+		gs.log.Printf("'%s' is unchanged", key)
+		return false, nil
+	}
+
+	gs.ui.PrettyDiff(before, after)
+	if !gs.ui.Confirm(fmt.Sprintf("Update '%s'?", key)) {
+		return false, nil
+	}
+
+	if err := gs.runHooks("pre-update", r); err != nil {
+		return false, err
+	}
+
+	if !gs.pretend {
+		if err := gs.store.Update(key, r); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
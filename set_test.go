@@ -0,0 +1,136 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+func TestParseAssignment(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want *fieldAssignment
+	}{
+		{"Publisher:Gallimard", &fieldAssignment{"Publisher", setOp, "Gallimard"}},
+		{"Publisher=Le Livre de Poche", &fieldAssignment{"Publisher", setOp, "Le Livre de Poche"}},
+		{"Tags+=classic", &fieldAssignment{"Tags", addOp, "classic"}},
+		{"Tags-=to read", &fieldAssignment{"Tags", removeOp, "to read"}},
+		{"Comment-", &fieldAssignment{"Comment", unsetOp, ""}},
+		{"Description:a: b", &fieldAssignment{"Description", setOp, "a: b"}},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseAssignment(tc.in)
+		if err != nil {
+			t.Errorf("Fail to parse '%s': %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Fail to parse '%s': got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{"Publisher", "Comment+", ":Gallimard"} {
+		if _, err := parseAssignment(in); err == nil {
+			t.Errorf("Parsing '%s' should fail", in)
+		}
+	}
+}
+
+func TestApplyAssignment(t *testing.T) {
+	mdata := map[string]interface{}{
+		"Title":   "Les misérables",
+		"Authors": []string{"Victor Hugo"},
+		"Tags":    []interface{}{"classic", "to read"},
+		"Subject": "Fiction",
+		"Comment": "Buy it",
+	}
+
+	for _, s := range []string{"Publisher:Gallimard", "Authors+=Isabel F. Hapgood", "Authors+=Victor Hugo", "Tags-=to read", "Subject-=Fiction", "Comment-", "Serie+=Classics"} {
+		a, err := parseAssignment(s)
+		if err != nil {
+			t.Fatalf("Fail to parse '%s': %v", s, err)
+		}
+		a.apply(mdata)
+	}
+
+	want := map[string]interface{}{
+		"Title":     "Les misérables",
+		"Authors":   []interface{}{"Victor Hugo", "Isabel F. Hapgood"},
+		"Tags":      []interface{}{"classic"},
+		"Publisher": "Gallimard",
+		"Serie":     []interface{}{"Classics"},
+	}
+	if !reflect.DeepEqual(mdata, want) {
+		t.Errorf("Fail to apply assignments:\nwant: %#v\ngot : %#v", want, mdata)
+	}
+}
+
+func TestSet(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testData := map[string]map[string]interface{}{
+		"a.epub": {"Type": "book/epub", "Title": "Les misérables", "Authors": []string{"Victor Hugo"}, "Comment": "Buy it"},
+		"b.epub": {"Type": "book/epub", "Title": "Les fleurs du mal", "Authors": []string{"Charles Baudelaire"}},
+	}
+	for key, data := range testData {
+		if _, err := gs.store.Create(key, data, verify.MockROFile("0123456789")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	if err := gs.Set([]string{"Publisher:Gallimard"}, nil, ""); err == nil {
+		t.Errorf("Set without selecting records should fail")
+	}
+
+	if err := gs.Set([]string{"Publisher"}, []string{"*.epub"}, ""); err == nil {
+		t.Errorf("Set with an invalid assignment should fail")
+	}
+
+	t.Run("Pretend", func(t *testing.T) {
+		gs.pretend = true
+		defer func() { gs.pretend = false }()
+
+		if err := gs.Set([]string{"Publisher:Gallimard"}, []string{"*.epub"}, ""); err != nil {
+			t.Fatalf("Fail to set fields: %v", err)
+		}
+
+		r, err := gs.store.Read("a.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+		if r.Get("Publisher") != nil {
+			t.Errorf("Set should not modify the collection in pretend mode")
+		}
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		if err := gs.Set([]string{"Publisher:Gallimard", "Comment-"}, nil, "Authors:Hugo"); err != nil {
+			t.Fatalf("Fail to set fields: %v", err)
+		}
+
+		a, err := gs.store.Read("a.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+		if a.Get("Publisher") != "Gallimard" || a.Get("Comment") != nil {
+			t.Errorf("Fail to set fields of selected record: got Publisher %v, Comment %v", a.Get("Publisher"), a.Get("Comment"))
+		}
+
+		b, err := gs.store.Read("b.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+		if b.Get("Publisher") != nil {
+			t.Errorf("Set should only modify selected records")
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pirmd/gostore/media"
+	"github.com/pirmd/gostore/store"
+)
+
+const (
+	// statsType is the Type of the collection's statistics when printed, so
+	// that a specific formatter can be defined for them.
+	statsType = "stats"
+)
+
+// statsConfig describes the statistics reported on the collection.
+type statsConfig struct {
+	// Values lists the fields whose distribution of values is reported.
+	// Default to QALevel.
+	Values []string
+
+	// Top lists the fields whose most frequent values are reported. Default
+	// to Authors, Publisher and Subject.
+	Top []string
+
+	// TopCount is the number of most frequent values reported for each of
+	// the Top fields. Default to 10.
+	TopCount int
+
+	// Required lists the fields that records are expected to have. Records
+	// missing them are reported. Default to Title and Authors.
+	Required []string
+}
+
+func newStatsConfig() *statsConfig {
+	return &statsConfig{
+		Values:   []string{"QALevel"},
+		Top:      []string{"Authors", "Publisher", "Subject"},
+		TopCount: 10,
+		Required: []string{"Title", "Authors"},
+	}
+}
+
+// valueCount is the number of records having a given value.
+type valueCount struct {
+	Value string
+	Count int
+}
+
+// collectionStats summarizes the content of a collection.
+type collectionStats struct {
+	Collection string
+	Records    int
+	Size       int64
+	Types      map[string]*store.TypeStats
+	Activity   map[string]int
+	Values     map[string]map[string]int
+	Top        map[string][]*valueCount
+	Missing    map[string][]string
+}
+
+// Flatted returns the collection's statistics as a flat map, in a human
+// readable form suitable for printing.
+func (s *collectionStats) Flatted() map[string]interface{} {
+	m := map[string]interface{}{
+		media.TypeField: statsType,
+		store.KeyField:  s.Collection,
+		"Records":       s.Records,
+		"Size":          formatSize(s.Size),
+	}
+
+	var types []string
+	for typ, ts := range s.Types {
+		types = append(types, fmt.Sprintf("%s: %d (%s)", typ, ts.Records, formatSize(ts.Size)))
+	}
+	sort.Strings(types)
+	m["Types"] = strings.Join(types, ", ")
+
+	var activity []string
+	for month, n := range s.Activity {
+		activity = append(activity, fmt.Sprintf("%s: %d", month, n))
+	}
+	sort.Strings(activity)
+	m["Activity"] = strings.Join(activity, ", ")
+
+	for field, values := range s.Values {
+		var dist []string
+		for _, vc := range sortByCount(values) {
+			dist = append(dist, fmt.Sprintf("%s: %d", vc.Value, vc.Count))
+		}
+		m[field] = strings.Join(dist, ", ")
+	}
+
+	for field, top := range s.Top {
+		var values []string
+		for _, vc := range top {
+			values = append(values, fmt.Sprintf("%s (%d)", vc.Value, vc.Count))
+		}
+		m["Top"+field] = strings.Join(values, ", ")
+	}
+
+	for field, keys := range s.Missing {
+		m["Missing"+field] = len(keys)
+	}
+
+	return m
+}
+
+// sortByCount sorts values from the most frequent to the least frequent one,
+// values of same frequency being sorted alphabetically.
+func sortByCount(values map[string]int) []*valueCount {
+	var counts []*valueCount
+	for v, n := range values {
+		counts = append(counts, &valueCount{v, n})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].Value < counts[j].Value
+		}
+		return counts[i].Count > counts[j].Count
+	})
+
+	return counts
+}
+
+// formatSize displays a size in bytes in a human readable way (like
+// "1.5 MiB").
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// stats computes the collection's statistics.
+func (gs *Gostore) stats() (*collectionStats, error) {
+	cfg := gs.config.Stats
+	if cfg == nil {
+		cfg = newStatsConfig()
+	}
+
+	fields := append(append([]string{}, cfg.Values...), cfg.Top...)
+	st, err := gs.store.Stats(fields, cfg.Required)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &collectionStats{
+		Collection: gs.name(),
+		Records:    st.Records,
+		Size:       st.Size,
+		Types:      st.Types,
+		Activity:   st.Activity,
+		Values:     make(map[string]map[string]int),
+		Top:        make(map[string][]*valueCount),
+		Missing:    st.Missing,
+	}
+
+	for _, field := range cfg.Values {
+		if values, exists := st.Values[field]; exists {
+			s.Values[field] = values
+		}
+	}
+
+	for _, field := range cfg.Top {
+		top := sortByCount(st.Values[field])
+		if len(top) > cfg.TopCount {
+			top = top[:cfg.TopCount]
+		}
+		if len(top) > 0 {
+			s.Top[field] = top
+		}
+	}
+
+	return s, nil
+}
+
+// Stats reports an overview of the collection: number of records and disk
+// usage by Type, distribution of some fields' values (like QALevel), most
+// frequent values of other fields (like Authors), records missing key fields
+// and import activity over time.
+// Statistics are printed using the "stats" formatter of the selected style
+// or as JSON if asJSON is set.
+func (gs *Gostore) Stats(asJSON bool) error {
+	s, err := gs.stats()
+	if err != nil {
+		return fmt.Errorf("computing statistics failed: %s", err)
+	}
+
+	if asJSON {
+		output, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return fmt.Errorf("computing statistics failed: %s", err)
+		}
+		gs.ui.Printf("%s\n", output)
+		return nil
+	}
+
+	gs.ui.PrettyPrint(s.Flatted())
+	return nil
+}
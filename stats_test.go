@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+func TestStats(t *testing.T) {
+	cfg := newConfig()
+	cfg.Stats.TopCount = 1
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	testData := map[string]map[string]interface{}{
+		"a.epub": {"Type": "book/epub", "Title": "Les misérables", "Authors": []string{"Victor Hugo"}, "QALevel": "high"},
+		"b.epub": {"Type": "book/epub", "Title": "Notre-Dame de Paris", "Authors": []string{"Victor Hugo"}, "QALevel": "low"},
+		"c.epub": {"Type": "book/epub", "Title": "Les fleurs du mal", "Authors": []string{"Charles Baudelaire"}, "QALevel": "high"},
+		"d.pdf":  {"Type": "book/pdf", "Title": "Untitled"},
+	}
+	for key, data := range testData {
+		if _, err := gs.store.Create(key, data, verify.MockROFile("0123456789")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	s, err := gs.stats()
+	if err != nil {
+		t.Fatalf("Fail to compute statistics: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"Type":           "stats",
+		"Name":           "default",
+		"Records":        4,
+		"Size":           "40 B",
+		"Types":          "book/epub: 3 (30 B), book/pdf: 1 (10 B)",
+		"Activity":       "1976-01: 4",
+		"QALevel":        "high: 2, low: 1",
+		"TopAuthors":     "Victor Hugo (2)",
+		"MissingAuthors": 1,
+	}
+	if got := s.Flatted(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Statistics mismatch:\nWant: %#v\nGot : %#v", want, got)
+	}
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	if err := gs.Stats(true); err != nil {
+		t.Fatalf("Fail to print statistics: %v", err)
+	}
+
+	got := new(collectionStats)
+	if err := json.Unmarshal([]byte(stdout.String()), got); err != nil {
+		t.Fatalf("Fail to read statistics as JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got.Missing["Authors"], []string{"d.pdf"}) {
+		t.Errorf("Records missing Authors mismatch: %v", got.Missing)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	testCases := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+
+	for _, tc := range testCases {
+		if got := formatSize(tc.in); got != tc.want {
+			t.Errorf("Fail to format size %d: want '%s', got '%s'", tc.in, tc.want, got)
+		}
+	}
+}
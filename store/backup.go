@@ -0,0 +1,377 @@
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	// backup's archive layout
+	backupManifest = "manifest.json"
+	backupDb       = "database.db"
+	backupIdx      = "index"
+	backupMedia    = "media"
+)
+
+var (
+	// ErrBackupIsCorrupted raises an error if an archive's content does not
+	// match its manifest.
+	ErrBackupIsCorrupted = errors.New("backup is corrupted")
+)
+
+// BackupManifest describes the content of a Store's backup.
+type BackupManifest struct {
+	// CreatedAt is the time stamp of the backup.
+	CreatedAt time.Time
+	// Since is the time stamp from which records' files were saved. It is
+	// zero for a full backup.
+	Since time.Time
+	// WithIndex indicates whether the store's index is part of the backup.
+	WithIndex bool
+	// Files lists the archived files together with their sha256 checksum.
+	Files map[string]string
+}
+
+// IsIncremental indicates whether the backup only contains records' files
+// updated since a given date.
+func (m *BackupManifest) IsIncremental() bool {
+	return !m.Since.IsZero()
+}
+
+// Backup writes a snapshot of the Store to w as a gzipped tar archive. The
+// database is always fully saved whereas records' files are only saved if
+// they have been updated after since (use a zero time.Time for a full
+// backup). The index is saved only if withIndex is set, otherwise it is
+// expected to be rebuilt on restore.
+func (s *Store) Backup(w io.Writer, since time.Time, withIndex bool) (err error) {
+	s.log.Printf("Backing-up store (since: %v, with index: %v)", since, withIndex)
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	defer func() {
+		if e := tw.Close(); err == nil {
+			err = e
+		}
+		if e := zw.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	manifest := &BackupManifest{
+		CreatedAt: timestamper(),
+		Since:     since,
+		WithIndex: withIndex,
+		Files:     make(map[string]string),
+	}
+
+	s.log.Printf("Backing-up store's database")
+	if err = s.db.Backup(func(size int64, wr io.WriterTo) error {
+		return addToTar(tw, manifest, backupDb, size, func(w io.Writer) error {
+			_, err := wr.WriteTo(w)
+			return err
+		})
+	}); err != nil {
+		return fmt.Errorf("fail to backup database: %s", err)
+	}
+
+	if withIndex {
+		s.log.Printf("Backing-up store's index")
+		if err = addDirToTar(tw, manifest, filepath.Join(s.fs.path, idxPath), backupIdx); err != nil {
+			return fmt.Errorf("fail to backup index: %s", err)
+		}
+	}
+
+	s.log.Printf("Backing-up store's files")
+	errBackup := new(util.MultiErrors)
+	if err = s.db.Walk(func(key string) error {
+		r, err := s.db.Get(key)
+		if err != nil {
+			return err
+		}
+
+		if !since.IsZero() && !r.value.UpdatedAt.After(since) {
+			return nil
+		}
+
+		s.log.Printf("Backing-up '%s'", key)
+		if err := addFileToTar(tw, manifest, filepath.Join(s.fs.path, filepath.FromSlash(key)), path.Join(backupMedia, key)); err != nil {
+			errBackup.Add(fmt.Errorf("fail to backup '%s': %s", key, err))
+		}
+
+		if exists, _ := s.covers.Exists(key); exists {
+			if err := addFileToTar(tw, manifest, filepath.Join(s.covers.path, filepath.FromSlash(key)), path.Join(backupMedia, coverPath, key)); err != nil {
+				errBackup.Add(fmt.Errorf("fail to backup cover of '%s': %s", key, err))
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err = errBackup.Err(); err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return addToTar(tw, nil, backupManifest, int64(len(buf)), func(w io.Writer) error {
+		_, err := w.Write(buf)
+		return err
+	})
+}
+
+// Restore extracts a backup generated by Backup into the Store. Restore
+// expects the Store to be closed.
+//
+// Archive's content is first extracted in a staging area and checked against
+// its manifest before replacing any of the Store's content, so that a
+// corrupted archive leaves the Store untouched (ErrBackupIsCorrupted).
+// Restoring an incremental backup only makes sense on top of the restoration
+// of the backup it is based on.
+//
+// If the backup does not contain the index, it is up to the caller to rebuild
+// it once the Store is opened.
+func (s *Store) Restore(r io.Reader) (*BackupManifest, error) {
+	root := s.fs.path
+	staging := filepath.Join(root, restorePath)
+
+	s.log.Printf("Restoring store from backup")
+	if err := s.lock.Lock(true); err != nil {
+		return nil, err
+	}
+	defer s.lock.Unlock()
+
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := extractTar(r, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Printf("Restoring store's database")
+	if err := os.Rename(filepath.Join(staging, backupDb), filepath.Join(root, dbPath)); err != nil {
+		return nil, err
+	}
+
+	if manifest.WithIndex {
+		s.log.Printf("Restoring store's index")
+		if err := os.RemoveAll(filepath.Join(root, idxPath)); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(staging, backupIdx), filepath.Join(root, idxPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	s.log.Printf("Restoring store's files")
+	errRestore := new(util.MultiErrors)
+	for name := range manifest.Files {
+		if !strings.HasPrefix(name, backupMedia+"/") {
+			continue
+		}
+
+		key := strings.TrimPrefix(name, backupMedia+"/")
+		dst := filepath.Join(root, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+			errRestore.Add(fmt.Errorf("fail to restore '%s': %s", key, err))
+			continue
+		}
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(name)), dst); err != nil {
+			errRestore.Add(fmt.Errorf("fail to restore '%s': %s", key, err))
+		}
+	}
+
+	return manifest, errRestore.Err()
+}
+
+// CheckBackup verifies that a backup archive generated by Backup is
+// consistent with its manifest without restoring it (ErrBackupIsCorrupted).
+func CheckBackup(r io.Reader) (*BackupManifest, error) {
+	return extractTar(r, "")
+}
+
+// extractTar extracts a backup archive into dir and verifies that its content
+// is consistent with its manifest. If dir is empty, archive's content is only
+// verified.
+func extractTar(r io.Reader, dir string) (*BackupManifest, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var manifest *BackupManifest
+	checksums := make(map[string]string)
+
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == backupManifest {
+			manifest = new(BackupManifest)
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("%s: cannot read manifest: %s", ErrBackupIsCorrupted, err)
+			}
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		if name != backupDb && !strings.HasPrefix(name, backupIdx+"/") && !strings.HasPrefix(name, backupMedia+"/") {
+			return nil, fmt.Errorf("%s: unexpected file '%s'", ErrBackupIsCorrupted, hdr.Name)
+		}
+
+		if dir == "" {
+			if checksums[name], err = checksum(tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if checksums[name], err = extractFile(tr, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%s: no manifest found", ErrBackupIsCorrupted)
+	}
+
+	var mismatches []string
+	for name, sum := range manifest.Files {
+		if checksums[name] != sum {
+			mismatches = append(mismatches, name)
+		}
+	}
+	for name := range checksums {
+		if _, exists := manifest.Files[name]; !exists {
+			mismatches = append(mismatches, name)
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return nil, fmt.Errorf("%s: checksum mismatch for %s", ErrBackupIsCorrupted, strings.Join(mismatches, ", "))
+	}
+
+	if _, exists := checksums[backupDb]; !exists {
+		return nil, fmt.Errorf("%s: no database found", ErrBackupIsCorrupted)
+	}
+
+	return manifest, nil
+}
+
+// extractFile copies r content to dst and returns its sha256 checksum.
+func extractFile(r io.Reader, dst string) (sum string, err error) {
+	if err = os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return
+	}
+
+	var f *os.File
+	if f, err = os.Create(dst); err != nil {
+		return
+	}
+	defer func() {
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksum returns the sha256 checksum of r content.
+func checksum(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// addToTar adds a new file to a tar archive. Added file's checksum is
+// registered in the provided manifest if not nil.
+func addToTar(tw *tar.Writer, manifest *BackupManifest, name string, size int64, writeFn func(io.Writer) error) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0666,
+		Size:    size,
+		ModTime: timestamper(),
+	}); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if err := writeFn(io.MultiWriter(tw, h)); err != nil {
+		return err
+	}
+
+	if manifest != nil {
+		manifest.Files[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return nil
+}
+
+// addFileToTar adds a file from the host file-system to a tar archive.
+func addFileToTar(tw *tar.Writer, manifest *BackupManifest, src string, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	return addToTar(tw, manifest, name, fi.Size(), func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	})
+}
+
+// addDirToTar adds a folder from the host file-system to a tar archive.
+func addDirToTar(tw *tar.Writer, manifest *BackupManifest, src string, name string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		return addFileToTar(tw, manifest, p, path.Join(name, filepath.ToSlash(rel)))
+	})
+}
@@ -0,0 +1,235 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pirmd/verify"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	keys := populateStore(t, s)
+
+	for _, withIndex := range []bool{true, false} {
+		archive := new(bytes.Buffer)
+		if err := s.Backup(archive, time.Time{}, withIndex); err != nil {
+			t.Fatalf("Fail to backup store: %v", err)
+		}
+
+		tstDir, err := verify.NewTestFolder(t.Name() + "_restored")
+		if err != nil {
+			t.Fatalf("Fail to create test folder: %v", err)
+		}
+		defer tstDir.Clean()
+
+		restored, err := New(tstDir.Root)
+		if err != nil {
+			t.Fatalf("Fail to create restored Store: %v", err)
+		}
+
+		manifest, err := restored.Restore(archive)
+		if err != nil {
+			t.Fatalf("Fail to restore store: %v", err)
+		}
+		if manifest.WithIndex != withIndex {
+			t.Errorf("Restored manifest does not record index presence.\nWant: %v\nGot : %v", withIndex, manifest.WithIndex)
+		}
+
+		if err := restored.Open(); err != nil {
+			t.Fatalf("Fail to open restored Store: %v", err)
+		}
+		if !withIndex {
+			if err := restored.RebuildIndex(); err != nil {
+				t.Fatalf("Fail to rebuild restored Store index: %v", err)
+			}
+		}
+
+		for _, k := range keys {
+			shouldExistInStore(t, restored, k)
+		}
+
+		if restored.IsDirty() {
+			t.Errorf("Restored Store is inconsistent")
+		}
+
+		if err := restored.Close(); err != nil {
+			t.Fatalf("Fail to close restored Store: %v", err)
+		}
+		tstDir.Clean()
+	}
+}
+
+func TestIncrementalBackup(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	_ = populateStore(t, s)
+
+	archive := new(bytes.Buffer)
+	if err := s.Backup(archive, time.Unix(190701725, 0).Add(time.Hour), false); err != nil {
+		t.Fatalf("Fail to backup store: %v", err)
+	}
+
+	tstDir, err := verify.NewTestFolder(t.Name() + "_extracted")
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	manifest, err := extractTar(archive, tstDir.Root)
+	if err != nil {
+		t.Fatalf("Fail to read backup: %v", err)
+	}
+
+	if !manifest.IsIncremental() {
+		t.Errorf("Backup should be incremental")
+	}
+
+	for name := range manifest.Files {
+		if strings.HasPrefix(name, backupMedia+"/") {
+			t.Errorf("Incremental backup contains '%s' that was not updated since backup date", name)
+		}
+	}
+}
+
+func TestRestoreCorruptedBackup(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	_ = populateStore(t, s)
+
+	archive := new(bytes.Buffer)
+	if err := s.Backup(archive, time.Time{}, false); err != nil {
+		t.Fatalf("Fail to backup store: %v", err)
+	}
+
+	tstDir, err := verify.NewTestFolder(t.Name() + "_extracted")
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	truncated := bytes.NewReader(archive.Bytes()[:archive.Len()/2])
+	if _, err := extractTar(truncated, tstDir.Root); err == nil {
+		t.Errorf("Restoring a truncated backup should fail")
+	}
+}
+
+func TestRestoreBackupWithWrongChecksum(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	keys := populateStore(t, s)
+
+	archive := new(bytes.Buffer)
+	if err := s.Backup(archive, time.Time{}, false); err != nil {
+		t.Fatalf("Fail to backup store: %v", err)
+	}
+
+	tstDir, err := verify.NewTestFolder(t.Name() + "_restored")
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	restored, err := New(tstDir.Root)
+	if err != nil {
+		t.Fatalf("Fail to create restored Store: %v", err)
+	}
+
+	if _, err := restored.Restore(bytes.NewReader(archive.Bytes())); err != nil {
+		t.Fatalf("Fail to restore store: %v", err)
+	}
+
+	db, err := ioutil.ReadFile(filepath.Join(tstDir.Root, dbPath))
+	if err != nil {
+		t.Fatalf("Fail to read restored database: %v", err)
+	}
+
+	if _, err := CheckBackup(bytes.NewReader(archive.Bytes())); err != nil {
+		t.Errorf("Fail to check backup: %v", err)
+	}
+
+	if _, err := CheckBackup(tamperBackup(t, archive.Bytes(), backupMedia+"/"+keys[0])); err == nil {
+		t.Errorf("Checking a backup with a wrong checksum should fail")
+	}
+
+	tampered := tamperBackup(t, archive.Bytes(), backupMedia+"/"+keys[0])
+	if _, err := restored.Restore(tampered); err == nil {
+		t.Errorf("Restoring a backup with a wrong checksum should fail")
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(tstDir.Root, dbPath))
+	if err != nil {
+		t.Fatalf("Fail to read restored database: %v", err)
+	}
+	if !bytes.Equal(got, db) {
+		t.Errorf("Restoring a corrupted backup should leave the Store untouched")
+	}
+}
+
+// tamperBackup rewrites a backup archive, modifying the content of the member
+// name without updating the manifest.
+func tamperBackup(tb testing.TB, archive []byte, name string) io.Reader {
+	zr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		tb.Fatalf("Fail to read backup: %v", err)
+	}
+
+	tampered := new(bytes.Buffer)
+	zw := gzip.NewWriter(tampered)
+	tw := tar.NewWriter(zw)
+
+	var found bool
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tb.Fatalf("Fail to read backup: %v", err)
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			tb.Fatalf("Fail to read backup: %v", err)
+		}
+
+		if hdr.Name == name {
+			found = true
+			content = append(content, []byte("tampered")...)
+			hdr.Size = int64(len(content))
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			tb.Fatalf("Fail to write backup: %v", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			tb.Fatalf("Fail to write backup: %v", err)
+		}
+	}
+
+	if !found {
+		tb.Fatalf("Fail to find '%s' in backup", name)
+	}
+
+	if err := tw.Close(); err != nil {
+		tb.Fatalf("Fail to write backup: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		tb.Fatalf("Fail to write backup: %v", err)
+	}
+
+	return tampered
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"io/ioutil"
 	"log"
+	"time"
 
 	"github.com/blevesearch/bleve/mapping"
 	"github.com/blevesearch/bleve/registry"
@@ -29,26 +30,59 @@ type Config struct {
 	// IndexingScheme is the bleve's document mapping used to index store's
 	// records.
 	IndexingScheme *mapping.IndexMappingImpl
+
+	// LanguageField is the name of the record's field that holds the
+	// record's language (as a BCP-47 tag like "en" or "fr-CA"). If not
+	// empty, LanguageAnalyzedFields are also indexed using the analyzer of
+	// the record's language.
+	LanguageField string
+
+	// LanguageAnalyzedFields lists the text fields to index using the
+	// analyzer of the record's language. Such fields can be searched for as
+	// '<analyzer>.<field>' (like 'fr.Description').
+	LanguageAnalyzedFields []string
+
+	// KeywordFields lists the fields that are indexed as a whole instead of
+	// being split into words (like a reading status 'to-read' that should
+	// not match a search for 'read').
+	KeywordFields []string
+
+	// Schema describes, by record's Type, the expected records' fields.
+	// Records' values are converted to the schema's types when stored and
+	// records that do not follow their schema are rejected.
+	Schema Schema
+
+	// LockTimeout is the time to wait for other processes to release the
+	// Store before giving up opening it.
+	// Default to 10 seconds.
+	LockTimeout time.Duration
 }
 
 // NewConfig creates config
 func NewConfig() *Config {
 	return &Config{
-		Path:      ".",
-		Logger:    log.New(ioutil.Discard, "", log.Ltime|log.Lshortfile),
-		TypeField: "Type",
+		Path:        ".",
+		Logger:      log.New(ioutil.Discard, "", log.Ltime|log.Lshortfile),
+		TypeField:   "Type",
+		LockTimeout: 10 * time.Second,
 	}
 }
 
-// NewFromConfig creates a Store from a given Config
-func NewFromConfig(cfg *Config) (*Store, error) {
-	return New(
-		cfg.Path,
+// NewFromConfig creates a Store from a given Config. NewFromConfig accepts
+// options to further customize the Store.
+func NewFromConfig(cfg *Config, opts ...Option) (*Store, error) {
+	cfgOpts := []Option{
 		UsingLogger(cfg.Logger),
 		UsingDefaultAnalyzer(cfg.IndexingAnalyzer),
 		UsingIndexingScheme(cfg.IndexingScheme),
 		UsingTypeField(cfg.TypeField),
-	)
+		UsingLanguageAnalyzers(cfg.LanguageField, cfg.LanguageAnalyzedFields),
+		UsingKeywordFields(cfg.KeywordFields),
+		UsingSchema(cfg.Schema),
+		UsingLockTimeout(cfg.LockTimeout),
+	}
+
+	return New(cfg.Path, append(cfgOpts, opts...)...)
 }
 
 // Analyzers lists the available analyzers that can be used to configure the
@@ -118,3 +152,72 @@ func UsingTypeField(name string) Option {
 		return nil
 	}
 }
+
+// UsingLanguageAnalyzers makes the Store's index analyze the given text fields
+// a second time using the analyzer that corresponds to the record's language
+// as found in languageField. Language specific analysis (like stemming) is
+// then available by searching '<analyzer>.<field>' (like 'fr.Description'),
+// usual searches being unchanged.
+//
+// Language analyzers only apply to newly created indexes so that you might
+// need to manually regenerate the index.
+//
+// UsingLanguageAnalyzers shall be used after UsingIndexingScheme
+func UsingLanguageAnalyzers(languageField string, fields []string) Option {
+	return func(s *Store) error {
+		if languageField != "" && len(fields) > 0 {
+			s.idx.UseLanguageAnalyzers(languageField, fields)
+		}
+		return nil
+	}
+}
+
+// UsingKeywordFields makes the Store's index keep the given fields' values
+// as a whole instead of splitting them into words, so that they can only be
+// searched for using their exact value.
+//
+// Keyword fields only apply to newly created indexes so that you might need
+// to manually regenerate the index.
+//
+// UsingKeywordFields shall be used after UsingIndexingScheme
+func UsingKeywordFields(fields []string) Option {
+	return func(s *Store) error {
+		if len(fields) > 0 {
+			s.idx.UseKeywordFields(fields)
+		}
+		return nil
+	}
+}
+
+// UsingUserFields declares the fields holding the end-user's personal
+// information (see Record.UserData). Values of these fields found in
+// records' data, like for records stored before personal information was
+// kept apart, are moved to the end-user's personal information when the
+// Store's database is migrated.
+func UsingUserFields(fields []string) Option {
+	return func(s *Store) error {
+		s.userFields = fields
+		return nil
+	}
+}
+
+// UsingSchema sets the schema that the Store's records should follow.
+func UsingSchema(schema Schema) Option {
+	return func(s *Store) error {
+		if err := schema.check(); err != nil {
+			return err
+		}
+		s.schema = schema
+		return nil
+	}
+}
+
+// UsingLockTimeout sets the time to wait for other processes to release the
+// Store when opening it. By default, opening a Store that is used by another
+// process fails immediately.
+func UsingLockTimeout(timeout time.Duration) Option {
+	return func(s *Store) error {
+		s.lock.timeout = timeout
+		return nil
+	}
+}
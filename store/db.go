@@ -3,6 +3,9 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 
 	"github.com/pirmd/gostore/util"
 
@@ -10,7 +13,17 @@ import (
 )
 
 const (
-	bucketName = "gostore"
+	bucketName      = "gostore"
+	aliasBucketName = "aliases"
+	metaBucketName  = "meta"
+
+	// versionKey is the key of the database's format version in the
+	// metadata bucket.
+	versionKey = "version"
+
+	// reindexKey is the key, in the metadata bucket, flagging that the index
+	// has to be rebuilt from the database.
+	reindexKey = "reindex"
 )
 
 var (
@@ -28,21 +41,59 @@ func newDB(path string) *storedb {
 	return &storedb{path: path}
 }
 
-// Open opens a new database.
-func (s *storedb) Open() (err error) {
-	if s.db, err = bolt.Open(s.path, 0666, nil); err != nil {
+// Open opens a new database. A database opened in read-only mode is not
+// created if missing and can be shared with other read-only processes.
+func (s *storedb) Open(readOnly bool) (err error) {
+	if readOnly {
+		if _, err = os.Stat(s.path); err != nil {
+			return
+		}
+	}
+
+	if s.db, err = bolt.Open(s.path, 0666, &bolt.Options{ReadOnly: readOnly}); err != nil {
+		return
+	}
+
+	if readOnly {
 		return
 	}
 
 	return s.db.Update(func(tx *bolt.Tx) error {
-		_, err = tx.CreateBucketIfNotExists([]byte(bucketName))
-		return err
+		b, err := tx.CreateBucketIfNotExists([]byte(bucketName))
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.CreateBucketIfNotExists([]byte(aliasBucketName)); err != nil {
+			return err
+		}
+
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucketName))
+		if err != nil {
+			return err
+		}
+
+		// New databases are created in the current format, databases
+		// without version are left as is to be later migrated.
+		if meta.Get([]byte(versionKey)) == nil {
+			if k, _ := b.Cursor().First(); k == nil {
+				return putVersion(tx, dbVersion())
+			}
+		}
+		return nil
 	})
 }
 
-// Close closes database
+// Close closes database. Closing a database that is not opened does
+// nothing.
 func (s *storedb) Close() error {
-	return s.db.Close()
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+	return err
 }
 
 // Put adds a new Record into the database
@@ -107,6 +158,128 @@ func (s *storedb) Exists(key string) (bool, error) {
 	return (buf != nil), nil
 }
 
+// PutAlias records that alias is a variant of the canonical value of the
+// given field.
+func (s *storedb) PutAlias(field, alias, canonical string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket([]byte(aliasBucketName)).CreateBucketIfNotExists([]byte(field))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(alias), []byte(canonical))
+	})
+}
+
+// DeleteAlias removes an alias of the given field.
+func (s *storedb) DeleteAlias(field, alias string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(aliasBucketName)).Bucket([]byte(field))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(alias))
+	})
+}
+
+// Aliases retrieves the aliases of the given field and their canonical
+// value.
+func (s *storedb) Aliases(field string) (map[string]string, error) {
+	aliases := make(map[string]string)
+
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(aliasBucketName)).Bucket([]byte(field))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			aliases[string(k)] = string(v)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// Version returns the version of the database's format. Databases created
+// before formats were versioned are of version 1.
+func (s *storedb) Version() (version int, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket([]byte(metaBucketName))
+		if meta == nil {
+			version = 1
+			return nil
+		}
+
+		buf := meta.Get([]byte(versionKey))
+		if buf == nil {
+			version = 1
+			return nil
+		}
+
+		version, err = strconv.Atoi(string(buf))
+		return err
+	})
+	return
+}
+
+// Migrate upgrades the database to the given version's format using fn.
+// Migration is done within a single transaction so that the database is left
+// unmodified if fn fails.
+func (s *storedb) Migrate(version int, fn func(*bolt.Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return putVersion(tx, version)
+	})
+}
+
+// CopyTo saves a consistent copy of the database to path.
+func (s *storedb) CopyTo(path string) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0666)
+	})
+}
+
+func putVersion(tx *bolt.Tx, version int) error {
+	return tx.Bucket([]byte(metaBucketName)).Put([]byte(versionKey), []byte(strconv.Itoa(version)))
+}
+
+// NeedsReindex reports whether the index has to be rebuilt from the
+// database.
+func (s *storedb) NeedsReindex() (needed bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket([]byte(metaBucketName)); meta != nil {
+			needed = meta.Get([]byte(reindexKey)) != nil
+		}
+		return nil
+	})
+	return
+}
+
+// SetReindexed records that the index has been rebuilt.
+func (s *storedb) SetReindexed() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(metaBucketName)).Delete([]byte(reindexKey))
+	})
+}
+
+func putReindex(tx *bolt.Tx) error {
+	return tx.Bucket([]byte(metaBucketName)).Put([]byte(reindexKey), []byte("true"))
+}
+
+// Backup provides fn with a consistent copy of the database. Copy is
+// obtained from a read-only transaction so that it can safely be run while
+// the database is in use. fn receives the size of the copy and a WriterTo to
+// actually write it.
+func (s *storedb) Backup(fn func(size int64, wr io.WriterTo) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(tx.Size(), tx)
+	})
+}
+
 // Walk iterates over all storedb items and call walkFn for each item
 // Walk does not stop if an error is reported by walkFn, such errors will
 // be captured and reported back once Walk is over
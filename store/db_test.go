@@ -14,7 +14,7 @@ func setupDb(tb testing.TB) (*storedb, func()) {
 	}
 
 	db := newDB(filepath.Join(tstDir.Root, "test.db"))
-	if err := db.Open(); err != nil {
+	if err := db.Open(false); err != nil {
 		tstDir.Clean()
 		tb.Fatalf("Fail to create testing Store database: %s", err)
 	}
@@ -58,3 +58,48 @@ func TestDBWalk(t *testing.T) {
 		t.Errorf("Walk through db failed:\n%v", failure)
 	}
 }
+
+func TestDBAliases(t *testing.T) {
+	db, cleanFn := setupDb(t)
+	defer cleanFn()
+
+	aliases, err := db.Aliases("Authors")
+	if err != nil {
+		t.Fatalf("Reading aliases failed: %v", err)
+	}
+	if len(aliases) != 0 {
+		t.Errorf("Aliases should be empty, got: %v", aliases)
+	}
+
+	if err := db.PutAlias("Authors", "Hugo, Victor", "Victor Hugo"); err != nil {
+		t.Fatalf("Adding alias failed: %v", err)
+	}
+	if err := db.PutAlias("Authors", "V. Hugo", "Victor Hugo"); err != nil {
+		t.Fatalf("Adding alias failed: %v", err)
+	}
+	if err := db.PutAlias("Publisher", "Hetzel", "J. Hetzel et Cie"); err != nil {
+		t.Fatalf("Adding alias failed: %v", err)
+	}
+	if err := db.DeleteAlias("Authors", "Hugo, Victor"); err != nil {
+		t.Fatalf("Deleting alias failed: %v", err)
+	}
+
+	aliases, err = db.Aliases("Authors")
+	if err != nil {
+		t.Fatalf("Reading aliases failed: %v", err)
+	}
+	if len(aliases) != 1 || aliases["V. Hugo"] != "Victor Hugo" {
+		t.Errorf("Aliases are not correctly stored, got: %v", aliases)
+	}
+
+	keys := []string{}
+	if err := db.Walk(func(key string) error {
+		keys = append(keys, key)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walking through db failed: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Aliases should not be walked through, got: %v", keys)
+	}
+}
@@ -45,7 +45,20 @@ func (s *storefs) Exists(path string) (bool, error) {
 // Put imports a Record into the storefs.
 // Put will happily erase and replace any existing file previously
 // found at Record's path, if any.
+// If Record has no attached file, Put only checks that a file already exists
+// at Record's path.
 func (s *storefs) Put(r *Record) error {
+	if r.File() == nil {
+		exists, err := s.Exists(r.Key())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrRecordDoesNotExist
+		}
+		return nil
+	}
+
 	return s.fs.Copy(r.File(), r.Key())
 }
 
@@ -57,6 +70,15 @@ func (s *storefs) Get(key string) (io.ReadCloser, error) {
 	return s.fs.Open(key)
 }
 
+// Size returns the size of the Record's file corresponding to the given key.
+func (s *storefs) Size(key string) (int64, error) {
+	info, err := s.fs.Stat(key)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
 // Update updates an existing Record in the storefs.
 func (s *storefs) Update(oldkey string, r *Record) error {
 	if r.File() != nil {
@@ -7,6 +7,7 @@ import (
 	"github.com/pirmd/gostore/util"
 
 	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/document"
 	"github.com/blevesearch/bleve/mapping"
 	"github.com/blevesearch/bleve/search/query"
@@ -51,6 +52,9 @@ type storeidx struct {
 
 	path string
 	idx  bleve.Index
+
+	languageField  string
+	languageFields []string
 }
 
 func newIdx(path string) *storeidx {
@@ -60,12 +64,13 @@ func newIdx(path string) *storeidx {
 	}
 }
 
-// Open opens or creates a new storeidx.
-func (s *storeidx) Open() (err error) {
-	if s.idx, err = bleve.Open(s.path); err == nil {
+// Open opens or creates a new storeidx. An index opened in read-only mode is
+// not created if missing and can be shared with other read-only processes.
+func (s *storeidx) Open(readOnly bool) (err error) {
+	if s.idx, err = bleve.OpenUsing(s.path, map[string]interface{}{"read_only": readOnly}); err == nil {
 		return
 	}
-	if err != bleve.ErrorIndexPathDoesNotExist {
+	if err != bleve.ErrorIndexPathDoesNotExist || readOnly {
 		return
 	}
 
@@ -73,9 +78,16 @@ func (s *storeidx) Open() (err error) {
 	return
 }
 
-// Close cleanly closes the storeidx.
+// Close cleanly closes the storeidx. Closing a storeidx that is not opened
+// does nothing.
 func (s *storeidx) Close() error {
-	return s.idx.Close()
+	if s.idx == nil {
+		return nil
+	}
+
+	err := s.idx.Close()
+	s.idx = nil
+	return err
 }
 
 // Empty removes all content from the index and restart from scratch.
@@ -88,13 +100,13 @@ func (s *storeidx) Empty() (err error) {
 		return
 	}
 
-	err = s.Open()
+	err = s.Open(false)
 	return
 }
 
 // Put adds a new value to the new index.
 func (s *storeidx) Put(r *Record) error {
-	return s.idx.Index(r.key, r.Value())
+	return s.idx.Index(r.key, s.indexedValue(r))
 }
 
 // Get retrieves a value from the index.
@@ -330,3 +342,21 @@ func (s *storeidx) search(req *bleve.SearchRequest) ([]string, error) {
 	}
 	return keys, nil
 }
+
+// UseKeywordFields configures the index so that the given fields are indexed
+// using the keyword analyzer, keeping their values as a whole.
+func (s *storeidx) UseKeywordFields(fields []string) {
+	docMappings := []*mapping.DocumentMapping{s.Mapping.DefaultMapping}
+	for _, dm := range s.Mapping.TypeMapping {
+		docMappings = append(docMappings, dm)
+	}
+
+	for _, field := range fields {
+		fm := mapping.NewTextFieldMapping()
+		fm.Analyzer = keyword.Name
+
+		for _, dm := range docMappings {
+			dm.AddFieldMappingsAt(field, fm)
+		}
+	}
+}
@@ -15,7 +15,7 @@ func setupIdx(tb testing.TB) (*storeidx, func()) {
 	}
 
 	idx := newIdx(filepath.Join(tstDir.Root, "idxtest"))
-	if err := idx.Open(); err != nil {
+	if err := idx.Open(false); err != nil {
 		tstDir.Clean()
 		tb.Fatalf("Fail to create testing Store index: %s", err)
 	}
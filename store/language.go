@@ -0,0 +1,93 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/blevesearch/bleve/mapping"
+)
+
+var (
+	// languageAnalyzers lists, by language, the bleve's language analyzers
+	// available to the index.
+	languageAnalyzers = map[string]string{
+		"ar": "ar", "ckb": "ckb", "da": "da", "de": "de", "en": "en",
+		"es": "es", "fa": "fa", "fi": "fi", "fr": "fr", "hi": "hi",
+		"hu": "hu", "it": "it", "nl": "nl", "no": "no", "nb": "no",
+		"nn": "no", "pt": "pt", "ro": "ro", "ru": "ru", "sv": "sv",
+		"tr": "tr", "zh": "cjk", "ja": "cjk", "ko": "cjk",
+	}
+)
+
+// UseLanguageAnalyzers configures the index so that fields are also analyzed
+// using the analyzer corresponding to the record's language stored in
+// languageField.
+func (s *storeidx) UseLanguageAnalyzers(languageField string, fields []string) {
+	s.languageField, s.languageFields = languageField, fields
+
+	docMappings := []*mapping.DocumentMapping{s.Mapping.DefaultMapping}
+	for _, dm := range s.Mapping.TypeMapping {
+		docMappings = append(docMappings, dm)
+	}
+
+	seen := make(map[string]bool)
+	for _, analyzer := range languageAnalyzers {
+		if seen[analyzer] {
+			continue
+		}
+		seen[analyzer] = true
+
+		langMapping := mapping.NewDocumentMapping()
+		for _, field := range fields {
+			fm := mapping.NewTextFieldMapping()
+			fm.Analyzer = analyzer
+			fm.Store = false
+			fm.IncludeInAll = false
+			langMapping.AddFieldMappingsAt(field, fm)
+		}
+
+		for _, dm := range docMappings {
+			dm.AddSubDocumentMapping(analyzer, langMapping)
+		}
+	}
+}
+
+// languageAnalyzerFor returns the name of the bleve's analyzer corresponding
+// to the given language tag (like "fr" or "en-US"), if any.
+func languageAnalyzerFor(lang string) (string, bool) {
+	base := strings.ToLower(strings.SplitN(strings.Replace(lang, "_", "-", -1), "-", 2)[0])
+	analyzer, ok := languageAnalyzers[base]
+	return analyzer, ok
+}
+
+// indexedValue returns the record's value to index. If language analyzers are
+// used, the language analyzed fields are copied in a sub-document named after
+// the language analyzer.
+func (s *storeidx) indexedValue(r *Record) map[string]interface{} {
+	value := r.Value()
+	if s.languageField == "" {
+		return value
+	}
+
+	lang, ok := value[s.languageField].(string)
+	if !ok {
+		return value
+	}
+
+	analyzer, ok := languageAnalyzerFor(lang)
+	if !ok {
+		return value
+	}
+
+	langValue := make(map[string]interface{})
+	for _, field := range s.languageFields {
+		if v, exists := value[field]; exists {
+			langValue[field] = v
+		}
+	}
+
+	if len(langValue) > 0 {
+		value[analyzer] = langValue
+	}
+
+	return value
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+func TestLanguageAnalyzers(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	s, err := New(tstDir.Root, UsingLanguageAnalyzers("Language", []string{"Description"}))
+	if err != nil {
+		t.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+	defer s.Close()
+
+	testData := map[string]map[string]interface{}{
+		"fr.epub":      {"Language": "fr-FR", "Description": "Les voyages extraordinaires"},
+		"en.epub":      {"Language": "en", "Description": "The travelling salesman"},
+		"unknown.epub": {"Language": "xx", "Description": "Les voyages extraordinaires"},
+	}
+	for key, data := range testData {
+		if _, err := s.Create(key, data, verify.MockROFile("")); err != nil {
+			t.Fatalf("Fail to add %v: %s", data, err)
+		}
+	}
+
+	testCases := []struct {
+		in  string
+		out []string
+	}{
+		{"fr.Description:voyage", []string{"fr.epub"}},
+		{"en.Description:travel", []string{"en.epub"}},
+		{"Description:voyages", []string{"fr.epub", "unknown.epub"}},
+		{"Description:voyage", nil},
+	}
+
+	for _, tc := range testCases {
+		out, err := s.ReadQuery(tc.in)
+		if err != nil {
+			t.Errorf("Search for %s failed: %s", tc.in, err)
+		}
+
+		if failure := verify.EqualSliceWithoutOrder(out.Key(), tc.out); failure != nil {
+			t.Errorf("Search for %s failed:\n%v", tc.in, failure)
+		}
+	}
+
+	r, err := s.Read("fr.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+	if _, exists := r.Get("fr").(map[string]interface{}); exists {
+		t.Errorf("Language analyzed fields should not be part of the record")
+	}
+}
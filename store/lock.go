@@ -0,0 +1,131 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	lockPath = ".store_lock"
+
+	// lockRetryDelay is the delay between two attempts to lock the Store.
+	lockRetryDelay = 50 * time.Millisecond
+)
+
+var (
+	// ErrStoreIsReadOnly raises an error if a Store opened in read-only mode
+	// is asked to be modified.
+	ErrStoreIsReadOnly = fmt.Errorf("store is opened in read-only mode")
+)
+
+// storelock prevents concurrent processes from opening the Store in
+// conflicting modes: any number of processes can hold a shared lock to read
+// the Store or a single one can hold an exclusive lock to modify it.
+//
+// The process holding the exclusive lock identifies itself in the lock's
+// file so that other processes can tell who they are waiting for.
+type storelock struct {
+	path    string
+	timeout time.Duration
+
+	f         *os.File
+	exclusive bool
+}
+
+func newLock(path string) *storelock {
+	return &storelock{path: path}
+}
+
+// Lock locks the Store, exclusively if exclusive is set. Lock waits up to the
+// lock's timeout for the Store to be released by other processes.
+func (l *storelock) Lock(exclusive bool) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0777); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(l.timeout)
+	for {
+		locked, err := tryLock(f, exclusive)
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		if locked {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			f.Close()
+			return l.lockedError(exclusive)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+
+	if exclusive {
+		if err := f.Truncate(0); err != nil {
+			unlock(f)
+			f.Close()
+			return err
+		}
+		if _, err := f.WriteAt([]byte(holder()), 0); err != nil {
+			unlock(f)
+			f.Close()
+			return err
+		}
+	}
+
+	l.f, l.exclusive = f, exclusive
+	return nil
+}
+
+// Unlock releases the Store's lock.
+func (l *storelock) Unlock() error {
+	if l.f == nil {
+		return nil
+	}
+
+	var err error
+	if l.exclusive {
+		// Clear the holder's identity, it is only meaningful as long as the
+		// lock is held.
+		err = l.f.Truncate(0)
+	}
+
+	if e := unlock(l.f); err == nil {
+		err = e
+	}
+	if e := l.f.Close(); err == nil {
+		err = e
+	}
+	l.f = nil
+
+	return err
+}
+
+// lockedError explains why the Store could not be locked.
+func (l *storelock) lockedError(exclusive bool) error {
+	buf, _ := ioutil.ReadFile(l.path)
+	if h := strings.TrimSpace(string(buf)); h != "" {
+		return fmt.Errorf("store is locked by another process (%s), retry once it is done", h)
+	}
+
+	if exclusive {
+		return fmt.Errorf("store is opened by other processes in read-only mode, retry once they are done")
+	}
+	return fmt.Errorf("store is locked by another process, retry once it is done")
+}
+
+// holder identifies the current process.
+func holder() string {
+	return fmt.Sprintf("pid %d: %s", os.Getpid(), strings.Join(os.Args, " "))
+}
@@ -0,0 +1,19 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package store
+
+import (
+	"os"
+)
+
+// tryLock always succeeds on platforms without flock. Concurrent accesses are
+// then only protected by the database's own lock, without waiting timeout nor
+// explicit error.
+func tryLock(f *os.File, exclusive bool) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
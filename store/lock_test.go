@@ -0,0 +1,111 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pirmd/verify"
+)
+
+func TestLock(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	newStore := func() *Store {
+		s, err := New(tstDir.Root, UsingLockTimeout(100*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Fail to create testing Store: %s", err)
+		}
+		return s
+	}
+
+	t.Run("Can open a new Store in read-only mode", func(t *testing.T) {
+		s := newStore()
+		if err := s.OpenReadOnly(); err != nil {
+			t.Fatalf("Fail to open new Store in read-only mode: %s", err)
+		}
+		defer s.Close()
+
+		records, err := s.ReadAll()
+		if err != nil {
+			t.Fatalf("Fail to read new Store: %s", err)
+		}
+		if len(records) != 0 {
+			t.Errorf("New Store should be empty, got %d records", len(records))
+		}
+	})
+
+	writer := newStore()
+	if err := writer.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+
+	if _, err := writer.Create("test.epub", map[string]interface{}{"Title": "Les misérables"}, verify.MockROFile("")); err != nil {
+		t.Fatalf("Fail to create record: %v", err)
+	}
+
+	t.Run("Cannot open a Store used by another process", func(t *testing.T) {
+		for _, open := range []func(*Store) error{(*Store).Open, (*Store).OpenReadOnly} {
+			start := time.Now()
+			err := open(newStore())
+			if err == nil {
+				t.Fatalf("Opening a locked Store should fail")
+			}
+			if time.Since(start) < 100*time.Millisecond {
+				t.Errorf("Opening a locked Store should wait for lock's timeout")
+			}
+			if !strings.Contains(err.Error(), "store is locked by another process (pid ") {
+				t.Errorf("Lock's error should identify lock's holder: %v", err)
+			}
+		}
+	})
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Fail to close testing Store: %s", err)
+	}
+
+	readers := []*Store{newStore(), newStore()}
+	for _, r := range readers {
+		if err := r.OpenReadOnly(); err != nil {
+			t.Fatalf("Fail to concurrently open Store in read-only mode: %s", err)
+		}
+	}
+
+	t.Run("Can read a Store opened in read-only mode", func(t *testing.T) {
+		for _, r := range readers {
+			shouldExistInStore(t, r, "test.epub")
+		}
+	})
+
+	t.Run("Cannot modify a Store opened in read-only mode", func(t *testing.T) {
+		if err := readers[0].Delete("test.epub"); err != ErrStoreIsReadOnly {
+			t.Errorf("Deleting from a read-only Store should fail with ErrStoreIsReadOnly, got: %v", err)
+		}
+	})
+
+	t.Run("Cannot open a Store used by read-only processes", func(t *testing.T) {
+		if err := newStore().Open(); err == nil {
+			t.Fatalf("Opening a Store used in read-only mode should fail")
+		}
+	})
+
+	for _, r := range readers {
+		if err := r.Close(); err != nil {
+			t.Fatalf("Fail to close testing Store: %s", err)
+		}
+	}
+
+	t.Run("Can open a released Store", func(t *testing.T) {
+		s := newStore()
+		if err := s.Open(); err != nil {
+			t.Fatalf("Fail to open released Store: %s", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("Fail to close testing Store: %s", err)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// migration upgrades the store's database from the previous version's format
+// to a new one.
+type migration struct {
+	// version is the version of the database's format after migration.
+	version int
+	// description explains what the migration is about.
+	description string
+	// apply migrates the database's content.
+	apply func(*Store, *bolt.Tx) error
+}
+
+func (m *migration) String() string {
+	return fmt.Sprintf("v%d: %s", m.version, m.description)
+}
+
+var (
+	// errNeedsMigration signals that the Store's database cannot be opened
+	// in read-only mode as it first needs to be migrated.
+	errNeedsMigration = errors.New("database needs to be migrated")
+
+	// migrations lists, in order, the successive migrations of the store's
+	// database format. Any change in the way records are stored in the
+	// database should come with a new migration.
+	migrations = []*migration{
+		{2, "record the type of records' values", reencodeValues},
+		{3, "move end-user's personal information out of records' data", moveUserFields},
+	}
+)
+
+// dbVersion is the version of the store's database's format.
+func dbVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// Migrate upgrades the Store's database to the current format. A copy of the
+// database is saved before any migration next to the database. Migrate
+// returns the list of the applied migrations or, if dryRun is set, the list
+// of the migrations that would be applied.
+//
+// Migrate expects the Store not to be opened as migrations are anyway
+// automatically applied when opening the Store.
+func (s *Store) Migrate(dryRun bool) ([]string, error) {
+	if err := s.lock.Lock(true); err != nil {
+		return nil, err
+	}
+	defer s.lock.Unlock()
+
+	if err := s.db.Open(false); err != nil {
+		return nil, err
+	}
+	defer s.db.Close()
+
+	return s.migrate(dryRun)
+}
+
+// checkVersion makes sure that the Store's database is in the current format,
+// migrating it if needed. Databases opened in read-only mode cannot be
+// migrated, checkVersion returns errNeedsMigration instead.
+func (s *Store) checkVersion(readOnly bool) error {
+	pending, err := s.migrate(readOnly)
+	if err != nil {
+		return err
+	}
+
+	if readOnly && len(pending) > 0 {
+		s.log.Printf("Database needs to be migrated (%s)", strings.Join(pending, ", "))
+		return errNeedsMigration
+	}
+
+	return nil
+}
+
+func (s *Store) migrate(dryRun bool) ([]string, error) {
+	version, err := s.db.Version()
+	if err != nil {
+		return nil, fmt.Errorf("fail to read database's version: %s", err)
+	}
+
+	if version > dbVersion() {
+		return nil, fmt.Errorf("database's version (v%d) is more recent than the supported one (v%d)", version, dbVersion())
+	}
+
+	var pending []*migration
+	var done []string
+	for _, m := range migrations {
+		if m.version > version {
+			pending = append(pending, m)
+			done = append(done, m.String())
+		}
+	}
+
+	if len(pending) == 0 || dryRun {
+		return done, nil
+	}
+
+	bak := fmt.Sprintf("%s.v%d.bak", s.db.path, version)
+	s.log.Printf("Saving database to '%s' before migration", bak)
+	if err := s.db.CopyTo(bak); err != nil {
+		return nil, fmt.Errorf("fail to save database before migration: %s", err)
+	}
+
+	for i, m := range pending {
+		s.log.Printf("Migrating database to %s", m)
+		apply := m.apply
+		if err := s.db.Migrate(m.version, func(tx *bolt.Tx) error { return apply(s, tx) }); err != nil {
+			return done[:i], fmt.Errorf("fail to migrate database to %s (database is saved in '%s'): %s", m, bak, err)
+		}
+	}
+
+	return done, nil
+}
+
+// reencodeValues stores records' values again so that their types are
+// recorded.
+func reencodeValues(_ *Store, tx *bolt.Tx) error {
+	b := tx.Bucket([]byte(bucketName))
+
+	values := make(map[string][]byte)
+	if err := b.ForEach(func(k, v []byte) error {
+		val := new(value)
+		if err := json.Unmarshal(v, val); err != nil {
+			return fmt.Errorf("record '%s': %s", k, err)
+		}
+
+		buf, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("record '%s': %s", k, err)
+		}
+		values[string(k)] = buf
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for k, v := range values {
+		if err := b.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveUserFields moves the values of the end-user's personal information
+// fields found in records' data to the end-user's personal information,
+// values already there being kept. The index is rebuilt afterwards so that
+// personal information fields benefit from their specific indexing (like
+// reading status indexed as keywords).
+func moveUserFields(s *Store, tx *bolt.Tx) error {
+	b := tx.Bucket([]byte(bucketName))
+
+	values := make(map[string][]byte)
+	if err := b.ForEach(func(k, v []byte) error {
+		val := new(value)
+		if err := json.Unmarshal(v, val); err != nil {
+			return fmt.Errorf("record '%s': %s", k, err)
+		}
+
+		var moved bool
+		for _, field := range s.userFields {
+			v, exists := val.Data[field]
+			if !exists {
+				continue
+			}
+
+			if val.User == nil {
+				val.User = make(map[string]interface{})
+			}
+			if _, exists := val.User[field]; !exists {
+				val.User[field] = v
+			}
+			delete(val.Data, field)
+			moved = true
+		}
+
+		if !moved {
+			return nil
+		}
+
+		buf, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("record '%s': %s", k, err)
+		}
+		values[string(k)] = buf
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for k, v := range values {
+		if err := b.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return putReindex(tx)
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestMigrate(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	s, err := New(tstDir.Root)
+	if err != nil {
+		t.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+
+	if _, err := s.Create("legacy.epub", map[string]interface{}{"Title": "Les misérables"}, verify.MockROFile("")); err != nil {
+		t.Fatalf("Fail to create record: %v", err)
+	}
+
+	// Simulate a database created before formats were versioned.
+	legacy := `{"CreatedAt":"2020-01-02T03:04:05Z","UpdatedAt":"2020-01-02T03:04:05Z","Data":{"Title":"Les misérables","Pages":1232}}`
+	if err := s.db.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte(bucketName)).Put([]byte("legacy.epub"), []byte(legacy)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(metaBucketName)).Delete([]byte(versionKey))
+	}); err != nil {
+		t.Fatalf("Fail to simulate legacy database: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Fail to close store: %v", err)
+	}
+
+	pending, err := s.Migrate(true)
+	if err != nil {
+		t.Fatalf("Fail to list pending migrations: %v", err)
+	}
+	var want []string
+	for _, m := range migrations {
+		want = append(want, m.String())
+	}
+	if !reflect.DeepEqual(pending, want) {
+		t.Errorf("Pending migrations are not as expected.\nWant: %v\nGot : %v", want, pending)
+	}
+
+	bak := filepath.Join(tstDir.Root, dbPath+".v1.bak")
+	if _, err := os.Stat(bak); !os.IsNotExist(err) {
+		t.Errorf("Dry-run migration should not save the database (%v)", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+	defer s.Close()
+
+	if version, err := s.db.Version(); err != nil || version != dbVersion() {
+		t.Errorf("Database is not migrated: got version %d (%v)", version, err)
+	}
+
+	if _, err := os.Stat(bak); err != nil {
+		t.Errorf("Database is not saved before migration: %v", err)
+	}
+
+	r, err := s.Read("legacy.epub")
+	if err != nil {
+		t.Fatalf("Fail to read migrated record: %v", err)
+	}
+	if got := r.Get("Pages"); got != float64(1232) {
+		t.Errorf("Migrated record is not as expected: got %#v", r.Data())
+	}
+}
+
+func TestMigrateUserFields(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	s, err := New(tstDir.Root, UsingUserFields([]string{"Tags", "Rating"}))
+	if err != nil {
+		t.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+
+	if _, err := s.Create("legacy.epub", map[string]interface{}{"Title": "Les misérables"}, verify.MockROFile("")); err != nil {
+		t.Fatalf("Fail to create record: %v", err)
+	}
+
+	// Simulate a database storing end-user's personal information with
+	// records' data. The record is not indexed accordingly.
+	legacy := `{"CreatedAt":"2020-01-02T03:04:05Z","UpdatedAt":"2020-01-02T03:04:05Z","Data":{"Title":"Les misérables","Tags":["classic"],"Rating":4},"Types":{"Rating":"int"},"User":{"Rating":5},"UserTypes":{"Rating":"int"}}`
+	if err := s.db.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte(bucketName)).Put([]byte("legacy.epub"), []byte(legacy)); err != nil {
+			return err
+		}
+		return putVersion(tx, 2)
+	}); err != nil {
+		t.Fatalf("Fail to simulate legacy database: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Fail to close store: %v", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+	defer s.Close()
+
+	r, err := s.Read("legacy.epub")
+	if err != nil {
+		t.Fatalf("Fail to read migrated record: %v", err)
+	}
+
+	if want := map[string]interface{}{"Title": "Les misérables"}; !reflect.DeepEqual(r.Data(), want) {
+		t.Errorf("Personal information is not moved out of record's data.\nWant: %#v\nGot : %#v", want, r.Data())
+	}
+	if want := map[string]interface{}{"Tags": []interface{}{"classic"}, "Rating": 5}; !reflect.DeepEqual(r.UserData(), want) {
+		t.Errorf("Personal information is not moved to record's user data.\nWant: %#v\nGot : %#v", want, r.UserData())
+	}
+
+	found, err := s.ReadQuery("Tags:classic")
+	if err != nil {
+		t.Fatalf("Fail to search store: %v", err)
+	}
+	if len(found) != 1 || found[0].Key() != "legacy.epub" {
+		t.Errorf("Index is not rebuilt after migration: found %v", found)
+	}
+
+	if needed, err := s.db.NeedsReindex(); err != nil || needed {
+		t.Errorf("Index should not need to be rebuilt anymore (%v)", err)
+	}
+}
+
+func TestMigrateReadOnly(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	s, err := New(tstDir.Root)
+	if err != nil {
+		t.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+
+	if err := s.db.db.Update(func(tx *bolt.Tx) error {
+		return putVersion(tx, 2)
+	}); err != nil {
+		t.Fatalf("Fail to simulate legacy database: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Fail to close store: %v", err)
+	}
+
+	if err := s.OpenReadOnly(); err != nil {
+		t.Fatalf("Fail to open legacy Store in read-only mode: %s", err)
+	}
+	defer s.Close()
+
+	if !s.readOnly {
+		t.Errorf("Store should be opened in read-only mode")
+	}
+
+	if version, err := s.db.Version(); err != nil || version != dbVersion() {
+		t.Errorf("Database is not migrated: got version %d (%v)", version, err)
+	}
+}
+
+func TestMigrateNewerDatabase(t *testing.T) {
+	db, cleanFn := setupDb(t)
+	defer cleanFn()
+
+	if version, err := db.Version(); err != nil || version != dbVersion() {
+		t.Errorf("New database is not in the current format: got version %d (%v)", version, err)
+	}
+
+	if err := db.Migrate(dbVersion()+1, func(*bolt.Tx) error { return nil }); err != nil {
+		t.Fatalf("Fail to change database's version: %v", err)
+	}
+
+	s := &Store{db: db, log: verify.NewLogger(t)}
+	if _, err := s.migrate(false); err == nil {
+		t.Errorf("Migrating a database more recent than supported should fail")
+	}
+}
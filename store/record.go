@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -17,6 +18,15 @@ type Record struct {
 	key   string
 	value *value
 	file  Reader
+	cover Reader
+	links []link
+}
+
+// link describes a record derived from another one (like a converted file)
+// through the field of the derived record that refers to the other one.
+type link struct {
+	record *Record
+	field  string
 }
 
 // NewRecord creates a Record.
@@ -38,8 +48,9 @@ func (r *Record) SetKey(key string) {
 }
 
 // Value returns a copy of all information known about Record. It contains the
-// information supplied by the end-user as well as information auto-generated
-// during Record's management (like creation/update stamps).
+// information supplied by the end-user, the end-user's personal information
+// as well as information auto-generated during Record's management (like
+// creation/update stamps).
 func (r *Record) Value() map[string]interface{} {
 	return r.value.Flatted()
 }
@@ -55,6 +66,32 @@ func (r *Record) SetData(data map[string]interface{}) {
 	r.value.SetData(data)
 }
 
+// UserData returns a copy of the end-user's personal information about Record
+// (like tags or reading status).
+func (r *Record) UserData() map[string]interface{} {
+	return r.value.GetUser()
+}
+
+// SetUserData replaces the end-user's personal information about Record.
+// Personal information is kept apart from Record's data so that replacing
+// Record's data (for example when reading again the media's metadata) never
+// modifies it.
+func (r *Record) SetUserData(data map[string]interface{}) {
+	r.value.SetUserData(data)
+}
+
+// SetTimeStamps sets Record's creation and last update time stamps, zero
+// time stamps being ignored. It is useful to restore records serialized
+// elsewhere.
+func (r *Record) SetTimeStamps(createdAt, updatedAt time.Time) {
+	if !createdAt.IsZero() {
+		r.value.CreatedAt = createdAt
+	}
+	if !updatedAt.IsZero() {
+		r.value.UpdatedAt = updatedAt
+	}
+}
+
 // Flatted returns all Record's data in a single flat map including Record's Key
 func (r *Record) Flatted() map[string]interface{} {
 	flatted := r.Value()
@@ -101,6 +138,30 @@ func (r *Record) SetFile(f Reader) {
 	r.file = f
 }
 
+// SetCover sets the cover image of Record. The cover is imported in the store
+// together with Record.
+func (r *Record) SetCover(f Reader) {
+	r.cover = f
+}
+
+// Link attaches to Record a new record derived from it (like a converted
+// file) that is to be stored once Record is. Field is the field of the
+// derived record that refers to Record's key.
+func (r *Record) Link(derived *Record, field string) {
+	r.links = append(r.links, link{record: derived, field: field})
+}
+
+// Linked returns the records derived from Record, referring to Record's
+// current key.
+func (r *Record) Linked() Records {
+	var linked Records
+	for _, l := range r.links {
+		l.record.Set(l.field, r.key)
+		linked = append(linked, l.record)
+	}
+	return linked
+}
+
 // Records represents a collection of Record
 type Records []*Record
 
@@ -152,6 +213,76 @@ type value struct {
 	UpdatedAt time.Time
 	// Data is a dictionary of all user-supplied data stored in the record
 	Data map[string]interface{}
+	// User is a dictionary of the end-user's personal information about the
+	// record (like tags or reading status)
+	User map[string]interface{}
+}
+
+// jsonValue is the JSON representation of a value. As JSON does not keep
+// track of values' types (like time or integer values), Types records the
+// type of the Data's values that are not strings, UserTypes doing the same
+// for User's values.
+type jsonValue struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Data      map[string]interface{}
+	Types     map[string]string      `json:",omitempty"`
+	User      map[string]interface{} `json:",omitempty"`
+	UserTypes map[string]string      `json:",omitempty"`
+}
+
+// MarshalJSON encodes a value to JSON, remembering its Data's types.
+func (val *value) MarshalJSON() ([]byte, error) {
+	jv := &jsonValue{
+		CreatedAt: val.CreatedAt,
+		UpdatedAt: val.UpdatedAt,
+		Data:      val.Data,
+		Types:     typesOf(val.Data),
+		User:      val.User,
+		UserTypes: typesOf(val.User),
+	}
+
+	return json.Marshal(jv)
+}
+
+// UnmarshalJSON decodes a value from JSON, converting back its Data to their
+// recorded types.
+func (val *value) UnmarshalJSON(b []byte) error {
+	jv := new(jsonValue)
+	if err := json.Unmarshal(b, jv); err != nil {
+		return err
+	}
+
+	val.CreatedAt, val.UpdatedAt = jv.CreatedAt, jv.UpdatedAt
+	val.Data = fromTypes(jv.Data, jv.Types)
+	if len(jv.User) > 0 {
+		val.User = fromTypes(jv.User, jv.UserTypes)
+	}
+
+	return nil
+}
+
+// typesOf records the type of m's values that are not strings.
+func typesOf(m map[string]interface{}) map[string]string {
+	types := make(map[string]string)
+	for k, v := range m {
+		if typ := typeOf(v); typ != "" {
+			types[k] = typ
+		}
+	}
+	return types
+}
+
+// fromTypes converts back JSON decoded m's values to their recorded types.
+func fromTypes(m map[string]interface{}, types map[string]string) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if typ, exists := types[k]; exists {
+			v = fromType(typ, v)
+		}
+		converted[k] = v
+	}
+	return converted
 }
 
 // newValue creates a new value
@@ -186,10 +317,36 @@ func (val *value) GetData() map[string]interface{} {
 	return data
 }
 
-// Flatted returns all information stored in value, both user-supplied data
-// and automatic managed data like creation/update time.
+// SetUserData replaces the end-user's personal information.
+func (val *value) SetUserData(data map[string]interface{}) {
+	if fmt.Sprint(val.User) == fmt.Sprint(data) {
+		return
+	}
+
+	val.User = make(map[string]interface{})
+	for k, v := range data {
+		val.User[k] = v
+	}
+	val.UpdatedAt = timestamper()
+}
+
+// GetUser returns the end-user's personal information.
+func (val *value) GetUser() map[string]interface{} {
+	user := make(map[string]interface{})
+	for k, v := range val.User {
+		user[k] = v
+	}
+	return user
+}
+
+// Flatted returns all information stored in value, user-supplied data,
+// end-user's personal information and automatic managed data like
+// creation/update time.
 func (val *value) Flatted() map[string]interface{} {
 	flatted := val.GetData()
+	for k, v := range val.User {
+		flatted[k] = v
+	}
 	flatted["CreatedAt"] = val.CreatedAt
 	flatted["UpdatedAt"] = val.UpdatedAt
 	return flatted
@@ -0,0 +1,358 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	// DefaultSchemaType is the name of the schema that applies to records
+	// whose Type has no specific schema.
+	DefaultSchemaType = "media"
+
+	// Known types of fields' values.
+	StringType = "string"
+	IntType    = "int"
+	FloatType  = "float"
+	BoolType   = "bool"
+	TimeType   = "time"
+)
+
+// FieldSchema describes the expected value of a record's field.
+type FieldSchema struct {
+	// Name is the name of the field.
+	Name string
+
+	// Type is the type of the field's value. Known types are string, int,
+	// float, bool and time. Default to string.
+	Type string
+
+	// Multi is a flag that indicates that the field holds a list of values.
+	Multi bool
+
+	// Required is a flag that indicates that the field should have a
+	// non-empty value.
+	Required bool
+
+	// Allowed lists the allowed values of the field. Any value is allowed if
+	// empty.
+	Allowed []string
+}
+
+// Schema describes, by record's Type, the expected records' fields. Fields
+// that are not described by the schema are stored as is.
+type Schema map[string][]*FieldSchema
+
+// For returns the schema that applies to the given record's Type. For falls
+// back to the schema of the Type's sub-family, family then to
+// DefaultSchemaType.
+func (s Schema) For(typ string) []*FieldSchema {
+	for _, t := range []string{typ, filepath.Base(typ), filepath.Dir(typ)} {
+		if fields, exists := s[t]; exists {
+			return fields
+		}
+	}
+
+	return s[DefaultSchemaType]
+}
+
+// check verifies that the schema only relies on known types.
+func (s Schema) check() error {
+	for typ, fields := range s {
+		for _, f := range fields {
+			switch f.Type {
+			case "", StringType, IntType, FloatType, BoolType, TimeType:
+			default:
+				return fmt.Errorf("schema of '%s': field '%s' is of unknown type '%s'", typ, f.Name, f.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that the given fields follow the schema of their record's
+// Type (as found in typeField) and converts their values to the expected
+// types. Validate reports all invalid fields at once, the valid fields
+// being converted anyway.
+func (s Schema) Validate(data map[string]interface{}, typeField string) error {
+	if len(s) == 0 {
+		return nil
+	}
+
+	typ, _ := data[typeField].(string)
+
+	var errs util.MultiErrors
+	for _, field := range s.For(typ) {
+		v, err := field.convert(data[field.Name])
+		if err != nil {
+			errs.Add(fmt.Errorf("invalid field '%s': %v", field.Name, err))
+			continue
+		}
+
+		if v == nil {
+			delete(data, field.Name)
+			continue
+		}
+		data[field.Name] = v
+	}
+
+	return errs.Err()
+}
+
+func (f *FieldSchema) convert(v interface{}) (interface{}, error) {
+	values, isList := toList(v)
+
+	if len(values) == 0 {
+		if f.Required {
+			return nil, fmt.Errorf("value is required")
+		}
+		return nil, nil
+	}
+
+	if isList && !f.Multi && len(values) > 1 {
+		return nil, fmt.Errorf("expecting a single value, got %d", len(values))
+	}
+
+	var converted []interface{}
+	for _, item := range values {
+		c, err := convertTo(f.Type, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if !f.allows(c) {
+			return nil, fmt.Errorf("'%v' is not one of the allowed values (%s)", c, strings.Join(f.Allowed, ", "))
+		}
+
+		converted = append(converted, c)
+	}
+
+	if !f.Multi {
+		return converted[0], nil
+	}
+	return typedList(f.Type, converted), nil
+}
+
+func (f *FieldSchema) allows(v interface{}) bool {
+	if len(f.Allowed) == 0 {
+		return true
+	}
+
+	for _, a := range f.Allowed {
+		if a == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// toList returns v as a list of values, ignoring nil or empty values.
+func toList(v interface{}) ([]interface{}, bool) {
+	var values []interface{}
+
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+
+	case []interface{}:
+		for _, item := range v {
+			if !isEmpty(item) {
+				values = append(values, item)
+			}
+		}
+		return values, true
+
+	case []string:
+		for _, item := range v {
+			if !isEmpty(item) {
+				values = append(values, item)
+			}
+		}
+		return values, true
+
+	default:
+		if isEmpty(v) {
+			return nil, false
+		}
+		return []interface{}{v}, false
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case time.Time:
+		return v.IsZero()
+	}
+	return false
+}
+
+// convertTo converts v to the given type.
+func convertTo(typ string, v interface{}) (interface{}, error) {
+	switch typ {
+	case "", StringType:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		if t, ok := v.(time.Time); ok {
+			return t.Format(time.RFC3339), nil
+		}
+		return fmt.Sprint(v), nil
+
+	case IntType:
+		switch v := v.(type) {
+		case int:
+			return v, nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v == float64(int(v)) {
+				return int(v), nil
+			}
+		case string:
+			if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return i, nil
+			}
+		}
+
+	case FloatType:
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+
+	case BoolType:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+
+	case TimeType:
+		switch v := v.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			if t, err := util.ParseTime(strings.TrimSpace(v)); err == nil {
+				return t, nil
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown type '%s'", typ)
+	}
+
+	return nil, fmt.Errorf("cannot convert '%v' to %s", v, typ)
+}
+
+// typedList converts a list of values of the given type to the corresponding
+// typed slice.
+func typedList(typ string, values []interface{}) interface{} {
+	switch typ {
+	case "", StringType:
+		l := make([]string, len(values))
+		for i, v := range values {
+			l[i] = v.(string)
+		}
+		return l
+
+	case IntType:
+		l := make([]int, len(values))
+		for i, v := range values {
+			l[i] = v.(int)
+		}
+		return l
+
+	case FloatType:
+		l := make([]float64, len(values))
+		for i, v := range values {
+			l[i] = v.(float64)
+		}
+		return l
+
+	case BoolType:
+		l := make([]bool, len(values))
+		for i, v := range values {
+			l[i] = v.(bool)
+		}
+		return l
+
+	case TimeType:
+		l := make([]time.Time, len(values))
+		for i, v := range values {
+			l[i] = v.(time.Time)
+		}
+		return l
+	}
+
+	return values
+}
+
+// typeOf returns the type of a value among the types known by the schema.
+// Multi-valued types are prefixed by "[]". typeOf returns an empty string
+// for strings, that do not need to be remembered as they survive a JSON
+// encoding, or for unknown types.
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case int, int64:
+		return IntType
+	case float64:
+		return FloatType
+	case bool:
+		return BoolType
+	case time.Time:
+		return TimeType
+	case []string:
+		return "[]" + StringType
+	case []int:
+		return "[]" + IntType
+	case []float64:
+		return "[]" + FloatType
+	case []bool:
+		return "[]" + BoolType
+	case []time.Time:
+		return "[]" + TimeType
+	}
+	return ""
+}
+
+// fromType converts back a JSON decoded value to its recorded type.
+func fromType(typ string, v interface{}) interface{} {
+	if !strings.HasPrefix(typ, "[]") {
+		if c, err := convertTo(typ, v); err == nil {
+			return c
+		}
+		return v
+	}
+
+	typ = typ[2:]
+	values, _ := toList(v)
+	var converted []interface{}
+	for _, item := range values {
+		c, err := convertTo(typ, item)
+		if err != nil {
+			return v
+		}
+		converted = append(converted, c)
+	}
+	return typedList(typ, converted)
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pirmd/verify"
+)
+
+var (
+	testSchema = Schema{
+		"book": {
+			{Name: "Title", Required: true},
+			{Name: "Authors", Multi: true},
+			{Name: "PublishedDate", Type: TimeType},
+			{Name: "SeriePosition", Type: FloatType},
+			{Name: "Pages", Type: IntType},
+			{Name: "Read", Type: BoolType},
+			{Name: "Status", Allowed: []string{"toread", "reading", "read"}},
+		},
+		DefaultSchemaType: {
+			{Name: "Title", Required: true},
+		},
+	}
+)
+
+func TestSchemaValidate(t *testing.T) {
+	testCases := []struct {
+		in      map[string]interface{}
+		want    map[string]interface{}
+		isValid bool
+	}{
+		{
+			in: map[string]interface{}{
+				"Type":          "book/epub",
+				"Title":         "Les misérables",
+				"Authors":       "Victor Hugo",
+				"PublishedDate": "1862-04-03",
+				"SeriePosition": "1.5",
+				"Pages":         float64(1232),
+				"Read":          "true",
+				"Status":        "read",
+				"Comment":       42,
+			},
+			want: map[string]interface{}{
+				"Type":          "book/epub",
+				"Title":         "Les misérables",
+				"Authors":       []string{"Victor Hugo"},
+				"PublishedDate": time.Date(1862, 4, 3, 0, 0, 0, 0, time.UTC),
+				"SeriePosition": 1.5,
+				"Pages":         1232,
+				"Read":          true,
+				"Status":        "read",
+				"Comment":       42,
+			},
+			isValid: true,
+		},
+		{
+			in:      map[string]interface{}{"Type": "book/epub", "Title": []interface{}{"Les misérables"}, "Authors": []interface{}{"Victor Hugo", ""}},
+			want:    map[string]interface{}{"Type": "book/epub", "Title": "Les misérables", "Authors": []string{"Victor Hugo"}},
+			isValid: true,
+		},
+		{
+			in:      map[string]interface{}{"Type": "book/epub", "Title": "", "Pages": "many", "Status": "lost"},
+			want:    map[string]interface{}{"Type": "book/epub", "Title": "", "Pages": "many", "Status": "lost"},
+			isValid: false,
+		},
+		{
+			in:      map[string]interface{}{"Type": "movie", "Pages": "many"},
+			want:    map[string]interface{}{"Type": "movie", "Pages": "many"},
+			isValid: false,
+		},
+		{
+			in:      map[string]interface{}{"Type": "movie", "Title": "Le cinquième élément", "Pages": "many"},
+			want:    map[string]interface{}{"Type": "movie", "Title": "Le cinquième élément", "Pages": "many"},
+			isValid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		got := make(map[string]interface{})
+		for k, v := range tc.in {
+			got[k] = v
+		}
+
+		err := testSchema.Validate(got, "Type")
+		if tc.isValid && err != nil {
+			t.Errorf("Validation of %v failed: %v", tc.in, err)
+		}
+		if !tc.isValid && err == nil {
+			t.Errorf("Validation of %v should have failed", tc.in)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Validation of %v failed.\nWant: %#v\nGot : %#v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestSchemaInStore(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	s, err := New(tstDir.Root, UsingTypeField("Type"), UsingSchema(testSchema))
+	if err != nil {
+		t.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+	defer s.Close()
+
+	in := map[string]interface{}{"Type": "book/epub", "Title": "Les misérables", "Pages": "1232", "Read": true}
+	if _, err := s.Create("hugo.epub", in, verify.MockROFile("")); err != nil {
+		t.Fatalf("Fail to create record: %v", err)
+	}
+
+	r, err := s.Read("hugo.epub")
+	if err != nil {
+		t.Fatalf("Fail to read record: %v", err)
+	}
+
+	want := map[string]interface{}{"Type": "book/epub", "Title": "Les misérables", "Pages": 1232, "Read": true}
+	if got := r.Data(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Record is not stored as expected.\nWant: %#v\nGot : %#v", want, got)
+	}
+
+	r.Set("Pages", "many")
+	if err := s.Update("hugo.epub", r); err == nil {
+		t.Errorf("Updating a record that does not follow its schema should fail")
+	}
+
+	if _, err := New(tstDir.Root, UsingSchema(Schema{"book": {{Name: "Pages", Type: "number"}}})); err == nil {
+		t.Errorf("Creating a store with a schema of unknown type should fail")
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	stamp := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	val := newValue(map[string]interface{}{
+		"Title":     "Les misérables",
+		"Authors":   []string{"Victor Hugo"},
+		"Pages":     1232,
+		"Rating":    4.5,
+		"Read":      true,
+		"ReadAt":    stamp,
+		"Positions": []int{1, 2},
+		"Any":       []interface{}{"a", 1.0},
+	})
+	val.SetUserData(map[string]interface{}{
+		"Rating":    4,
+		"ReadDates": []time.Time{stamp},
+	})
+
+	buf, err := val.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Fail to marshal value: %v", err)
+	}
+
+	got := new(value)
+	if err := got.UnmarshalJSON(buf); err != nil {
+		t.Fatalf("Fail to unmarshal value: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Data, val.Data) {
+		t.Errorf("Value's types are not kept.\nWant: %#v\nGot : %#v", val.Data, got.Data)
+	}
+
+	if !reflect.DeepEqual(got.User, val.User) {
+		t.Errorf("User value's types are not kept.\nWant: %#v\nGot : %#v", val.User, got.User)
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// activityFmt is the layout of the period used to report records'
+	// creation activity.
+	activityFmt = "2006-01"
+)
+
+// Stats summarizes the content of a Store.
+type Stats struct {
+	// Records is the number of records in the Store.
+	Records int
+	// Size is the total size (in bytes) of the records' files.
+	Size int64
+	// Types details, by record's Type, the number of records and the size of
+	// their files.
+	Types map[string]*TypeStats
+	// Activity counts the records created by month (like "2021-03").
+	Activity map[string]int
+	// Values counts, for each field asked for, the records by field's value.
+	// Records with several values for a field count once for each value.
+	Values map[string]map[string]int
+	// Missing lists, for each field asked for, the records where the field
+	// is empty.
+	Missing map[string][]string
+}
+
+// TypeStats summarizes the records of a given Type.
+type TypeStats struct {
+	// Records is the number of records of the Type.
+	Records int
+	// Size is the total size (in bytes) of the records' files.
+	Size int64
+}
+
+// add accounts for a record of the given Type whose file is of the given
+// size.
+func (s *Stats) add(r *Record, size int64, typ string, valueFields, requiredFields []string) {
+	s.Records++
+	s.Size += size
+
+	if typ == "" {
+		typ = DefaultSchemaType
+	}
+	if _, exists := s.Types[typ]; !exists {
+		s.Types[typ] = new(TypeStats)
+	}
+	s.Types[typ].Records++
+	s.Types[typ].Size += size
+
+	if !r.value.CreatedAt.IsZero() {
+		s.Activity[r.value.CreatedAt.Format(activityFmt)]++
+	}
+
+	for _, field := range valueFields {
+		values, _ := toList(r.Get(field))
+		for _, v := range values {
+			if _, exists := s.Values[field]; !exists {
+				s.Values[field] = make(map[string]int)
+			}
+			s.Values[field][strings.TrimSpace(fmt.Sprint(v))]++
+		}
+	}
+
+	for _, field := range requiredFields {
+		if values, _ := toList(r.Get(field)); len(values) == 0 {
+			s.Missing[field] = append(s.Missing[field], r.Key())
+		}
+	}
+}
+
+// Stats computes statistics on the Store's records. Stats counts the records
+// by value of each of the valueFields and lists the records missing any of
+// the requiredFields.
+func (s *Store) Stats(valueFields, requiredFields []string) (*Stats, error) {
+	stats := &Stats{
+		Types:    make(map[string]*TypeStats),
+		Activity: make(map[string]int),
+		Values:   make(map[string]map[string]int),
+		Missing:  make(map[string][]string),
+	}
+
+	records, err := s.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range records {
+		size, err := s.fs.Size(r.Key())
+		if err != nil {
+			s.log.Printf("Fail to get size of '%s': %s", r.Key(), err)
+		}
+
+		typ, _ := r.Get(s.idx.Mapping.TypeField).(string)
+		stats.add(r, size, typ, valueFields, requiredFields)
+	}
+
+	return stats, nil
+}
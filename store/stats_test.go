@@ -0,0 +1,64 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+func TestStats(t *testing.T) {
+	tstDir, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Fail to create test folder: %v", err)
+	}
+	defer tstDir.Clean()
+
+	UseFrozenTimeStamps()
+	s, err := New(tstDir.Root, UsingTypeField("Type"))
+	if err != nil {
+		t.Fatalf("Fail to create testing Store: %s", err)
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("Fail to open testing Store: %s", err)
+	}
+	defer s.Close()
+
+	testData := map[string]map[string]interface{}{
+		"a.epub": {"Type": "book/epub", "Authors": []string{"Victor Hugo"}, "QALevel": "high"},
+		"b.epub": {"Type": "book/epub", "Authors": []string{"Victor Hugo", "Luc"}},
+		"c.cbz":  {"Type": "comic/cbz", "Title": "Tintin", "QALevel": "high"},
+		"d.txt":  {"Title": "Notes"},
+	}
+	for key, data := range testData {
+		if _, err := s.Create(key, data, verify.MockROFile("0123456789")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	got, err := s.Stats([]string{"Authors", "QALevel"}, []string{"Title"})
+	if err != nil {
+		t.Fatalf("Fail to compute statistics: %v", err)
+	}
+
+	want := &Stats{
+		Records: 4,
+		Size:    40,
+		Types: map[string]*TypeStats{
+			"book/epub": {Records: 2, Size: 20},
+			"comic/cbz": {Records: 1, Size: 10},
+			"media":     {Records: 1, Size: 10},
+		},
+		Activity: map[string]int{"1976-01": 4},
+		Values: map[string]map[string]int{
+			"Authors": {"Victor Hugo": 2, "Luc": 1},
+			"QALevel": {"high": 2},
+		},
+		Missing: map[string][]string{"Title": {"a.epub", "b.epub"}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Statistics mismatch:\nWant: %+v\nGot : %+v", want, got)
+	}
+}
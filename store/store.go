@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -14,8 +15,11 @@ import (
 )
 
 const (
-	dbPath  = ".store_database.db"
-	idxPath = ".store_index"
+	dbPath      = ".store_database.db"
+	idxPath     = ".store_index"
+	restorePath = ".store_restore"
+	coverPath   = ".store_covers"
+	socketPath  = ".store_socket"
 )
 
 var (
@@ -32,9 +36,15 @@ var (
 // Store represents the actual storing engine. It is made of a filesystem, a
 // key-value database and an indexer (bleve)
 type Store struct {
-	fs  *storefs
-	db  *storedb
-	idx *storeidx
+	fs     *storefs
+	covers *storefs
+	db     *storedb
+	idx    *storeidx
+	lock   *storelock
+
+	schema     Schema
+	userFields []string
+	readOnly   bool
 
 	log *log.Logger
 }
@@ -47,8 +57,10 @@ func New(path string, opts ...Option) (*Store, error) {
 	}
 
 	s.fs = newFS(path, s.isValidKey)
+	s.covers = newFS(filepath.Join(path, coverPath), func(string) bool { return true })
 	s.db = newDB(filepath.Join(path, dbPath))
 	s.idx = newIdx(filepath.Join(path, idxPath))
+	s.lock = newLock(filepath.Join(path, lockPath))
 
 	for _, opt := range opts {
 		if err := opt(s); err != nil {
@@ -59,33 +71,139 @@ func New(path string, opts ...Option) (*Store, error) {
 	return s, nil
 }
 
-// Open opens a Store for use
+// Open opens a Store for use. Open waits for other processes using the Store
+// to release it.
 func (s *Store) Open() error {
+	return s.open(false)
+}
+
+// OpenReadOnly opens a Store for read-only use. Any number of processes can
+// open the same Store in read-only mode, but Open waits for them to release
+// it. Attempts to modify a read-only Store fail with ErrStoreIsReadOnly.
+// A Store that does not exist yet is first created empty and a Store whose
+// database is in an older format is first migrated, both needing the same
+// exclusive access to the Store as Open.
+func (s *Store) OpenReadOnly() error {
+	if !s.exists() {
+		if err := s.prepare(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.open(true); err != errNeedsMigration {
+		return err
+	}
+
+	if err := s.prepare(); err != nil {
+		return err
+	}
+	return s.open(true)
+}
+
+// prepare opens then closes the Store in read/write mode so that it is
+// created or migrated if needed.
+func (s *Store) prepare() error {
+	if err := s.open(false); err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// exists reports whether the Store's database and index have already been
+// created.
+func (s *Store) exists() bool {
+	for _, path := range []string{s.db.path, s.idx.path} {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) open(readOnly bool) error {
 	s.log.Printf("Opening store")
 	if err := s.fs.Open(); err != nil {
 		return err
 	}
 
-	if err := s.db.Open(); err != nil {
+	if err := s.covers.Open(); err != nil {
+		return err
+	}
+
+	if err := s.lock.Lock(!readOnly); err != nil {
+		if e := s.fs.Close(); e != nil {
+			err = fmt.Errorf("%s\nClose store's filesystem failed: %s", err, e)
+		}
+		return err
+	}
+
+	if err := s.db.Open(readOnly); err != nil {
+		if e := s.fs.Close(); e != nil {
+			err = fmt.Errorf("%s\nClose store's filesystem failed: %s", err, e)
+		}
+		if e := s.lock.Unlock(); e != nil {
+			err = fmt.Errorf("%s\nRelease store's lock failed: %s", err, e)
+		}
+		return err
+	}
+
+	if err := s.checkVersion(readOnly); err != nil {
 		if e := s.fs.Close(); e != nil {
 			err = fmt.Errorf("%s\nClose store's filesystem failed: %s", err, e)
 		}
+		if e := s.db.Close(); e != nil {
+			err = fmt.Errorf("%s\nClose store's database failed: %s", err, e)
+		}
+		if e := s.lock.Unlock(); e != nil {
+			err = fmt.Errorf("%s\nRelease store's lock failed: %s", err, e)
+		}
 		return err
 	}
 
-	if err := s.idx.Open(); err != nil {
+	if err := s.idx.Open(readOnly); err != nil {
 		if e := s.fs.Close(); e != nil {
 			err = fmt.Errorf("%s\nClose store's filesystem failed: %s", err, e)
 		}
 		if e := s.db.Close(); e != nil {
 			err = fmt.Errorf("%s\nClose store's database failed: %s", err, e)
 		}
+		if e := s.lock.Unlock(); e != nil {
+			err = fmt.Errorf("%s\nRelease store's lock failed: %s", err, e)
+		}
+		return err
+	}
+
+	s.readOnly = readOnly
+
+	if err := s.reindexIfNeeded(); err != nil {
+		if e := s.Close(); e != nil {
+			err = fmt.Errorf("%s\nClose store failed: %s", err, e)
+		}
 		return err
 	}
 
 	return nil
 }
 
+// reindexIfNeeded rebuilds the index if the database asks for it (like after
+// a migration that modifies the way records are indexed).
+func (s *Store) reindexIfNeeded() error {
+	if s.readOnly {
+		return nil
+	}
+
+	needed, err := s.db.NeedsReindex()
+	if err != nil || !needed {
+		return err
+	}
+
+	if err := s.RebuildIndex(); err != nil {
+		return fmt.Errorf("fail to rebuild index: %s", err)
+	}
+
+	return s.db.SetReindexed()
+}
+
 // Close cleanly closes a Store
 func (s *Store) Close() error {
 	s.log.Printf("Closing store")
@@ -104,6 +222,10 @@ func (s *Store) Close() error {
 		err.Add(fmt.Errorf("fail to close store's index: %s", e))
 	}
 
+	if e := s.lock.Unlock(); e != nil {
+		err.Add(fmt.Errorf("fail to release store's lock: %s", e))
+	}
+
 	return err.Err()
 }
 
@@ -125,9 +247,19 @@ func (s *Store) Create(key string, data map[string]interface{}, f Reader) (*Reco
 // It does not replace existing Record (you have to use Update for that) but
 // will replace partially existing records resulting from an inconsistent state
 // of the Store (e.g. file exists but entry in db does not)
+// If the Record has no attached file, Insert expects the Record's file to be
+// already present in the Store's file-system.
 func (s *Store) Insert(r *Record) error {
 	s.log.Printf("Adding new record to store '%s'", r.Key())
 
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+
+	if err := s.validate(r); err != nil {
+		return err
+	}
+
 	exists, err := s.Exists(r.Key())
 	if err != nil {
 		return err
@@ -163,9 +295,42 @@ func (s *Store) Insert(r *Record) error {
 		return err
 	}
 
+	if err := s.putCover(r); err != nil {
+		err = fmt.Errorf("fail to import record's cover: %s", err)
+
+		if e := s.Delete(r.Key()); e != nil {
+			err = fmt.Errorf("%s\nFail to clean store after error: %s", err, e)
+		}
+
+		return err
+	}
+
 	return nil
 }
 
+// putCover imports Record's cover, if any, into the store.
+func (s *Store) putCover(r *Record) error {
+	if r.cover == nil {
+		return nil
+	}
+
+	s.log.Printf("Import record's cover into store's fs")
+	r.cover.Seek(0, io.SeekStart)
+	return s.covers.fs.Copy(r.cover, r.Key())
+}
+
+// SocketPath returns the path of the socket that a server keeping the Store
+// opened for other processes listens to.
+func (s *Store) SocketPath() string {
+	return filepath.Join(s.fs.path, socketPath)
+}
+
+// OpenCover opens the cover image of the Record corresponding to the given
+// key. It fails with an os.ErrNotExist error if Record has no cover.
+func (s *Store) OpenCover(key string) (io.ReadCloser, error) {
+	return s.covers.Get(key)
+}
+
 // Exists returns whether a Record exists for the given key. If the Store's state
 // is inconsistent for the given key (e.g. file is not present but and entry
 // exists in the database), Exists returns false
@@ -192,22 +357,36 @@ func (s *Store) Read(key string) (*Record, error) {
 		return nil, err
 	}
 
-	// Retrieving data from store's database relies on JSON unmarshaling to a
-	// map[string]interface{} type. It will miss detecting properly time and
-	// numeric values. At this point using information stored in the store's
-	// index can help at this kind of information is found there.
+	// Store's database remembers the type of non-text values but time
+	// values stored as text (like a date read from a media's metadata) are
+	// only recognized by the store's index. At this point using information
+	// stored in the store's index can help at this kind of information is
+	// found there.
 	ridx, err := s.idx.Get(key)
 	if err != nil {
 		return nil, err
 	}
 
 	for k, v := range ridx.Data() {
+		if _, isText := r.Get(k).(string); !isText {
+			continue
+		}
+
 		switch v := v.(type) {
 		case time.Time, float64:
 			r.SetIfExists(k, v)
 		}
 	}
 
+	// Records stored before their schema was defined or modified might not
+	// follow it. Values are converted as far as possible, invalid values
+	// being kept as is.
+	data := r.Data()
+	if err := s.schema.Validate(data, s.idx.Mapping.TypeField); err != nil {
+		s.log.Printf("Record '%s' does not follow its schema: %s", key, err)
+	}
+	r.value.Data = data
+
 	return r, nil
 }
 
@@ -362,6 +541,14 @@ func (s *Store) MatchFields(fuzziness int, fields ...string) (keys []string, val
 func (s *Store) Update(key string, r *Record) error {
 	s.log.Printf("Updating record '%s' to '%s'", key, r.Key())
 
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+
+	if err := s.validate(r); err != nil {
+		return err
+	}
+
 	if r.Key() != key {
 		exists, err := s.Exists(r.Key())
 		if err != nil {
@@ -398,9 +585,29 @@ func (s *Store) Update(key string, r *Record) error {
 		return err
 	}
 
+	if r.cover != nil {
+		if err := s.putCover(r); err != nil {
+			return fmt.Errorf("fail to update record's cover: %s", err)
+		}
+	}
+
 	if r.Key() != key {
 		errDel := new(util.MultiErrors)
 
+		if exists, _ := s.covers.Exists(key); exists {
+			s.log.Printf("Clean old entry '%s' in the store's covers", key)
+
+			var err error
+			if r.cover != nil {
+				err = s.covers.Delete(key)
+			} else {
+				err = s.covers.fs.Move(key, r.Key())
+			}
+			if err != nil {
+				errDel.Add(fmt.Errorf("fail to clean covers from old entry: %s", err))
+			}
+		}
+
 		s.log.Printf("Clean old entry '%s' in the store's db", key)
 		if err := s.db.Delete(key); err != nil {
 			errDel.Add(fmt.Errorf("fail to clean db from old entry: %s", err))
@@ -421,6 +628,10 @@ func (s *Store) Update(key string, r *Record) error {
 func (s *Store) Delete(key string) error {
 	s.log.Printf("Deleting record '%s' from store", key)
 
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+
 	errDel := new(util.MultiErrors)
 
 	s.log.Printf("Deleting record's file from store's fs")
@@ -428,6 +639,13 @@ func (s *Store) Delete(key string) error {
 		errDel.Add(fmt.Errorf("fail to remove old entry: %s", err))
 	}
 
+	s.log.Printf("Deleting record's cover from store's fs")
+	if exists, _ := s.covers.Exists(key); exists {
+		if err := s.covers.Delete(key); err != nil {
+			errDel.Add(fmt.Errorf("fail to remove old entry's cover: %s", err))
+		}
+	}
+
 	s.log.Printf("Deleting record from store's db")
 	if err := s.db.Delete(key); err != nil {
 		errDel.Add(fmt.Errorf("fail to clean db from old entry: %s", err))
@@ -445,6 +663,10 @@ func (s *Store) Delete(key string) error {
 // database content. It can be used for example to implement a new mapping
 // strategy or if things are really going bad
 func (s *Store) RebuildIndex() error {
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+
 	s.log.Printf("Create a new index from scratch")
 	if err := s.idx.Empty(); err != nil {
 		return err
@@ -469,6 +691,10 @@ func (s *Store) RebuildIndex() error {
 // RepairIndex check the consistency between the index and the database. Try to
 // repair them as far as possible.
 func (s *Store) RepairIndex() error {
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+
 	var errRepair util.MultiErrors
 
 	s.log.Printf("Verify that all store's database entries are in the store's index")
@@ -598,15 +824,94 @@ func (s *Store) IsDirty() bool {
 	return false
 }
 
+// SetAlias records that alias is a variant of the canonical value of the given
+// field, so that it is always resolved the same way.
+func (s *Store) SetAlias(field, alias, canonical string) error {
+	s.log.Printf("Record '%s' as an alias of '%s' for field '%s'", alias, canonical, field)
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+	return s.db.PutAlias(field, alias, canonical)
+}
+
+// DeleteAlias removes an alias of the given field.
+func (s *Store) DeleteAlias(field, alias string) error {
+	s.log.Printf("Delete alias '%s' for field '%s'", alias, field)
+	if s.readOnly {
+		return ErrStoreIsReadOnly
+	}
+	return s.db.DeleteAlias(field, alias)
+}
+
+// Alias returns the canonical value for the given field's alias. Alias returns
+// an empty string if no such alias is known.
+func (s *Store) Alias(field, alias string) (string, error) {
+	aliases, err := s.db.Aliases(field)
+	if err != nil {
+		return "", err
+	}
+	return aliases[alias], nil
+}
+
+// Aliases lists the known aliases of the given field and their canonical
+// value.
+func (s *Store) Aliases(field string) (map[string]string, error) {
+	return s.db.Aliases(field)
+}
+
 // Fields list the fields that can be used when searching the collection.
 func (s *Store) Fields() ([]string, error) {
 	return s.idx.Fields()
 }
 
+// Validate checks that a record's data follow the Store's schema and
+// converts them to the types expected by the schema.
+func (s *Store) Validate(data map[string]interface{}) error {
+	return s.schema.Validate(data, s.idx.Mapping.TypeField)
+}
+
+// IsListField reports whether the schema that applies to a record's data
+// expects field to hold a list of values.
+func (s *Store) IsListField(data map[string]interface{}, field string) bool {
+	typ, _ := data[s.idx.Mapping.TypeField].(string)
+	for _, f := range s.schema.For(typ) {
+		if f.Name == field {
+			return f.Multi
+		}
+	}
+	return false
+}
+
+// IsUserField reports whether field holds the end-user's personal
+// information (see UsingUserFields).
+func (s *Store) IsUserField(field string) bool {
+	for _, f := range s.userFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that a record follows the Store's schema, converting its
+// values to the expected types.
+func (s *Store) validate(r *Record) error {
+	data := r.Data()
+	if err := s.Validate(data); err != nil {
+		return fmt.Errorf("record '%s' does not follow its schema: %s", r.Key(), err)
+	}
+	r.value.Data = data
+	return nil
+}
+
 func (s *Store) isValidKey(key string) bool {
 	cleanKey := filepath.ToSlash(filepath.Clean("/" + key))[1:]
 
 	return cleanKey != "/" &&
 		!strings.HasPrefix(cleanKey, dbPath) &&
-		!strings.HasPrefix(cleanKey, idxPath)
+		!strings.HasPrefix(cleanKey, idxPath) &&
+		!strings.HasPrefix(cleanKey, restorePath) &&
+		!strings.HasPrefix(cleanKey, coverPath) &&
+		!strings.HasPrefix(cleanKey, lockPath) &&
+		!strings.HasPrefix(cleanKey, socketPath)
 }
@@ -2,8 +2,10 @@ package store
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/blevesearch/bleve/analysis/lang/fr"
 	"github.com/pirmd/verify"
@@ -188,6 +190,108 @@ func TestUpdate(t *testing.T) {
 	})
 }
 
+func TestUserData(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	keys := populateStore(t, s)
+
+	readAt := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	user := map[string]interface{}{
+		"Tags":      []string{"classic"},
+		"Rating":    4,
+		"ReadDates": []time.Time{readAt},
+	}
+
+	r, err := s.Read(keys[0])
+	if err != nil {
+		t.Fatalf("Fail to read '%s': %v", keys[0], err)
+	}
+	r.SetUserData(user)
+	if err := s.Update(keys[0], r); err != nil {
+		t.Fatalf("Fail to update '%s': %v", keys[0], err)
+	}
+
+	t.Run("Can keep user data when replacing data", func(t *testing.T) {
+		r, err := s.Read(keys[0])
+		if err != nil {
+			t.Fatalf("Fail to read '%s': %v", keys[0], err)
+		}
+
+		r.SetData(map[string]interface{}{"Title": "Refreshed"})
+		if err := s.Update(keys[0], r); err != nil {
+			t.Fatalf("Fail to update '%s': %v", keys[0], err)
+		}
+
+		r, err = s.Read(keys[0])
+		if err != nil {
+			t.Fatalf("Fail to read '%s': %v", keys[0], err)
+		}
+
+		if failure := verify.Equal(r.UserData(), user); failure != nil {
+			t.Errorf("User data are not kept:\n%v", failure)
+		}
+
+		if _, exists := r.Data()["Tags"]; exists {
+			t.Errorf("User data should not be part of record's data")
+		}
+	})
+
+	t.Run("Can search user data", func(t *testing.T) {
+		out, err := s.ReadQuery("Tags:classic")
+		if err != nil {
+			t.Fatalf("Search for user data failed: %s", err)
+		}
+
+		if failure := verify.EqualSliceWithoutOrder(out.Key(), []string{keys[0]}); failure != nil {
+			t.Errorf("Search for user data failed:\n%v", failure)
+		}
+	})
+}
+
+func TestCover(t *testing.T) {
+	s, cleanFn := setupStore(t)
+	defer cleanFn()
+
+	r := NewRecord("a.epub", map[string]interface{}{"Title": "Les misérables"})
+	r.SetFile(verify.MockROFile("0123456789"))
+	r.SetCover(verify.MockROFile("cover"))
+	if err := s.Insert(r); err != nil {
+		t.Fatalf("Fail to insert record: %v", err)
+	}
+
+	r.SetKey("Victor Hugo/a.epub")
+	r.SetFile(nil)
+	r.SetCover(nil)
+	if err := s.Update("a.epub", r); err != nil {
+		t.Fatalf("Fail to update record: %v", err)
+	}
+
+	f, err := s.OpenCover("Victor Hugo/a.epub")
+	if err != nil {
+		t.Fatalf("Fail to open cover: %v", err)
+	}
+	cover, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Fail to read cover: %v", err)
+	}
+	if string(cover) != "cover" {
+		t.Errorf("Fail to import cover: got %q", cover)
+	}
+
+	if orphans, err := s.CheckOrphans(); err != nil || len(orphans) != 0 {
+		t.Errorf("Covers should not be reported as orphans: %v (%v)", orphans, err)
+	}
+
+	if err := s.Delete("Victor Hugo/a.epub"); err != nil {
+		t.Fatalf("Fail to delete record: %v", err)
+	}
+	if _, err := s.OpenCover("Victor Hugo/a.epub"); err == nil {
+		t.Errorf("Cover should be deleted together with its record")
+	}
+}
+
 func TestQuery(t *testing.T) {
 	s, cleanFn := setupStore(t)
 	defer cleanFn()
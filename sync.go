@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pirmd/gostore/modules"
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+const (
+	// syncManifest is the name of the file, stored on the device, that keeps
+	// track of the files synchronized by gostore.
+	syncManifest = ".gostore-sync.json"
+)
+
+// device represents a target (like an e-reader) the collection can be
+// synchronized with.
+type device struct {
+	path   string
+	query  string
+	delete bool
+	namer  modules.Module
+}
+
+func newDevice(cfg *deviceConfig, env *modules.Environment) (*device, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("no path defined")
+	}
+
+	dev := &device{
+		path:   cfg.Path,
+		query:  cfg.Query,
+		delete: cfg.Delete,
+	}
+
+	if cfg.Organizer != nil {
+		var err error
+		if dev.namer, err = modules.New("organizer", cfg.Organizer, env); err != nil {
+			return nil, err
+		}
+	}
+
+	return dev, nil
+}
+
+// name returns the name of a record's file once on the device.
+func (dev *device) name(r *store.Record) (string, error) {
+	if dev.namer == nil {
+		return r.Key(), nil
+	}
+
+	renamed := store.NewRecord(r.Key(), r.Data())
+	if err := dev.namer.ProcessRecord(renamed); err != nil {
+		return "", err
+	}
+	return renamed.Key(), nil
+}
+
+// pathOf returns the location on the device of a synchronized file. It fails
+// if the file's name points outside of the device's folder or to the
+// device's manifest.
+func (dev *device) pathOf(name string) (string, error) {
+	path := filepath.Join(dev.path, filepath.FromSlash(name))
+
+	rel, err := filepath.Rel(dev.path, path)
+	if err != nil {
+		return "", err
+	}
+
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || rel == syncManifest {
+		return "", fmt.Errorf("'%s' is not a valid name on the device", name)
+	}
+
+	return path, nil
+}
+
+// deviceManifest describes the files synchronized on a device.
+type deviceManifest struct {
+	// SyncedAt is the time stamp of the last synchronization.
+	SyncedAt time.Time
+	// Files lists, by their name on the device, the synchronized files.
+	Files map[string]*syncedFile
+}
+
+// syncedFile describes a file synchronized on a device.
+type syncedFile struct {
+	// Key is the key of the corresponding record in the collection.
+	Key string
+	// Hash is the sha256 checksum of the file.
+	Hash string
+}
+
+func readDeviceManifest(root string) (*deviceManifest, error) {
+	m := &deviceManifest{Files: make(map[string]*syncedFile)}
+
+	buf, err := ioutil.ReadFile(filepath.Join(root, syncManifest))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, fmt.Errorf("cannot read device's manifest: %s", err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]*syncedFile)
+	}
+
+	return m, nil
+}
+
+func (m *deviceManifest) write(root string) error {
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(root, syncManifest), buf, 0666)
+}
+
+// Sync mirrors the records selected by a device's query to the device.
+// Only new or modified files (based on their checksum) are copied. Files
+// previously synchronized but that are no more selected are deleted from the
+// device if the device is configured so or if deleteUnselected is set.
+// Files of the device that were not synchronized by gostore are left
+// untouched.
+// A manifest is kept on the device to track synchronized files from one run
+// to the other.
+func (gs *Gostore) Sync(name string, deleteUnselected bool) error {
+	dev, exists := gs.devices[name]
+	if !exists {
+		return fmt.Errorf("syncing '%s' failed: unknown device", name)
+	}
+
+	gs.log.Printf("Syncing '%s' to '%s'", name, dev.path)
+
+	var records store.Records
+	var err error
+	if dev.query == "" {
+		records, err = gs.store.ReadAll()
+	} else {
+		records, err = gs.store.ReadQuery(dev.query)
+	}
+	if err != nil {
+		return fmt.Errorf("syncing '%s' failed: %s", name, err)
+	}
+
+	if _, err := os.Stat(dev.path); err != nil {
+		return fmt.Errorf("syncing '%s' failed: %s", name, err)
+	}
+
+	oldManifest, err := readDeviceManifest(dev.path)
+	if err != nil {
+		return fmt.Errorf("syncing '%s' failed: %s", name, err)
+	}
+
+	newManifest := &deviceManifest{
+		SyncedAt: time.Now(),
+		Files:    make(map[string]*syncedFile),
+	}
+
+	var synced store.Records
+	var syncErr util.MultiErrors
+	for _, r := range records {
+		dst, err := dev.name(r)
+		if err != nil {
+			syncErr.Add(fmt.Errorf("syncing '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		path, err := dev.pathOf(dst)
+		if err != nil {
+			syncErr.Add(fmt.Errorf("syncing '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		if f, exists := newManifest.Files[dst]; exists {
+			syncErr.Add(fmt.Errorf("syncing '%s' failed: '%s' already synced as '%s'", r.Key(), f.Key, dst))
+			continue
+		}
+
+		hash, err := gs.hash(r)
+		if err != nil {
+			syncErr.Add(fmt.Errorf("syncing '%s' failed: %s", r.Key(), err))
+			continue
+		}
+
+		newManifest.Files[dst] = &syncedFile{Key: r.Key(), Hash: hash}
+
+		if old, exists := oldManifest.Files[dst]; exists && old.Hash == hash {
+			if _, err := os.Stat(path); err == nil {
+				gs.log.Printf("'%s' is already synced", r.Key())
+				continue
+			}
+		}
+
+		gs.log.Printf("Syncing '%s' as '%s'", r.Key(), dst)
+		if !gs.pretend {
+			if err := gs.exportAs(r, path); err != nil {
+				delete(newManifest.Files, dst)
+				syncErr.Add(fmt.Errorf("syncing '%s' failed: %s", r.Key(), err))
+				continue
+			}
+		}
+
+		synced = append(synced, r)
+	}
+
+	var unselected []string
+	for dst, f := range oldManifest.Files {
+		if _, exists := newManifest.Files[dst]; exists {
+			continue
+		}
+
+		// entries pointing outside of the device are dropped from the manifest
+		// without touching them
+		path, err := dev.pathOf(dst)
+		if err != nil {
+			syncErr.Add(fmt.Errorf("deleting '%s' from device failed: %s", dst, err))
+			continue
+		}
+
+		if !(dev.delete || deleteUnselected) {
+			newManifest.Files[dst] = f
+			continue
+		}
+
+		gs.log.Printf("Deleting '%s' from device", dst)
+		if !gs.pretend {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				newManifest.Files[dst] = f
+				syncErr.Add(fmt.Errorf("deleting '%s' from device failed: %s", dst, err))
+				continue
+			}
+		}
+		unselected = append(unselected, dst)
+	}
+
+	if !gs.pretend {
+		if err := newManifest.write(dev.path); err != nil {
+			syncErr.Add(fmt.Errorf("syncing '%s' failed: cannot write device's manifest: %s", name, err))
+		}
+	}
+
+	if len(synced) != 0 {
+		gs.ui.PrettyPrint(synced.Flatted()...)
+	}
+
+	if len(unselected) != 0 {
+		sort.Strings(unselected)
+		gs.ui.Printf("Deleted from device:\n%s\n", strings.Join(unselected, "\n"))
+	}
+
+	return syncErr.Err()
+}
+
+// hash computes the sha256 checksum of a record's file.
+func (gs *Gostore) hash(r *store.Record) (string, error) {
+	f, err := gs.store.OpenRecord(r)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pirmd/verify"
+)
+
+func TestSync(t *testing.T) {
+	device, err := verify.NewTestFolder(t.Name() + "_device")
+	if err != nil {
+		t.Fatalf("Failed to create device's folder: %v", err)
+	}
+	defer device.Clean()
+
+	if err := device.Populate([]string{"notes.txt"}); err != nil {
+		t.Fatalf("Failed to populate device's folder: %v", err)
+	}
+
+	cfg := newConfig()
+	rawcfg := `
+devices:
+    reader:
+        path: ` + device.Root + `
+        organizer:
+            namingschemes:
+                media: 'ebooks/{{ .Name }}'
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testCases := []string{
+		filepath.Join(testdataPath, "pg11-images.epub"),
+		filepath.Join(testdataPath, "pg1661-images.epub"),
+	}
+	if err := gs.Import(testCases); err != nil {
+		t.Fatalf("Fail to import epubs '%s': %v", testCases, err)
+	}
+
+	if err := gs.Sync("reader", false); err != nil {
+		t.Fatalf("Fail to sync device: %v", err)
+	}
+
+	for _, f := range []string{"notes.txt", syncManifest, "ebooks/pg11-images.epub", "ebooks/pg1661-images.epub"} {
+		if err := device.ShouldHaveFile(f); err != nil {
+			t.Errorf("Fail to sync device: %v", err)
+		}
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	synced := device.Fullpath("ebooks/pg1661-images.epub")
+	if err := os.Chtimes(synced, past, past); err != nil {
+		t.Fatalf("Fail to modify synced file's time stamp: %v", err)
+	}
+
+	if err := gs.Delete([]string{"pg11-images.epub"}); err != nil {
+		t.Fatalf("Fail to delete record: %v", err)
+	}
+
+	if err := gs.Sync("reader", true); err != nil {
+		t.Fatalf("Fail to sync device: %v", err)
+	}
+
+	if err := device.ShouldNotHaveFile("ebooks/pg11-images.epub"); err != nil {
+		t.Errorf("Fail to delete unselected files from device: %v", err)
+	}
+
+	for _, f := range []string{"notes.txt", "ebooks/pg1661-images.epub"} {
+		if err := device.ShouldHaveFile(f); err != nil {
+			t.Errorf("Fail to sync device: %v", err)
+		}
+	}
+
+	fi, err := os.Stat(synced)
+	if err != nil {
+		t.Fatalf("Fail to read synced file: %v", err)
+	}
+	if !fi.ModTime().Equal(past) {
+		t.Errorf("Unchanged file has been copied again to the device")
+	}
+}
+
+func TestSyncWithInvalidManifest(t *testing.T) {
+	root, err := verify.NewTestFolder(t.Name())
+	if err != nil {
+		t.Fatalf("Failed to create test folder: %v", err)
+	}
+	defer root.Clean()
+
+	if err := root.Populate([]string{"outside.txt", "device/notes.txt"}); err != nil {
+		t.Fatalf("Failed to populate test folder: %v", err)
+	}
+
+	manifest := `{"Files": {"../outside.txt": {"Key": "outside.txt"}}}`
+	if err := ioutil.WriteFile(root.Fullpath(filepath.Join("device", syncManifest)), []byte(manifest), 0666); err != nil {
+		t.Fatalf("Failed to write device's manifest: %v", err)
+	}
+
+	cfg := newConfig()
+	rawcfg := `
+devices:
+    reader:
+        path: ` + root.Fullpath("device") + `
+`
+	if err := yaml.Unmarshal([]byte(rawcfg), cfg); err != nil {
+		t.Fatalf("Fail to read config: %v", err)
+	}
+
+	gs := newTestGostore(t, cfg)
+	defer gs.Close()
+
+	if err := gs.Sync("reader", true); err == nil {
+		t.Errorf("Sync with an invalid manifest's entry should fail")
+	}
+
+	for _, f := range []string{"outside.txt", "device/notes.txt"} {
+		if err := root.ShouldHaveFile(f); err != nil {
+			t.Errorf("Sync modified files it should not: %v", err)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -26,8 +29,10 @@ var (
 
 // CLI is a user interface built for the command-line.
 type CLI struct {
+	auto   bool
 	editor string
 	merger string
+	format editFormat
 
 	style    style.Styler
 	printers *template.Template
@@ -36,6 +41,7 @@ type CLI struct {
 // New creates CLI User Interface with default values
 func New() *CLI {
 	ui := &CLI{
+		format:   jsonFormat{},
 		style:    style.NewColorterm(),
 		printers: template.New("pprinter"),
 	}
@@ -70,7 +76,7 @@ func (ui *CLI) PrettyDiff(mediaL, mediaR map[string]interface{}) {
 // Edit fires-up a new editor to modify a map
 func (ui *CLI) Edit(m map[string]interface{}) (map[string]interface{}, error) {
 	if len(ui.editor) > 0 {
-		return editAsJSON(m, ui.editor)
+		return editAs(m, ui.editor, ui.format)
 	}
 
 	return m, nil
@@ -79,7 +85,7 @@ func (ui *CLI) Edit(m map[string]interface{}) (map[string]interface{}, error) {
 // MultiEdit fires-up a new editor to modify a set of maps
 func (ui *CLI) MultiEdit(m []map[string]interface{}) ([]map[string]interface{}, error) {
 	if len(ui.editor) > 0 {
-		return multiEditAsJSON(m, ui.editor)
+		return multiEditAs(m, ui.editor, ui.format)
 	}
 
 	return m, nil
@@ -88,12 +94,114 @@ func (ui *CLI) MultiEdit(m []map[string]interface{}) ([]map[string]interface{},
 // Merge fires-up a new editor to merge m and n
 func (ui *CLI) Merge(m, n map[string]interface{}) (map[string]interface{}, error) {
 	if len(ui.merger) > 0 {
-		return mergeAsJSON(m, n, ui.merger)
+		return mergeAs(m, n, ui.merger, ui.editor, ui.format)
 	}
 
 	return mergeMaps(m, n)
 }
 
+// Confirm asks the user to confirm an action. Actions are always confirmed
+// if the CLI is in automatic mode.
+func (ui *CLI) Confirm(msg string) bool {
+	if ui.auto {
+		return true
+	}
+
+	fmt.Printf("%s [y/N] ", msg)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// Choose asks the user to choose among a set of options, either by typing
+// an option or its first letter. The first option is chosen by default or if
+// the CLI is in automatic mode.
+func (ui *CLI) Choose(msg string, options ...string) string {
+	if len(options) == 0 {
+		return ""
+	}
+
+	if ui.auto {
+		return options[0]
+	}
+
+	fmt.Printf("%s [%s] ", msg, strings.Join(options, "/"))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return options[0]
+	}
+
+	return chooseOption(answer, options)
+}
+
+func chooseOption(answer string, options []string) string {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return options[0]
+	}
+
+	for _, o := range options {
+		if answer == strings.ToLower(o) {
+			return o
+		}
+	}
+
+	for _, o := range options {
+		if strings.HasPrefix(strings.ToLower(o), answer) {
+			return o
+		}
+	}
+
+	return options[0]
+}
+
+// Pick asks the user to pick one of the candidates by typing its number. The
+// first candidate is picked by default or if the CLI is in automatic mode.
+// Pick returns -1 if the user does not pick any candidate.
+func (ui *CLI) Pick(msg string, candidates ...map[string]interface{}) int {
+	if len(candidates) == 0 {
+		return -1
+	}
+
+	if ui.auto {
+		return 0
+	}
+
+	for i, c := range candidates {
+		fmt.Printf("[%d]\n%s\n", i+1, ui.print(c))
+	}
+
+	fmt.Printf("%s [1-%d, 0 to skip] ", msg, len(candidates))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return 0
+	}
+
+	return pickOption(answer, len(candidates))
+}
+
+func pickOption(answer string, n int) int {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return 0
+	}
+
+	i, err := strconv.Atoi(answer)
+	if err != nil || i < 0 || i > n {
+		return 0
+	}
+
+	return i - 1
+}
+
 func (ui *CLI) print(medias ...map[string]interface{}) string {
 	t := ui.printerFor(medias...)
 	if t == nil {
@@ -10,12 +10,20 @@ type Config struct {
 	// editing or merging records' attributes
 	Auto bool
 
+	// Fullscreen is the flag that switches to a full-screen terminal User
+	// Interface to browse, edit or merge records
+	Fullscreen bool
+
 	// EditorCmd contains the command line to open a text editor
 	EditorCmd string
 
 	// MergerCmd contains the command line to open a text merger
 	MergerCmd string
 
+	// EditFormat selects the text format (json, yaml or toml) used to edit
+	// or merge records. Default to json.
+	EditFormat string
+
 	// OutputFormat selects the style of output to format records when printing
 	// them.
 	OutputFormat string
@@ -28,6 +36,7 @@ type Config struct {
 // NewConfig create a new Config
 func NewConfig() *Config {
 	return &Config{
+		EditFormat:   "json",
 		OutputFormat: "name",
 		Formatters: map[string]map[string]string{
 			"name": {
@@ -63,6 +72,15 @@ func NewFromConfig(cfg *Config) (*CLI, error) {
 		}
 	}
 
+	if cfg.EditFormat != "" {
+		format, exists := editFormats[cfg.EditFormat]
+		if !exists {
+			return nil, fmt.Errorf("CLI config: '%s': unknown edit format", cfg.EditFormat)
+		}
+		ui.format = format
+	}
+
+	ui.auto = cfg.Auto
 	if !cfg.Auto {
 		ui.editor = cfg.EditorCmd
 		ui.merger = cfg.MergerCmd
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -9,25 +8,38 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/kballard/go-shellquote"
 )
 
-// editAsJSON fires-up an editor to modify the provided map using its JSON
-// form.
-func editAsJSON(m map[string]interface{}, cmdEditor string) (map[string]interface{}, error) {
-	j, err := json.MarshalIndent(m, "", "  ")
-	if err != nil {
-		return nil, err
-	}
+const (
+	// errorComment is the prefix of the comments reporting parsing errors
+	// when re-opening the editor.
+	errorComment = "# ERROR: "
+)
 
-	buf, err := edit(j, cmdEditor, ".json")
+var (
+	// reErrorLine matches the line number reported by YAML, TOML or JSON
+	// (once completed by errorLine) parsing errors.
+	reErrorLine = regexp.MustCompile(`line (\d+)`)
+)
+
+// editAs fires-up an editor to modify the provided map using the given
+// format.
+func editAs(m map[string]interface{}, cmdEditor string, f editFormat) (map[string]interface{}, error) {
+	data, err := f.marshal(m, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var edited map[string]interface{}
-	err = json.Unmarshal(buf, &edited)
+	err = editText(data, cmdEditor, f.ext(), func(buf []byte) (err error) {
+		edited, err = f.unmarshal(buf)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -35,21 +47,19 @@ func editAsJSON(m map[string]interface{}, cmdEditor string) (map[string]interfac
 	return edited, nil
 }
 
-// multiEditAsJSON fires-up an editor to modify the provided maps using their
-// JSON form.
-func multiEditAsJSON(m []map[string]interface{}, cmdEditor string) ([]map[string]interface{}, error) {
-	j, err := json.MarshalIndent(m, "", "  ")
-	if err != nil {
-		return nil, err
-	}
-
-	buf, err := edit(j, cmdEditor, ".json")
+// multiEditAs fires-up an editor to modify the provided maps using the
+// given format.
+func multiEditAs(m []map[string]interface{}, cmdEditor string, f editFormat) ([]map[string]interface{}, error) {
+	data, err := f.marshalList(m)
 	if err != nil {
 		return nil, err
 	}
 
 	var edited []map[string]interface{}
-	err = json.Unmarshal(buf, &edited)
+	err = editText(data, cmdEditor, f.ext(), func(buf []byte) (err error) {
+		edited, err = f.unmarshalList(buf)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -57,26 +67,40 @@ func multiEditAsJSON(m []map[string]interface{}, cmdEditor string) ([]map[string
 	return edited, nil
 }
 
-// mergeAsJSON fires-up an editor to merge the provided maps using their JSON
-// form.
-func mergeAsJSON(left, right map[string]interface{}, cmdMerger string) (map[string]interface{}, error) {
-	l, err := json.MarshalIndent(left, "", "  ")
+// mergeAs fires-up a merger to merge the provided maps using the given
+// format. Comments of left's fields show right's values when they differ.
+// If the merged text cannot be read back, it is opened in the editor to be
+// fixed.
+func mergeAs(left, right map[string]interface{}, cmdMerger string, cmdEditor string, f editFormat) (map[string]interface{}, error) {
+	l, err := f.marshal(left, right)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err := json.MarshalIndent(right, "", "  ")
+	r, err := f.marshal(right, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	bufL, _, err := merge(l, r, cmdMerger, ".json")
+	bufL, _, err := merge(l, r, cmdMerger, f.ext())
 	if err != nil {
 		return nil, err
 	}
 
-	var merged map[string]interface{}
-	err = json.Unmarshal(bufL, &merged)
+	merged, err := f.unmarshal(bufL)
+	if err == nil {
+		return merged, nil
+	}
+
+	if len(cmdEditor) == 0 {
+		return nil, err
+	}
+
+	data, line := annotateError(bufL, err)
+	err = editTextAt(data, line, cmdEditor, f.ext(), func(buf []byte) (err error) {
+		merged, err = f.unmarshal(buf)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -84,8 +108,88 @@ func mergeAsJSON(left, right map[string]interface{}, cmdMerger string) (map[stri
 	return merged, nil
 }
 
+// editText spans an editor to modify data until decode succeeds in reading
+// the edited text. If decode fails, the editor is re-opened at the faulty
+// line with the error reported as a comment. The edition is aborted if the
+// user leaves the faulty text unchanged.
+func editText(data []byte, cmdEditor string, filetype string, decode func([]byte) error) error {
+	return editTextAt(data, 1, cmdEditor, filetype, decode)
+}
+
+func editTextAt(data []byte, line int, cmdEditor string, filetype string, decode func([]byte) error) error {
+	for {
+		buf, err := edit(data, cmdEditor, filetype, line)
+		if err != nil {
+			return err
+		}
+
+		unchanged := bytes.Equal(buf, data)
+		buf = stripErrorComments(buf)
+
+		err = decode(buf)
+		if err == nil || unchanged || len(cmdEditor) == 0 {
+			return err
+		}
+
+		data, line = annotateError(buf, err)
+	}
+}
+
+// annotateError reports err as comments at the beginning of data. It
+// returns the annotated data and the line where the error occurred.
+func annotateError(data []byte, err error) ([]byte, int) {
+	var annotated []byte
+	var n int
+	for _, msg := range strings.Split(err.Error(), "\n") {
+		annotated = append(annotated, errorComment+strings.TrimSpace(msg)+"\n"...)
+		n++
+	}
+	annotated = append(annotated, errorComment+"fix the error or leave the file unchanged to abort\n"...)
+	n++
+
+	return append(annotated, data...), errorLine(data, err) + n
+}
+
+// stripErrorComments removes the comments added by annotateError.
+func stripErrorComments(data []byte) []byte {
+	for bytes.HasPrefix(data, []byte(errorComment)) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return nil
+		}
+		data = data[i+1:]
+	}
+	return data
+}
+
+// errorLine returns the line of data where the parsing error err occurred,
+// or 1 if it cannot be found.
+func errorLine(data []byte, err error) int {
+	var offset int64 = -1
+	switch err := err.(type) {
+	case *json.SyntaxError:
+		offset = err.Offset
+	case *json.UnmarshalTypeError:
+		offset = err.Offset
+	}
+
+	if offset >= 0 && offset <= int64(len(data)) {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	if m := reErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		if line, err := strconv.Atoi(m[1]); err == nil && line > 0 {
+			return line
+		}
+	}
+
+	return 1
+}
+
 // edit spans an editor to modify the input text and feedbacks the result.
-func edit(data []byte, cmdEditor string, filetype string) ([]byte, error) {
+// If cmdEditor refers to a second argument (using '%[2]d'), the editor is
+// given the line to open the text at.
+func edit(data []byte, cmdEditor string, filetype string, line int) ([]byte, error) {
 	if len(cmdEditor) == 0 {
 		return data, nil
 	}
@@ -95,7 +199,12 @@ func edit(data []byte, cmdEditor string, filetype string) ([]byte, error) {
 		return nil, err
 	}
 
-	cmdArgs, err := parseCmd(cmdEditor, tmpfile)
+	args := []interface{}{tmpfile}
+	if strings.Contains(cmdEditor, "%[2]") {
+		args = append(args, line)
+	}
+
+	cmdArgs, err := parseCmd(cmdEditor, args...)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse editor command line '%s': %s", cmdEditor, err)
 	}
@@ -183,29 +292,11 @@ func data2file(data []byte, filetype string) (string, error) {
 }
 
 // file2data reads back the content of a temp file and deletes it whatever
-// happens. file2data strips comments line.
+// happens.
 func file2data(name string) ([]byte, error) {
 	defer func() { os.Remove(name) }()
 
-	f, err := os.Open(name)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	data := []byte{}
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if !bytes.HasPrefix(line, []byte{'#'}) {
-			data = append(data, line...)
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return ioutil.ReadFile(name)
 }
 
 // parseCmd parses a command-line
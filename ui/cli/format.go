@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// blockTextWidth is the length above which texts are edited as YAML
+	// block scalars.
+	blockTextWidth = 80
+
+	// hintValueWidth is the maximum length of previous values displayed in
+	// fields' comments.
+	hintValueWidth = 60
+
+	// tomlListTable is the name of the TOML array of tables used to edit
+	// several maps at once.
+	tomlListTable = "record"
+)
+
+// editFormats lists the text formats available to edit maps.
+var editFormats = map[string]editFormat{
+	"json": jsonFormat{},
+	"yaml": yamlFormat{},
+	"toml": tomlFormat{},
+}
+
+// editFormat is a text format to edit maps in.
+type editFormat interface {
+	// ext returns the file extension of the format (like ".json").
+	ext() string
+
+	// marshal serializes a map. If the format supports comments, each field
+	// is commented with the type of its value and with its value from prev
+	// if it differs.
+	marshal(m map[string]interface{}, prev map[string]interface{}) ([]byte, error)
+
+	// marshalList serializes a list of maps.
+	marshalList(m []map[string]interface{}) ([]byte, error)
+
+	// unmarshal reads back a serialized map.
+	unmarshal(data []byte) (map[string]interface{}, error)
+
+	// unmarshalList reads back a serialized list of maps.
+	unmarshalList(data []byte) ([]map[string]interface{}, error)
+}
+
+// jsonFormat edits maps as JSON. Lines starting with '#' are considered as
+// comments.
+type jsonFormat struct{}
+
+func (jsonFormat) ext() string {
+	return ".json"
+}
+
+func (jsonFormat) marshal(m map[string]interface{}, prev map[string]interface{}) ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func (jsonFormat) marshalList(m []map[string]interface{}) ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func (jsonFormat) unmarshal(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(blankComments(data), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (jsonFormat) unmarshalList(data []byte) ([]map[string]interface{}, error) {
+	var m []map[string]interface{}
+	if err := json.Unmarshal(blankComments(data), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// yamlFormat edits maps as YAML, long texts being presented as block
+// scalars. Lists of maps are edited as a stream of YAML documents.
+type yamlFormat struct{}
+
+func (yamlFormat) ext() string {
+	return ".yaml"
+}
+
+func (yamlFormat) marshal(m map[string]interface{}, prev map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	for _, k := range sortedKeys(m) {
+		fmt.Fprintf(buf, "# %s\n", fieldHint(k, m[k], prev))
+
+		if s, ok := m[k].(string); ok && isBlockText(s) {
+			key, err := yaml.Marshal(k)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(buf, "%s: |-\n", bytes.TrimSpace(key))
+			for _, line := range strings.Split(s, "\n") {
+				if line == "" {
+					buf.WriteString("\n")
+					continue
+				}
+				fmt.Fprintf(buf, "  %s\n", line)
+			}
+			continue
+		}
+
+		field, err := yaml.Marshal(map[string]interface{}{k: m[k]})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(field)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (f yamlFormat) marshalList(m []map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	for _, item := range m {
+		doc, err := f.marshal(item, nil)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(doc)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (yamlFormat) unmarshal(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return fromYAML(m), nil
+}
+
+func (yamlFormat) unmarshalList(data []byte) ([]map[string]interface{}, error) {
+	var l []map[string]interface{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			if err == io.EOF {
+				return l, nil
+			}
+			return nil, err
+		}
+		l = append(l, fromYAML(m))
+	}
+}
+
+// fromYAML converts nested maps decoded from YAML, whose keys are of any
+// type, to maps of strings, so that they can be handled as if decoded from
+// JSON.
+func fromYAML(m map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		converted[k] = fromYAMLValue(v)
+	}
+	return converted
+}
+
+func fromYAMLValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = fromYAMLValue(val)
+		}
+		return m
+
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = fromYAMLValue(val)
+		}
+		return l
+	}
+
+	return v
+}
+
+// isBlockText reports whether a text is long enough to be edited as a YAML
+// block scalar and can be presented as such without changing its content.
+func isBlockText(s string) bool {
+	if !strings.Contains(s, "\n") && len(s) <= blockTextWidth {
+		return false
+	}
+
+	if strings.HasPrefix(s, "\n") || strings.HasSuffix(s, "\n") {
+		return false
+	}
+
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return false
+		}
+	}
+
+	for _, r := range s {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tomlFormat edits maps as TOML. Lists of maps are edited as an array of
+// tables.
+type tomlFormat struct{}
+
+func (tomlFormat) ext() string {
+	return ".toml"
+}
+
+func (tomlFormat) marshal(m map[string]interface{}, prev map[string]interface{}) ([]byte, error) {
+	return marshalTOML(m, prev, "")
+}
+
+func (tomlFormat) marshalList(m []map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	for _, item := range m {
+		table, err := marshalTOML(item, nil, tomlListTable)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(buf, "[[%s]]\n", tomlListTable)
+		buf.Write(table)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (tomlFormat) unmarshal(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if _, err := toml.Decode(string(data), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (tomlFormat) unmarshalList(data []byte) ([]map[string]interface{}, error) {
+	var l map[string][]map[string]interface{}
+	if _, err := toml.Decode(string(data), &l); err != nil {
+		return nil, err
+	}
+	return l[tomlListTable], nil
+}
+
+// marshalTOML serializes a map as TOML, commenting each field. Fields whose
+// value is a map are serialized last as sub-tables of parent, if any.
+// TOML having no null value, fields without value are omitted.
+func marshalTOML(m map[string]interface{}, prev map[string]interface{}, parent string) ([]byte, error) {
+	var keys, tables []string
+	for _, k := range sortedKeys(m) {
+		switch reflect.ValueOf(m[k]).Kind() {
+		case reflect.Invalid:
+		case reflect.Map:
+			tables = append(tables, k)
+		default:
+			keys = append(keys, k)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, k := range append(keys, tables...) {
+		fmt.Fprintf(buf, "# %s\n", fieldHint(k, m[k], prev))
+
+		field := new(bytes.Buffer)
+		enc := toml.NewEncoder(field)
+		enc.Indent = ""
+		if err := enc.Encode(map[string]interface{}{k: m[k]}); err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.SplitAfter(field.String(), "\n") {
+			if parent != "" && strings.HasPrefix(line, "[[") {
+				line = "[[" + parent + "." + line[2:]
+			} else if parent != "" && strings.HasPrefix(line, "[") {
+				line = "[" + parent + "." + line[1:]
+			}
+			buf.WriteString(line)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fieldHint describes a field's value for the user, giving its type and,
+// if it differs, its value from prev.
+func fieldHint(k string, v interface{}, prev map[string]interface{}) string {
+	hint := fmt.Sprintf("%s (%s)", k, typeHint(v))
+
+	if prev == nil {
+		return hint
+	}
+
+	p, exists := prev[k]
+	if !exists {
+		return hint + ", new field"
+	}
+
+	if !reflect.DeepEqual(p, v) {
+		return hint + ", previously: " + valueHint(p)
+	}
+
+	return hint
+}
+
+// typeHint gives a human readable type of a field's value.
+func typeHint(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "empty"
+	case string:
+		return "text"
+	case bool:
+		return "yes/no"
+	case time.Time:
+		return "date"
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map:
+		return "map"
+	}
+
+	return "value"
+}
+
+// valueHint gives a short, single line, representation of a field's value.
+func valueHint(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		s = "<empty>"
+	case string:
+		s = v
+	default:
+		if buf, err := json.Marshal(v); err == nil {
+			s = string(buf)
+		} else {
+			s = fmt.Sprint(v)
+		}
+	}
+
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > hintValueWidth {
+		s = string(r[:hintValueWidth-1]) + "…"
+	}
+	return s
+}
+
+// blankComments empties lines starting with '#' (keeping them so that
+// reported errors' line numbers stay accurate).
+func blankComments(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte{'#'}) {
+			lines[i] = nil
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pirmd/verify"
+)
+
+const (
+	// noopEditor is an editor that leaves the edited file unchanged.
+	noopEditor = "true %s"
+)
+
+func TestEditFormats(t *testing.T) {
+	m := map[string]interface{}{
+		"Title":       "Les misérables",
+		"Authors":     []interface{}{"Victor Hugo"},
+		"Description": "Introducing one of the most famous characters in literature, Jean Valjean.\nThe story follows him for decades.",
+		"Publisher":   "",
+	}
+	n := map[string]interface{}{"Title": "Notre-Dame de Paris"}
+
+	for name, f := range editFormats {
+		t.Run(name, func(t *testing.T) {
+			got, err := editAs(m, noopEditor, f)
+			if err != nil {
+				t.Fatalf("Edit failed: %v", err)
+			}
+			if failure := verify.Equal(got, m); failure != nil {
+				t.Errorf("Edit failed to read back map:\n%v", failure)
+			}
+
+			gotList, err := multiEditAs([]map[string]interface{}{m, n}, noopEditor, f)
+			if err != nil {
+				t.Fatalf("MultiEdit failed: %v", err)
+			}
+			if failure := verify.Equal(gotList, []map[string]interface{}{m, n}); failure != nil {
+				t.Errorf("MultiEdit failed to read back maps:\n%v", failure)
+			}
+		})
+	}
+}
+
+func TestEditFormatsComments(t *testing.T) {
+	m := map[string]interface{}{
+		"Title":       "Les misérables",
+		"Authors":     []interface{}{"Victor Hugo"},
+		"Description": "A long enough description to be displayed as a YAML block scalar instead of a quoted string.",
+	}
+	prev := map[string]interface{}{"Title": "Les miserables", "Authors": []interface{}{"Victor Hugo"}}
+
+	for name, want := range map[string][]string{
+		"yaml": {
+			"# Title (text), previously: Les miserables\nTitle: Les misérables\n",
+			"# Authors (list)\nAuthors:\n- Victor Hugo\n",
+			"# Description (text), new field\nDescription: |-\n  A long enough",
+		},
+		"toml": {
+			"# Title (text), previously: Les miserables\nTitle = \"Les misérables\"\n",
+			"# Authors (list)\nAuthors = [\"Victor Hugo\"]\n",
+		},
+	} {
+		data, err := editFormats[name].marshal(m, prev)
+		if err != nil {
+			t.Fatalf("Fail to marshal as %s: %v", name, err)
+		}
+
+		for _, w := range want {
+			if !strings.Contains(string(data), w) {
+				t.Errorf("Fail to marshal as %s: %q not found in:\n%s", name, w, data)
+			}
+		}
+	}
+}
+
+func TestEditReopenOnError(t *testing.T) {
+	fixer := `sh -c 'sed "s/^Title: \[$/Title: fixed/" "$0" > "$0.tmp" && mv "$0.tmp" "$0"' %s`
+	data := []byte("Authors: Victor Hugo\nTitle: [\n")
+
+	var got map[string]interface{}
+	err := editTextAt(data, 1, noopEditor, ".yaml", func(buf []byte) (err error) {
+		got, err = yamlFormat{}.unmarshal(buf)
+		return
+	})
+	if err == nil {
+		t.Errorf("Edit should fail if the faulty text is left unchanged")
+	}
+
+	faulty, line := annotateError(data, err)
+	if !strings.HasPrefix(string(faulty), errorComment) {
+		t.Errorf("Error is not reported in edited text:\n%s", faulty)
+	}
+	if want := 2 + strings.Count(string(faulty), errorComment); line != want {
+		t.Errorf("Error is reported at wrong line: got %d, want %d", line, want)
+	}
+
+	err = editTextAt(faulty, line, fixer, ".yaml", func(buf []byte) (err error) {
+		got, err = yamlFormat{}.unmarshal(buf)
+		return
+	})
+	if err != nil {
+		t.Fatalf("Edit failed after fixing the error: %v", err)
+	}
+
+	want := map[string]interface{}{"Authors": "Victor Hugo", "Title": "fixed"}
+	if failure := verify.Equal(got, want); failure != nil {
+		t.Errorf("Edit failed:\n%v", failure)
+	}
+}
+
+func TestErrorLine(t *testing.T) {
+	tstCases := []struct {
+		format string
+		in     string
+		want   int
+	}{
+		{"json", "{\n  \"Title\": \"foo\",\n  \"Authors\": [\n}", 4},
+		{"yaml", "Title: foo\nAuthors: [\n", 2},
+		{"toml", "Title = \"foo\"\nAuthors = \n", 2},
+	}
+
+	for _, tc := range tstCases {
+		_, err := editFormats[tc.format].unmarshal([]byte(tc.in))
+		if err == nil {
+			t.Fatalf("Unmarshalling invalid %s should fail", tc.format)
+		}
+
+		if got := errorLine([]byte(tc.in), err); got != tc.want {
+			t.Errorf("Fail to locate %s error '%v': got line %d, want %d", tc.format, err, got, tc.want)
+		}
+	}
+
+	if got := errorLine(nil, fmt.Errorf("unexpected error")); got != 1 {
+		t.Errorf("Fail to default error location to first line: got %d", got)
+	}
+}
@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+)
+
+const (
+	browseHelp = "Type to search  Enter: edit  Esc: quit"
+	detailHelp = formHelp + "  Ctrl-S: save  Esc: back"
+)
+
+// browser displays the result of a search as a list of records together with
+// the details of the selected one.
+type browser struct {
+	search func(string) ([]map[string]interface{}, error)
+	update func(orig, edited map[string]interface{}) error
+
+	query   *lineEditor
+	results []map[string]interface{}
+	names   list
+
+	// detail displays the selected record and, if editing is set, allows to
+	// modify it.
+	detail  *form
+	editing bool
+
+	status string
+}
+
+func newBrowser(search func(string) ([]map[string]interface{}, error), update func(orig, edited map[string]interface{}) error) *browser {
+	b := &browser{
+		search: search,
+		update: update,
+		query:  newLineEditor(""),
+	}
+	b.refresh()
+	return b
+}
+
+// refresh runs the search for the current query. Previous results are kept
+// if the search fails (usually because the query is not complete yet).
+func (b *browser) refresh() {
+	results, err := b.search(b.query.String())
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+
+	b.results, b.status = results, fmt.Sprintf("%d record(s)", len(results))
+	b.names.items = b.names.items[:0]
+	for i, r := range results {
+		b.names.items = append(b.names.items, label(r, i))
+	}
+	b.names.clamp()
+	b.show()
+}
+
+// show updates the detail pane for the selected record.
+func (b *browser) show() {
+	b.detail, b.editing = nil, false
+	if len(b.results) > 0 {
+		b.detail = newForm(b.results[b.names.cursor])
+	}
+}
+
+// save updates the edited record and refreshes the search's results.
+func (b *browser) save() {
+	orig := b.results[b.names.cursor]
+	if err := b.update(orig, b.detail.Map()); err != nil {
+		b.status = fmt.Sprintf("Fail to update '%s': %s", label(orig, b.names.cursor), err)
+		return
+	}
+
+	status := fmt.Sprintf("'%s' updated", label(orig, b.names.cursor))
+	b.refresh()
+	b.status = status
+}
+
+// handle processes a key event, reporting whether the browser is over.
+func (b *browser) handle(ev *tcell.EventKey) bool {
+	if b.editing {
+		if b.detail.handle(ev) {
+			return false
+		}
+
+		switch ev.Key() {
+		case tcell.KeyCtrlS:
+			b.save()
+		case tcell.KeyEscape:
+			b.show()
+		case tcell.KeyCtrlC:
+			return true
+		}
+		return false
+	}
+
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		return true
+
+	case tcell.KeyEnter, tcell.KeyTab:
+		b.editing = b.detail != nil
+
+	default:
+		if b.names.handle(ev) {
+			b.show()
+			return false
+		}
+
+		if b.query.handle(ev) {
+			b.names.cursor = 0
+			b.refresh()
+		}
+	}
+
+	return false
+}
+
+// draw draws the browser on the whole screen: the search's query on top,
+// the list of records on the left and the selected record on the right.
+func (b *browser) draw(s tcell.Screen) {
+	w, h := s.Size()
+	lw := w / 3
+
+	drawText(s, 0, 0, 8, styleKey, "Search: ")
+	if b.editing {
+		drawText(s, 8, 0, w-8, styleDefault, b.query.String())
+	} else {
+		b.query.draw(s, 8, 0, w-8, styleDefault)
+	}
+
+	b.names.draw(s, 0, 1, lw, h-3, !b.editing)
+	if b.detail != nil {
+		b.detail.draw(s, lw+1, 1, w-lw-1, h-3, b.editing)
+	}
+
+	drawLine(s, 0, h-2, w, styleDimmed, " "+b.status)
+	if b.editing {
+		drawBar(s, h-1, detailHelp)
+	} else {
+		drawBar(s, h-1, browseHelp)
+	}
+}
+
+// Browse opens a full-screen browser that searches records as the user
+// types a query and allows to modify the selected record.
+func (ui *TUI) Browse(search func(query string) ([]map[string]interface{}, error), update func(orig, edited map[string]interface{}) error) error {
+	b := newBrowser(search, update)
+	return ui.run(b.draw, b.handle)
+}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+const (
+	formHelp = "Enter: edit field  a: add field  d: delete field"
+)
+
+// toText converts a field's value to an editable text. Strings are edited as
+// is, other values as JSON.
+func toText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(buf)
+}
+
+// fromText converts back an edited text to a field's value, trying to keep
+// the type of the original value. Texts that cannot be converted are kept as
+// strings.
+func fromText(text string, orig interface{}) interface{} {
+	switch orig.(type) {
+	case nil, string:
+		return text
+
+	case time.Time:
+		if t, err := time.Parse(time.RFC3339, text); err == nil {
+			return t
+		}
+		return text
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return text
+	}
+
+	if l, ok := v.([]interface{}); ok {
+		strs := make([]string, len(l))
+		for i, item := range l {
+			s, ok := item.(string)
+			if !ok {
+				return v
+			}
+			strs[i] = s
+		}
+		return strs
+	}
+
+	return v
+}
+
+// form displays the fields of a record and allows to edit them one by one.
+type form struct {
+	data map[string]interface{}
+	keys []string
+	rows list
+
+	// editor holds the value of the field being edited or, if naming is
+	// set, the name of the field being added.
+	editor *lineEditor
+	naming bool
+}
+
+func newForm(m map[string]interface{}) *form {
+	f := &form{data: make(map[string]interface{}, len(m))}
+	for k, v := range m {
+		f.data[k] = v
+	}
+	f.sortKeys()
+	return f
+}
+
+func (f *form) sortKeys() {
+	f.keys = f.keys[:0]
+	for k := range f.data {
+		f.keys = append(f.keys, k)
+	}
+	sort.Strings(f.keys)
+	f.rows.items = f.keys
+	f.rows.clamp()
+}
+
+// Map returns the edited record's fields. Any pending field's edition is
+// committed.
+func (f *form) Map() map[string]interface{} {
+	for f.editing() {
+		f.commit()
+	}
+
+	m := make(map[string]interface{}, len(f.data))
+	for k, v := range f.data {
+		m[k] = v
+	}
+	return m
+}
+
+// editing reports whether a field is being edited.
+func (f *form) editing() bool {
+	return f.editor != nil
+}
+
+// commit ends the edition of the current field, keeping the edited value.
+func (f *form) commit() {
+	switch {
+	case f.editor == nil:
+		return
+
+	case f.naming:
+		name := f.editor.String()
+		f.editor, f.naming = nil, false
+		if name == "" {
+			return
+		}
+
+		if _, exists := f.data[name]; !exists {
+			f.data[name] = ""
+			f.sortKeys()
+		}
+		for i, k := range f.keys {
+			if k == name {
+				f.rows.cursor = i
+			}
+		}
+		f.editor = newLineEditor(toText(f.data[name]))
+
+	default:
+		key := f.keys[f.rows.cursor]
+		f.data[key] = fromText(f.editor.String(), f.data[key])
+		f.editor = nil
+	}
+}
+
+// handle processes a key event, reporting whether the key is meaningful for
+// the form.
+func (f *form) handle(ev *tcell.EventKey) bool {
+	if f.editing() {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			f.commit()
+		case tcell.KeyEscape:
+			f.editor, f.naming = nil, false
+		default:
+			return f.editor.handle(ev)
+		}
+		return true
+	}
+
+	switch {
+	case ev.Key() == tcell.KeyEnter:
+		if len(f.keys) > 0 {
+			f.editor = newLineEditor(toText(f.data[f.keys[f.rows.cursor]]))
+		}
+
+	case ev.Key() == tcell.KeyRune && ev.Rune() == 'a':
+		f.editor, f.naming = newLineEditor(""), true
+
+	case ev.Key() == tcell.KeyDelete, ev.Key() == tcell.KeyRune && ev.Rune() == 'd':
+		if len(f.keys) > 0 {
+			delete(f.data, f.keys[f.rows.cursor])
+			f.sortKeys()
+		}
+
+	default:
+		return f.rows.handle(ev)
+	}
+
+	return true
+}
+
+// draw draws the form in the (x, y, w, h) area. The current field is
+// highlighted if the form is focused.
+func (f *form) draw(s tcell.Screen, x, y, w, h int, focused bool) {
+	if f.naming {
+		h--
+		drawText(s, x, y+h, w, styleKey, "New field: ")
+		f.editor.draw(s, x+11, y+h, w-11, styleDefault)
+	}
+
+	kw := 0
+	for _, k := range f.keys {
+		if len(k) > kw {
+			kw = len(k)
+		}
+	}
+	if kw > w/3 {
+		kw = w / 3
+	}
+
+	f.rows.scroll(h)
+	for i := 0; i < h && f.rows.offset+i < len(f.keys); i++ {
+		idx := f.rows.offset + i
+		key := f.keys[idx]
+
+		style := styleKey
+		if idx == f.rows.cursor && focused {
+			style = styleSelected
+		}
+		drawLine(s, x, y+i, kw, style, key)
+
+		if idx == f.rows.cursor && f.editor != nil && !f.naming {
+			f.editor.draw(s, x+kw+1, y+i, w-kw-1, styleDefault)
+			continue
+		}
+		drawText(s, x+kw+1, y+i, w-kw-1, styleDefault, toText(f.data[key]))
+	}
+}
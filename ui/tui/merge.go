@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"sort"
+
+	"github.com/gdamore/tcell"
+)
+
+const (
+	mergeHelp = "Left/Right: choose value  Enter: edit chosen value  Ctrl-S: save  Esc: cancel"
+)
+
+// merger displays two records side by side and allows to choose, field by
+// field, which value to keep.
+type merger struct {
+	left, right map[string]interface{}
+	keys        []string
+	rows        list
+
+	// chosen holds, for each field, the value kept in the merged record.
+	// fromLeft reports whether this value has been chosen from the left
+	// record.
+	chosen   map[string]interface{}
+	fromLeft map[string]bool
+
+	editor *lineEditor
+}
+
+func newMerger(left, right map[string]interface{}) *merger {
+	m := &merger{
+		left:     left,
+		right:    right,
+		chosen:   make(map[string]interface{}),
+		fromLeft: make(map[string]bool),
+	}
+
+	for k, v := range right {
+		m.chosen[k] = v
+	}
+	for k, v := range left {
+		m.chosen[k], m.fromLeft[k] = v, true
+	}
+
+	for k := range m.chosen {
+		m.keys = append(m.keys, k)
+	}
+	sort.Strings(m.keys)
+	m.rows.items = m.keys
+
+	return m
+}
+
+// Map returns the merged record, ignoring fields without value. Any pending
+// field's edition is committed.
+func (m *merger) Map() map[string]interface{} {
+	m.commit()
+
+	merged := make(map[string]interface{}, len(m.chosen))
+	for k, v := range m.chosen {
+		if v != nil {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// choose selects the value of the current field from the left or the right
+// record.
+func (m *merger) choose(fromLeft bool) {
+	if len(m.keys) == 0 {
+		return
+	}
+
+	key := m.keys[m.rows.cursor]
+	if fromLeft {
+		m.chosen[key] = m.left[key]
+	} else {
+		m.chosen[key] = m.right[key]
+	}
+	m.fromLeft[key] = fromLeft
+}
+
+// commit ends the edition of the current field, keeping the edited value.
+func (m *merger) commit() {
+	if m.editor == nil {
+		return
+	}
+
+	key := m.keys[m.rows.cursor]
+	m.chosen[key] = fromText(m.editor.String(), m.chosen[key])
+	m.editor = nil
+}
+
+// handle processes a key event, reporting whether the key is meaningful for
+// the merger.
+func (m *merger) handle(ev *tcell.EventKey) bool {
+	if m.editor != nil {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			m.commit()
+		case tcell.KeyEscape:
+			m.editor = nil
+		default:
+			return m.editor.handle(ev)
+		}
+		return true
+	}
+
+	switch ev.Key() {
+	case tcell.KeyLeft:
+		m.choose(true)
+	case tcell.KeyRight:
+		m.choose(false)
+	case tcell.KeyEnter:
+		if len(m.keys) > 0 {
+			m.editor = newLineEditor(toText(m.chosen[m.keys[m.rows.cursor]]))
+		}
+	default:
+		return m.rows.handle(ev)
+	}
+
+	return true
+}
+
+// draw draws the merger in the (x, y, w, h) area, the chosen value of each
+// field being highlighted.
+func (m *merger) draw(s tcell.Screen, x, y, w, h int) {
+	kw := w / 5
+	cw := (w - kw - 2) / 2
+
+	drawLine(s, x, y, kw, styleKey, "Field")
+	drawLine(s, x+kw+1, y, cw, styleKey, "Left")
+	drawLine(s, x+kw+cw+2, y, cw, styleKey, "Right")
+	y, h = y+1, h-1
+
+	m.rows.scroll(h)
+	for i := 0; i < h && m.rows.offset+i < len(m.keys); i++ {
+		idx := m.rows.offset + i
+		key := m.keys[idx]
+
+		keyStyle := styleKey
+		if idx == m.rows.cursor {
+			keyStyle = styleSelected
+		}
+		drawLine(s, x, y+i, kw, keyStyle, key)
+
+		lstyle, rstyle := styleCurrent, styleDimmed
+		if !m.fromLeft[key] {
+			lstyle, rstyle = styleDimmed, styleCurrent
+		}
+
+		lval, rval := toText(m.left[key]), toText(m.right[key])
+		if m.fromLeft[key] {
+			lval = toText(m.chosen[key])
+		} else {
+			rval = toText(m.chosen[key])
+		}
+
+		if idx == m.rows.cursor && m.editor != nil {
+			if m.fromLeft[key] {
+				m.editor.draw(s, x+kw+1, y+i, cw, styleDefault)
+				drawLine(s, x+kw+cw+2, y+i, cw, rstyle, rval)
+			} else {
+				drawLine(s, x+kw+1, y+i, cw, lstyle, lval)
+				m.editor.draw(s, x+kw+cw+2, y+i, cw, styleDefault)
+			}
+			continue
+		}
+
+		drawLine(s, x+kw+1, y+i, cw, lstyle, lval)
+		drawLine(s, x+kw+cw+2, y+i, cw, rstyle, rval)
+	}
+}
+
+// Merge opens a side by side view of two maps to choose, field by field,
+// the values to keep. Values from m are chosen by default. Merge returns n
+// unchanged if the user cancels the merge.
+func (ui *TUI) Merge(m, n map[string]interface{}) (map[string]interface{}, error) {
+	if ui.auto {
+		return ui.printer.Merge(m, n)
+	}
+
+	mg, merged := newMerger(m, n), n
+	err := ui.run(func(s tcell.Screen) {
+		w, h := s.Size()
+		drawBar(s, 0, "Merge records")
+		mg.draw(s, 0, 1, w, h-2)
+		drawBar(s, h-1, mergeHelp)
+	}, func(ev *tcell.EventKey) bool {
+		if mg.handle(ev) {
+			return false
+		}
+
+		switch ev.Key() {
+		case tcell.KeyCtrlS:
+			merged = mg.Map()
+			return true
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return true
+		}
+		return false
+	})
+
+	return merged, err
+}
@@ -0,0 +1,317 @@
+// Package tui provides a full-screen terminal user interface to browse, edit
+// and merge records, so that curating a collection does not require editing
+// records one by one in an external editor.
+//
+// Dialogs (edition, merge, choices) use the whole terminal until the user is
+// done. Messages and records' printing are delegated to a CLI User Interface
+// and are displayed once no dialog is running anymore.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+
+	"github.com/pirmd/gostore/ui"
+	"github.com/pirmd/gostore/ui/cli"
+)
+
+var (
+	_ ui.UserInterfacer = (*TUI)(nil) // Makes sure that TUI implements UserInterfacer
+	_ ui.Browser        = (*TUI)(nil) // Makes sure that TUI implements Browser
+)
+
+// TUI is a full-screen terminal user interface.
+type TUI struct {
+	auto    bool
+	printer *cli.CLI
+
+	newScreen func() (tcell.Screen, error)
+	screen    tcell.Screen
+	pending   []func()
+}
+
+// New creates a TUI User Interface that relies on printer to display
+// messages or records.
+func New(printer *cli.CLI) *TUI {
+	return &TUI{
+		printer:   printer,
+		newScreen: tcell.NewScreen,
+	}
+}
+
+// NewFromConfig creates a TUI User Interface from a given Config
+func NewFromConfig(cfg *cli.Config) (*TUI, error) {
+	printer, err := cli.NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ui := New(printer)
+	ui.auto = cfg.Auto
+	return ui, nil
+}
+
+// Printf displays a message to the user (has same behaviour than
+// fmt.Printf). Messages are postponed until no dialog is running.
+func (ui *TUI) Printf(format string, a ...interface{}) {
+	ui.output(func() { ui.printer.Printf(format, a...) })
+}
+
+// PrettyPrint shows in a pleasant manner a metadata set
+func (ui *TUI) PrettyPrint(medias ...map[string]interface{}) {
+	ui.output(func() { ui.printer.PrettyPrint(medias...) })
+}
+
+// PrettyDiff shows in a pleasant manner differences between two metadata sets
+func (ui *TUI) PrettyDiff(mediaL, mediaR map[string]interface{}) {
+	ui.output(func() { ui.printer.PrettyDiff(mediaL, mediaR) })
+}
+
+// output runs fn, postponing it until no dialog is running.
+func (ui *TUI) output(fn func()) {
+	if ui.screen == nil {
+		fn()
+		return
+	}
+
+	ui.pending = append(ui.pending, fn)
+}
+
+// Edit opens a form to modify a map. Edit returns m unchanged if the user
+// cancels the edition.
+func (ui *TUI) Edit(m map[string]interface{}) (map[string]interface{}, error) {
+	if ui.auto {
+		return ui.printer.Edit(m)
+	}
+
+	f, edited := newForm(m), m
+	err := ui.run(func(s tcell.Screen) {
+		w, h := s.Size()
+		drawBar(s, 0, "Edit record")
+		f.draw(s, 0, 1, w, h-2, true)
+		drawBar(s, h-1, formHelp+"  Ctrl-S: save  Esc: cancel")
+	}, func(ev *tcell.EventKey) bool {
+		if f.handle(ev) {
+			return false
+		}
+
+		switch ev.Key() {
+		case tcell.KeyCtrlS:
+			edited = f.Map()
+			return true
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return true
+		}
+		return false
+	})
+
+	return edited, err
+}
+
+// MultiEdit opens a list of the maps together with a form to modify the
+// selected one. MultiEdit returns m unchanged if the user cancels the
+// edition.
+func (ui *TUI) MultiEdit(m []map[string]interface{}) ([]map[string]interface{}, error) {
+	if ui.auto {
+		return ui.printer.MultiEdit(m)
+	}
+
+	var forms []*form
+	var names list
+	for i, item := range m {
+		forms = append(forms, newForm(item))
+		names.items = append(names.items, label(item, i))
+	}
+
+	edited, focusForm := m, false
+	err := ui.run(func(s tcell.Screen) {
+		w, h := s.Size()
+		lw := w / 3
+
+		drawBar(s, 0, fmt.Sprintf("Edit %d records", len(m)))
+		names.draw(s, 0, 1, lw, h-2, !focusForm)
+		if len(forms) > 0 {
+			forms[names.cursor].draw(s, lw+1, 1, w-lw-1, h-2, focusForm)
+		}
+		drawBar(s, h-1, "Tab: switch pane  "+formHelp+"  Ctrl-S: save  Esc: cancel")
+	}, func(ev *tcell.EventKey) bool {
+		if focusForm && len(forms) > 0 && forms[names.cursor].handle(ev) {
+			return false
+		}
+
+		switch ev.Key() {
+		case tcell.KeyTab, tcell.KeyBacktab:
+			focusForm = !focusForm
+		case tcell.KeyCtrlS:
+			edited = make([]map[string]interface{}, len(forms))
+			for i, f := range forms {
+				edited[i] = f.Map()
+			}
+			return true
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return true
+		default:
+			if !focusForm {
+				names.handle(ev)
+			}
+		}
+		return false
+	})
+
+	return edited, err
+}
+
+// Confirm asks the user to confirm an action. Actions are always confirmed
+// if the TUI is in automatic mode.
+func (ui *TUI) Confirm(msg string) bool {
+	if ui.auto {
+		return true
+	}
+
+	var confirmed bool
+	if err := ui.run(func(s tcell.Screen) {
+		w, h := s.Size()
+		drawLine(s, 0, h/2, w, styleCurrent, " "+msg+" [y/N]")
+	}, func(ev *tcell.EventKey) bool {
+		confirmed = ev.Key() == tcell.KeyRune && strings.ToLower(string(ev.Rune())) == "y"
+		return true
+	}); err != nil {
+		return false
+	}
+
+	return confirmed
+}
+
+// Choose asks the user to choose among a set of options. The first option is
+// chosen by default if the user cancels or if the TUI is in automatic mode.
+func (ui *TUI) Choose(msg string, options ...string) string {
+	if len(options) == 0 {
+		return ""
+	}
+
+	if ui.auto {
+		return options[0]
+	}
+
+	opts := &list{items: options}
+	chosen := options[0]
+	if err := ui.run(func(s tcell.Screen) {
+		w, h := s.Size()
+		drawBar(s, 0, msg)
+		opts.draw(s, 1, 1, w-1, h-2, true)
+		drawBar(s, h-1, "Enter: choose  Esc: "+options[0])
+	}, func(ev *tcell.EventKey) bool {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			chosen = options[opts.cursor]
+			return true
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return true
+		}
+		opts.handle(ev)
+		return false
+	}); err != nil {
+		return options[0]
+	}
+
+	return chosen
+}
+
+// Pick asks the user to pick one of the candidates, displaying the details
+// of the selected one. Pick returns -1 if the user does not pick any
+// candidate. The first candidate is picked if the TUI is in automatic mode.
+func (ui *TUI) Pick(msg string, candidates ...map[string]interface{}) int {
+	if ui.auto || len(candidates) == 0 {
+		return ui.printer.Pick(msg, candidates...)
+	}
+
+	var names list
+	for i, c := range candidates {
+		names.items = append(names.items, label(c, i))
+	}
+
+	picked := -1
+	if err := ui.run(func(s tcell.Screen) {
+		w, h := s.Size()
+		lw := w / 3
+
+		drawBar(s, 0, msg)
+		names.draw(s, 0, 1, lw, h-2, true)
+		newForm(candidates[names.cursor]).draw(s, lw+1, 1, w-lw-1, h-2, false)
+		drawBar(s, h-1, "Enter: pick  Esc: skip")
+	}, func(ev *tcell.EventKey) bool {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			picked = names.cursor
+			return true
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return true
+		}
+		names.handle(ev)
+		return false
+	}); err != nil {
+		return -1
+	}
+
+	return picked
+}
+
+// run displays a full-screen dialog until handle reports that the dialog is
+// over. Dialogs opened while another one is running re-use its screen.
+func (ui *TUI) run(draw func(tcell.Screen), handle func(*tcell.EventKey) bool) error {
+	s := ui.screen
+	if s == nil {
+		var err error
+		if s, err = ui.newScreen(); err != nil {
+			return err
+		}
+		if err := s.Init(); err != nil {
+			return err
+		}
+
+		ui.screen = s
+		defer func() {
+			s.Fini()
+			ui.screen = nil
+			ui.flush()
+		}()
+	}
+
+	for {
+		s.Clear()
+		s.HideCursor()
+		draw(s)
+		s.Show()
+
+		switch ev := s.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventResize:
+			s.Sync()
+		case *tcell.EventKey:
+			if handle(ev) {
+				return nil
+			}
+		}
+	}
+}
+
+// flush displays the output postponed while dialogs were running.
+func (ui *TUI) flush() {
+	for _, fn := range ui.pending {
+		fn()
+	}
+	ui.pending = nil
+}
+
+// label returns a short description of a map to identify it in a list.
+func label(m map[string]interface{}, idx int) string {
+	for _, k := range []string{"Name", "Title"} {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return fmt.Sprintf("#%d", idx+1)
+}
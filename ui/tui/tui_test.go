@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/pirmd/verify"
+
+	"github.com/pirmd/gostore/ui/cli"
+)
+
+// scriptedScreen is a simulated screen that receives a pre-defined sequence
+// of key events once initialized.
+type scriptedScreen struct {
+	tcell.SimulationScreen
+	script []*tcell.EventKey
+}
+
+func (s *scriptedScreen) Init() error {
+	if err := s.SimulationScreen.Init(); err != nil {
+		return err
+	}
+	s.SetSize(80, 24)
+
+	go func() {
+		for _, ev := range s.script {
+			s.PostEventWait(ev)
+		}
+	}()
+	return nil
+}
+
+// newTestTUI creates a TUI whose dialogs receive the given sequence of key
+// events.
+func newTestTUI(script ...[]*tcell.EventKey) *TUI {
+	var keys []*tcell.EventKey
+	for _, s := range script {
+		keys = append(keys, s...)
+	}
+
+	ui := New(cli.New())
+	ui.newScreen = func() (tcell.Screen, error) {
+		return &scriptedScreen{tcell.NewSimulationScreen("UTF-8"), keys}, nil
+	}
+	return ui
+}
+
+func key(k tcell.Key) []*tcell.EventKey {
+	return []*tcell.EventKey{tcell.NewEventKey(k, 0, tcell.ModNone)}
+}
+
+func text(str string) (keys []*tcell.EventKey) {
+	for _, r := range str {
+		keys = append(keys, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	return
+}
+
+func TestEdit(t *testing.T) {
+	m := map[string]interface{}{"Title": "foo", "Authors": []string{"bar"}}
+
+	tstCases := []struct {
+		script [][]*tcell.EventKey
+		want   map[string]interface{}
+	}{
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyDown), key(tcell.KeyEnter), key(tcell.KeyCtrlU), text("foobar"), key(tcell.KeyEnter), key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "foobar", "Authors": []string{"bar"}},
+		},
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyEnter), key(tcell.KeyCtrlU), text(`["bar", "baz"]`), key(tcell.KeyEnter), key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "foo", "Authors": []string{"bar", "baz"}},
+		},
+		{
+			script: [][]*tcell.EventKey{text("a"), text("Year"), key(tcell.KeyEnter), text("1976"), key(tcell.KeyEnter), text("d"), key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "foo", "Authors": []string{"bar"}},
+		},
+		{
+			script: [][]*tcell.EventKey{text("a"), text("Year"), key(tcell.KeyEnter), text("1976"), key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "foo", "Authors": []string{"bar"}, "Year": "1976"},
+		},
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyEnter), key(tcell.KeyCtrlU), key(tcell.KeyEnter), key(tcell.KeyEscape)},
+			want:   m,
+		},
+	}
+
+	for _, tc := range tstCases {
+		got, err := newTestTUI(tc.script...).Edit(m)
+		if err != nil {
+			t.Fatalf("Edit failed: %v", err)
+		}
+
+		if failure := verify.Equal(got, tc.want); failure != nil {
+			t.Errorf("Edit failed:\n%v", failure)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	left := map[string]interface{}{"Title": "foo", "Authors": []string{"bar"}}
+	right := map[string]interface{}{"Title": "Foo", "Year": "1976"}
+
+	tstCases := []struct {
+		script [][]*tcell.EventKey
+		want   map[string]interface{}
+	}{
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "foo", "Authors": []string{"bar"}, "Year": "1976"},
+		},
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyDown), key(tcell.KeyRight), key(tcell.KeyDown), key(tcell.KeyLeft), key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "Foo", "Authors": []string{"bar"}},
+		},
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyEnd), key(tcell.KeyEnter), text("-01"), key(tcell.KeyEnter), key(tcell.KeyCtrlS)},
+			want:   map[string]interface{}{"Title": "foo", "Authors": []string{"bar"}, "Year": "1976-01"},
+		},
+		{
+			script: [][]*tcell.EventKey{key(tcell.KeyDown), key(tcell.KeyRight), key(tcell.KeyEscape)},
+			want:   right,
+		},
+	}
+
+	for _, tc := range tstCases {
+		got, err := newTestTUI(tc.script...).Merge(left, right)
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+
+		if failure := verify.Equal(got, tc.want); failure != nil {
+			t.Errorf("Merge failed:\n%v", failure)
+		}
+	}
+}
+
+func TestChooseAndPick(t *testing.T) {
+	ui := newTestTUI(key(tcell.KeyDown), key(tcell.KeyEnter))
+	if got := ui.Choose("Keep?", "abort", "edit"); got != "edit" {
+		t.Errorf("Choose failed: got %s, want edit", got)
+	}
+
+	ui = newTestTUI(key(tcell.KeyDown), key(tcell.KeyEscape))
+	if got := ui.Choose("Keep?", "abort", "edit"); got != "abort" {
+		t.Errorf("Choose failed: got %s, want abort", got)
+	}
+
+	candidates := []map[string]interface{}{{"Title": "foo"}, {"Title": "bar"}}
+
+	ui = newTestTUI(key(tcell.KeyDown), key(tcell.KeyEnter))
+	if got := ui.Pick("Pick?", candidates...); got != 1 {
+		t.Errorf("Pick failed: got %d, want 1", got)
+	}
+
+	ui = newTestTUI(key(tcell.KeyEscape))
+	if got := ui.Pick("Pick?", candidates...); got != -1 {
+		t.Errorf("Pick failed: got %d, want -1", got)
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	records := []map[string]interface{}{
+		{"Name": "foo", "Title": "Foo"},
+		{"Name": "bar", "Title": "Bar"},
+		{"Name": "baz", "Title": "Baz"},
+	}
+
+	search := func(query string) ([]map[string]interface{}, error) {
+		if strings.HasPrefix(query, "!") {
+			return nil, fmt.Errorf("invalid query")
+		}
+
+		var found []map[string]interface{}
+		for _, r := range records {
+			if strings.Contains(r["Name"].(string), query) {
+				found = append(found, r)
+			}
+		}
+		return found, nil
+	}
+
+	var updated []map[string]interface{}
+	update := func(orig, edited map[string]interface{}) error {
+		for i, r := range records {
+			if r["Name"] == orig["Name"] {
+				records[i] = edited
+			}
+		}
+		updated = append(updated, edited)
+		return nil
+	}
+
+	ui := newTestTUI(
+		text("ba"), key(tcell.KeyDown), key(tcell.KeyEnter),
+		key(tcell.KeyDown), key(tcell.KeyEnter), key(tcell.KeyCtrlU), text("Baz!"), key(tcell.KeyEnter),
+		key(tcell.KeyCtrlS), key(tcell.KeyEscape),
+	)
+
+	if err := ui.Browse(search, update); err != nil {
+		t.Fatalf("Browse failed: %v", err)
+	}
+
+	want := []map[string]interface{}{{"Name": "baz", "Title": "Baz!"}}
+	if failure := verify.Equal(updated, want); failure != nil {
+		t.Errorf("Browse failed to update record:\n%v", failure)
+	}
+}
+
+func TestTextConversion(t *testing.T) {
+	date := time.Date(1976, time.January, 16, 10, 42, 5, 0, time.UTC)
+
+	tstCases := []struct {
+		in   interface{}
+		text string
+		want interface{}
+	}{
+		{"foo", "foo", "foo"},
+		{[]string{"foo", "bar"}, `["foo","bar"]`, []string{"foo", "bar"}},
+		{float64(3), "3", float64(3)},
+		{date, "1976-01-16T10:42:05Z", date},
+		{nil, "", ""},
+	}
+
+	for _, tc := range tstCases {
+		got := toText(tc.in)
+		if got != tc.text {
+			t.Errorf("Fail to convert %v to text: got %s, want %s", tc.in, got, tc.text)
+		}
+
+		if failure := verify.Equal(fromText(got, tc.in), tc.want); failure != nil {
+			t.Errorf("Fail to convert back %v from text:\n%v", tc.in, failure)
+		}
+	}
+
+	if got := fromText("not a number", float64(3)); got != "not a number" {
+		t.Errorf("Fail to keep invalid text as is: got %v", got)
+	}
+}
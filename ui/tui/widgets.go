@@ -0,0 +1,203 @@
+package tui
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	styleDefault  = tcell.StyleDefault
+	styleBar      = tcell.StyleDefault.Reverse(true)
+	styleSelected = tcell.StyleDefault.Reverse(true)
+	styleCurrent  = tcell.StyleDefault.Bold(true)
+	styleKey      = tcell.StyleDefault.Bold(true)
+	styleDimmed   = tcell.StyleDefault.Dim(true)
+)
+
+// drawText draws str at (x, y), truncated to w cells. Line breaks and
+// tabulations are displayed as spaces.
+func drawText(s tcell.Screen, x, y, w int, style tcell.Style, str string) {
+	var n int
+	for _, r := range str {
+		if r == '\n' || r == '\t' || r == '\r' {
+			r = ' '
+		}
+
+		rw := runewidth.RuneWidth(r)
+		if rw == 0 {
+			continue
+		}
+		if n+rw > w {
+			return
+		}
+
+		s.SetContent(x+n, y, r, nil, style)
+		n += rw
+	}
+}
+
+// drawLine draws str at (x, y) filling the remaining of the w cells with
+// spaces, so that the style applies to the whole line.
+func drawLine(s tcell.Screen, x, y, w int, style tcell.Style, str string) {
+	for i := 0; i < w; i++ {
+		s.SetContent(x+i, y, ' ', nil, style)
+	}
+	drawText(s, x, y, w, style, str)
+}
+
+// drawBar draws a title or status bar across the whole screen's width.
+func drawBar(s tcell.Screen, y int, str string) {
+	w, _ := s.Size()
+	drawLine(s, 0, y, w, styleBar, " "+str)
+}
+
+// lineEditor is a single line text editor.
+type lineEditor struct {
+	text   []rune
+	cursor int
+}
+
+func newLineEditor(str string) *lineEditor {
+	e := &lineEditor{text: []rune(str)}
+	e.cursor = len(e.text)
+	return e
+}
+
+// String returns the edited text.
+func (e *lineEditor) String() string {
+	return string(e.text)
+}
+
+// handle processes a key event, reporting whether the key is meaningful for
+// the editor.
+func (e *lineEditor) handle(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		e.text = append(e.text[:e.cursor], append([]rune{ev.Rune()}, e.text[e.cursor:]...)...)
+		e.cursor++
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if e.cursor > 0 {
+			e.text = append(e.text[:e.cursor-1], e.text[e.cursor:]...)
+			e.cursor--
+		}
+
+	case tcell.KeyDelete:
+		if e.cursor < len(e.text) {
+			e.text = append(e.text[:e.cursor], e.text[e.cursor+1:]...)
+		}
+
+	case tcell.KeyLeft:
+		if e.cursor > 0 {
+			e.cursor--
+		}
+
+	case tcell.KeyRight:
+		if e.cursor < len(e.text) {
+			e.cursor++
+		}
+
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		e.cursor = 0
+
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		e.cursor = len(e.text)
+
+	case tcell.KeyCtrlU:
+		e.text, e.cursor = e.text[:0], 0
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// draw draws the editor at (x, y) on w cells, scrolling the text so that
+// the cursor is always visible.
+func (e *lineEditor) draw(s tcell.Screen, x, y, w int, style tcell.Style) {
+	start := 0
+	for runewidth.StringWidth(string(e.text[start:e.cursor])) > w-1 {
+		start++
+	}
+
+	drawLine(s, x, y, w, style, string(e.text[start:]))
+	s.ShowCursor(x+runewidth.StringWidth(string(e.text[start:e.cursor])), y)
+}
+
+// list is a scrollable list of items with a selected item.
+type list struct {
+	items  []string
+	cursor int
+	offset int
+	height int
+}
+
+// handle processes a key event, reporting whether the key is meaningful for
+// the list.
+func (l *list) handle(ev *tcell.EventKey) bool {
+	page := l.height
+	if page < 1 {
+		page = 1
+	}
+
+	switch ev.Key() {
+	case tcell.KeyUp:
+		l.cursor--
+	case tcell.KeyDown:
+		l.cursor++
+	case tcell.KeyPgUp:
+		l.cursor -= page
+	case tcell.KeyPgDn:
+		l.cursor += page
+	case tcell.KeyHome:
+		l.cursor = 0
+	case tcell.KeyEnd:
+		l.cursor = len(l.items) - 1
+	default:
+		return false
+	}
+
+	l.clamp()
+	return true
+}
+
+// clamp makes sure that the cursor points to an existing item.
+func (l *list) clamp() {
+	if l.cursor >= len(l.items) {
+		l.cursor = len(l.items) - 1
+	}
+	if l.cursor < 0 {
+		l.cursor = 0
+	}
+}
+
+// scroll makes sure that the cursor is visible within h rows.
+func (l *list) scroll(h int) {
+	l.height = h
+	l.clamp()
+
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+h {
+		l.offset = l.cursor - h + 1
+	}
+}
+
+// draw draws the list in the (x, y, w, h) area. The selected item is
+// highlighted if the list is focused.
+func (l *list) draw(s tcell.Screen, x, y, w, h int, focused bool) {
+	l.scroll(h)
+
+	for i := 0; i < h && l.offset+i < len(l.items); i++ {
+		style := styleDefault
+		if l.offset+i == l.cursor {
+			style = styleCurrent
+			if focused {
+				style = styleSelected
+			}
+		}
+		drawLine(s, x, y+i, w, style, l.items[l.offset+i])
+	}
+}
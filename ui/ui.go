@@ -19,4 +19,23 @@ type UserInterfacer interface {
 
 	// Merge spawns a dialog to merge two maps into one
 	Merge(map[string]interface{}, map[string]interface{}) (map[string]interface{}, error)
+
+	// Confirm asks the user to confirm an action
+	Confirm(string) bool
+
+	// Choose asks the user to choose among a set of options. The first option
+	// is the default one
+	Choose(string, ...string) string
+
+	// Pick asks the user to pick one of the provided maps. Pick returns the
+	// index of the picked map or -1 if none is picked
+	Pick(string, ...map[string]interface{}) int
+}
+
+// Browser represents a User Interface that can browse a collection of maps,
+// searching them and editing them interactively.
+type Browser interface {
+	// Browse displays the maps returned by search for the query typed by the
+	// user, and calls update each time the user modifies one of them.
+	Browse(search func(query string) ([]map[string]interface{}, error), update func(orig, edited map[string]interface{}) error) error
 }
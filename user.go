@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pirmd/gostore/store"
+	"github.com/pirmd/gostore/util"
+)
+
+// Fields of the end-user's personal information about a record. They are
+// stored apart from the record's metadata so that reading again the media's
+// metadata never modifies them, and are indexed like any other field (for
+// example searching for 'Tags:classic' or 'ReadingStatus:reading').
+const (
+	tagsField      = "Tags"
+	shelvesField   = "Shelves"
+	statusField    = "ReadingStatus"
+	ratingField    = "Rating"
+	readDatesField = "ReadDates"
+
+	// maxRating is the highest rating a record can be given.
+	maxRating = 5
+)
+
+// Reading status of a record.
+const (
+	toReadStatus  = "to-read"
+	readingStatus = "reading"
+	readStatus    = "read"
+)
+
+var (
+	// readingStatuses lists the known reading status.
+	readingStatuses = []string{toReadStatus, readingStatus, readStatus}
+
+	// userFields lists the fields of the end-user's personal information.
+	userFields = []string{tagsField, shelvesField, statusField, ratingField, readDatesField}
+)
+
+// Tag adds tags to (or removes tags from, if remove is set) the records
+// selected either by their name or by a query.
+func (gs *Gostore) Tag(tags []string, remove bool, pattern []string, query string) error {
+	return gs.updateUserData("tagging", pattern, query, func(user map[string]interface{}) error {
+		updateList(user, tagsField, tags, remove)
+		return nil
+	})
+}
+
+// Shelve puts the records selected either by their name or by a query on
+// the given shelves (or takes them off, if remove is set). Shelves are
+// virtual collections that can be listed using a query like
+// 'Shelves:"to sort"'.
+func (gs *Gostore) Shelve(shelves []string, remove bool, pattern []string, query string) error {
+	return gs.updateUserData("shelving", pattern, query, func(user map[string]interface{}) error {
+		updateList(user, shelvesField, shelves, remove)
+		return nil
+	})
+}
+
+// SetReadingStatus modifies the reading status (to-read, reading or read) of
+// the records selected either by their name or by a query. Marking a record
+// as read also records the date it was read on, date being today if not
+// provided.
+func (gs *Gostore) SetReadingStatus(status string, readOn string, pattern []string, query string) error {
+	if !containsFold(readingStatuses, status) {
+		return fmt.Errorf("setting reading status failed: unknown status '%s' (known status: %s)", status, strings.Join(readingStatuses, ", "))
+	}
+	status = strings.ToLower(status)
+
+	readAt := today()
+	if readOn != "" {
+		if status != readStatus {
+			return fmt.Errorf("setting reading status failed: a read date can only be given to read records")
+		}
+
+		var err error
+		if readAt, err = util.ParseTime(readOn); err != nil {
+			return fmt.Errorf("setting reading status failed: %s", err)
+		}
+	}
+
+	return gs.updateUserData("setting reading status", pattern, query, func(user map[string]interface{}) error {
+		user[statusField] = status
+
+		if status == readStatus {
+			if err := checkUserData(user); err != nil {
+				return err
+			}
+
+			readDates, _ := user[readDatesField].([]time.Time)
+			for _, d := range readDates {
+				if d.Equal(readAt) {
+					return nil
+				}
+			}
+			user[readDatesField] = append(readDates, readAt)
+		}
+
+		return nil
+	})
+}
+
+// Rate gives a rating (from 1 to 5) to the records selected either by their
+// name or by a query. A rating of 0 removes the records' rating.
+func (gs *Gostore) Rate(rating int, pattern []string, query string) error {
+	if rating < 0 || rating > maxRating {
+		return fmt.Errorf("rating failed: rating should be between 0 and %d", maxRating)
+	}
+
+	return gs.updateUserData("rating", pattern, query, func(user map[string]interface{}) error {
+		if rating == 0 {
+			delete(user, ratingField)
+			return nil
+		}
+
+		user[ratingField] = rating
+		return nil
+	})
+}
+
+// updateUserData modifies the end-user's personal information of the
+// records selected either by their name or by a query. Changes are displayed
+// and confirmation is asked before updating each record.
+func (gs *Gostore) updateUserData(action string, pattern []string, query string, modify func(map[string]interface{}) error) error {
+	records, err := gs.selectRecords(pattern, query)
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", action, err)
+	}
+
+	var updated store.Records
+	var updateErr util.MultiErrors
+	for _, r := range records {
+		gs.log.Printf("Updating personal information of '%s'", r.Key())
+
+		ok, err := gs.updateUser(r, modify)
+		if err != nil {
+			updateErr.Add(fmt.Errorf("%s '%s' failed: %s", action, r.Key(), err))
+			continue
+		}
+
+		if ok {
+			updated = append(updated, r)
+		}
+	}
+
+	if len(updated) != 0 {
+		gs.ui.PrettyPrint(updated.Flatted()...)
+
+		if err := gs.runHooks("post-update", updated...); err != nil {
+			updateErr.Add(err)
+		}
+	}
+
+	return updateErr.Err()
+}
+
+// updateUser modifies a record's personal information, reporting whether
+// the record has been updated.
+func (gs *Gostore) updateUser(r *store.Record, modify func(map[string]interface{}) error) (bool, error) {
+	key, before := r.Key(), r.Flatted()
+
+	mdata, user := r.Data(), r.UserData()
+	splitUserData(mdata, user)
+
+	if err := modify(user); err != nil {
+		return false, err
+	}
+
+	if err := checkUserData(user); err != nil {
+		return false, err
+	}
+	r.SetData(mdata)
+	r.SetUserData(user)
+
+	after := r.Flatted()
+	if reflect.DeepEqual(before, after) {
+		gs.log.Printf("'%s' is unchanged", key)
+		return false, nil
+	}
+
+	gs.ui.PrettyDiff(before, after)
+	if !gs.ui.Confirm(fmt.Sprintf("Update '%s'?", key)) {
+		return false, nil
+	}
+
+	if err := gs.runHooks("pre-update", r); err != nil {
+		return false, err
+	}
+
+	if !gs.pretend {
+		if err := gs.store.Update(key, r); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// isUserField reports whether field is part of the end-user's personal
+// information.
+func isUserField(field string) bool {
+	for _, f := range userFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// isUserListField reports whether a field of the end-user's personal
+// information holds a list of values.
+func isUserListField(field string) bool {
+	switch field {
+	case tagsField, shelvesField, readDatesField:
+		return true
+	}
+	return false
+}
+
+// splitUserData moves the end-user's personal information found in a
+// record's data (like for records stored before it was kept apart) to user.
+// Values already in user are kept.
+func splitUserData(mdata, user map[string]interface{}) {
+	for k, v := range mdata {
+		if !isUserField(k) {
+			continue
+		}
+
+		if _, exists := user[k]; !exists {
+			user[k] = v
+		}
+		delete(mdata, k)
+	}
+}
+
+// checkUserData verifies the end-user's personal information, converting
+// values to their expected types.
+func checkUserData(user map[string]interface{}) error {
+	for _, field := range []string{tagsField, shelvesField} {
+		if _, exists := user[field]; exists {
+			updateList(user, field, nil, false)
+		}
+	}
+
+	if v, exists := user[statusField]; exists {
+		status := fmt.Sprint(v)
+		if !containsFold(readingStatuses, status) {
+			return fmt.Errorf("unknown reading status '%s' (known status: %s)", status, strings.Join(readingStatuses, ", "))
+		}
+		user[statusField] = strings.ToLower(status)
+	}
+
+	if v, exists := user[ratingField]; exists {
+		rating, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || rating < 1 || rating > maxRating {
+			return fmt.Errorf("invalid rating '%v', rating should be between 1 and %d", v, maxRating)
+		}
+		user[ratingField] = rating
+	}
+
+	if v, exists := user[readDatesField]; exists {
+		var readDates []time.Time
+		for _, item := range valuesOf(v) {
+			switch item := item.(type) {
+			case time.Time:
+				readDates = append(readDates, item)
+			case []time.Time:
+				readDates = append(readDates, item...)
+			default:
+				t, err := util.ParseTime(fmt.Sprint(item))
+				if err != nil {
+					return fmt.Errorf("invalid read date '%v': %s", item, err)
+				}
+				readDates = append(readDates, t)
+			}
+		}
+
+		if len(readDates) == 0 {
+			delete(user, readDatesField)
+		} else {
+			user[readDatesField] = readDates
+		}
+	}
+
+	return nil
+}
+
+// updateList adds values to (or removes values from, if remove is set) the
+// list of values of a field. The field is removed once empty.
+func updateList(m map[string]interface{}, field string, values []string, remove bool) {
+	var list []string
+	for _, v := range valuesOf(m[field]) {
+		list = append(list, fmt.Sprint(v))
+	}
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+
+		switch {
+		case v == "":
+		case remove:
+			list = removeString(list, v)
+		case !containsFold(list, v):
+			list = append(list, v)
+		}
+	}
+
+	if len(list) == 0 {
+		delete(m, field)
+		return
+	}
+	m[field] = list
+}
+
+func removeString(list []string, s string) []string {
+	var kept []string
+	for _, item := range list {
+		if !strings.EqualFold(item, s) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// today returns the current date, at midnight.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
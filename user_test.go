@@ -0,0 +1,194 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pirmd/verify"
+)
+
+func TestUserData(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	testData := map[string]map[string]interface{}{
+		"a.epub": {"Type": "book/epub", "Title": "Les misérables", "Authors": []string{"Victor Hugo"}},
+		"b.epub": {"Type": "book/epub", "Title": "Les fleurs du mal", "Authors": []string{"Charles Baudelaire"}},
+	}
+	for key, data := range testData {
+		if _, err := gs.store.Create(key, data, verify.MockROFile("0123456789")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	if err := gs.Tag([]string{"classic", "to sort"}, false, []string{"*.epub"}, ""); err != nil {
+		t.Fatalf("Fail to tag records: %v", err)
+	}
+	if err := gs.Tag([]string{"to sort"}, true, nil, "Authors:Hugo"); err != nil {
+		t.Fatalf("Fail to untag records: %v", err)
+	}
+	if err := gs.Shelve([]string{"French"}, false, []string{"a.epub"}, ""); err != nil {
+		t.Fatalf("Fail to shelve records: %v", err)
+	}
+	if err := gs.SetReadingStatus("read", "2021-01-02", []string{"a.epub"}, ""); err != nil {
+		t.Fatalf("Fail to set reading status: %v", err)
+	}
+	if err := gs.SetReadingStatus("to-read", "", []string{"b.epub"}, ""); err != nil {
+		t.Fatalf("Fail to set reading status: %v", err)
+	}
+	if err := gs.Rate(4, []string{"a.epub"}, ""); err != nil {
+		t.Fatalf("Fail to rate records: %v", err)
+	}
+
+	if err := gs.SetReadingStatus("done", "", []string{"a.epub"}, ""); err == nil {
+		t.Errorf("Setting an unknown reading status should fail")
+	}
+	if err := gs.Rate(6, []string{"a.epub"}, ""); err == nil {
+		t.Errorf("Rating above %d should fail", maxRating)
+	}
+	if err := gs.Tag([]string{"classic"}, false, nil, ""); err == nil {
+		t.Errorf("Tagging without selecting records should fail")
+	}
+
+	want := map[string]map[string]interface{}{
+		"a.epub": {
+			"Tags":          []string{"classic"},
+			"Shelves":       []string{"French"},
+			"ReadingStatus": "read",
+			"ReadDates":     []time.Time{time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
+			"Rating":        4,
+		},
+		"b.epub": {
+			"Tags":          []string{"classic", "to sort"},
+			"ReadingStatus": "to-read",
+		},
+	}
+
+	for key, w := range want {
+		r, err := gs.store.Read(key)
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		if got := r.UserData(); !reflect.DeepEqual(got, w) {
+			t.Errorf("Fail to update user data of '%s':\nwant: %#v\ngot : %#v", key, w, got)
+		}
+
+		if _, exists := r.Data()[tagsField]; exists {
+			t.Errorf("User data of '%s' should not be part of its metadata", key)
+		}
+	}
+
+	t.Run("Kept when replacing metadata", func(t *testing.T) {
+		r, err := gs.store.Read("a.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		if err := gs.update(r, map[string]interface{}{"Type": "book/epub", "Title": "Les Misérables"}); err != nil {
+			t.Fatalf("Fail to update record: %v", err)
+		}
+
+		r, err = gs.store.Read("a.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+		if !reflect.DeepEqual(r.UserData(), want["a.epub"]) {
+			t.Errorf("User data are modified when replacing metadata: got %#v", r.UserData())
+		}
+	})
+
+	t.Run("Searchable", func(t *testing.T) {
+		for query, keys := range map[string][]string{
+			"Tags:classic":              {"a.epub", "b.epub"},
+			"Shelves:French":            {"a.epub"},
+			"ReadingStatus:\"to-read\"": {"b.epub"},
+			"Rating:>=4":                {"a.epub"},
+		} {
+			r, err := gs.store.ReadQuery(query)
+			if err != nil {
+				t.Fatalf("Fail to search for '%s': %v", query, err)
+			}
+
+			if failure := verify.EqualSliceWithoutOrder(r.Key(), keys); failure != nil {
+				t.Errorf("Fail to search for '%s':\n%v", query, failure)
+			}
+		}
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		if err := gs.Set([]string{"Rating:3", "Tags+=poetry"}, []string{"b.epub"}, ""); err != nil {
+			t.Fatalf("Fail to set fields: %v", err)
+		}
+
+		r, err := gs.store.Read("b.epub")
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		if r.UserData()[ratingField] != 3 || !reflect.DeepEqual(r.UserData()[tagsField], []string{"classic", "to sort", "poetry"}) {
+			t.Errorf("Fail to set user data: got %#v", r.UserData())
+		}
+		if _, exists := r.Data()[ratingField]; exists {
+			t.Errorf("Set should not store user data as metadata")
+		}
+	})
+}
+
+func TestUserDataInMetadata(t *testing.T) {
+	gs := newTestGostore(t, newConfig())
+	defer gs.Close()
+
+	stdout, err := verify.StartMockStdout()
+	if err != nil {
+		t.Fatalf("Fail to mock stdout: %v", err)
+	}
+	defer stdout.Stop()
+
+	// Records stored before personal information was kept apart
+	testData := map[string]map[string]interface{}{
+		"a.epub": {"Title": "Les misérables", "Tags": []string{"classic", "to sort"}},
+		"b.epub": {"Title": "Les fleurs du mal", "Tags": []string{"classic", "to sort"}, "Rating": 3},
+	}
+	for key, data := range testData {
+		if _, err := gs.store.Create(key, data, verify.MockROFile("0123456789")); err != nil {
+			t.Fatalf("Fail to add %s: %v", key, err)
+		}
+	}
+
+	if err := gs.Tag([]string{"to sort"}, true, []string{"a.epub"}, ""); err != nil {
+		t.Fatalf("Fail to untag records: %v", err)
+	}
+	if err := gs.Set([]string{"Tags-=to sort"}, []string{"b.epub"}, ""); err != nil {
+		t.Fatalf("Fail to set fields: %v", err)
+	}
+
+	want := map[string]map[string]interface{}{
+		"a.epub": {"Tags": []string{"classic"}},
+		"b.epub": {"Tags": []string{"classic"}, "Rating": 3},
+	}
+
+	for key, w := range want {
+		r, err := gs.store.Read(key)
+		if err != nil {
+			t.Fatalf("Fail to read record: %v", err)
+		}
+
+		if got := r.UserData(); !reflect.DeepEqual(got, w) {
+			t.Errorf("Fail to update user data of '%s':\nwant: %#v\ngot : %#v", key, w, got)
+		}
+
+		for _, field := range userFields {
+			if _, exists := r.Data()[field]; exists {
+				t.Errorf("User data of '%s' should be moved out of its metadata", key)
+			}
+		}
+	}
+}